@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/dtroode/gophermart/internal/accrualmock"
+)
+
+// accrual-mock stands in for accrual system in local development and CI.
+// Orders follow scenarios from file given with -f, which can be changed
+// at runtime through /control API.
+func main() {
+	addr := flag.String("a", ":8081", "(address and) port to run server")
+	file := flag.String("f", "", "JSON or YAML file with scenarios")
+	flag.Parse()
+
+	scenarios := &accrualmock.Scenarios{}
+	if *file != "" {
+		var err error
+		scenarios, err = accrualmock.LoadScenarios(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Printf("accrual mock started on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, accrualmock.New(scenarios)))
+}
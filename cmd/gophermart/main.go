@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/dtroode/gophermart/config"
@@ -14,7 +17,10 @@ import (
 	"github.com/dtroode/gophermart/internal/application/service"
 	"github.com/dtroode/gophermart/internal/auth"
 	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/poller"
 	"github.com/dtroode/gophermart/internal/postgres"
+	"github.com/dtroode/gophermart/internal/reconciler"
+	"github.com/dtroode/gophermart/internal/rotator"
 	"github.com/dtroode/gophermart/internal/workerpool"
 )
 
@@ -35,6 +41,11 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+
+// @securityDefinitions.apikey Admin
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and admin token.
 func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
@@ -60,23 +71,204 @@ func main() {
 		uint32(cfg.ArgonKeyLen),
 	)
 
-	jwt := auth.NewJWT(cfg.JWTSecretKey)
+	keyEncryptionKey, err := cfg.KeyEncryptionKey()
+	if err != nil {
+		log.Error("invalid key encryption key", "error", err)
+		os.Exit(1)
+	}
+
+	jwt, err := auth.NewJWT(cfg.JWTAlgorithm, cfg.AccessTokenTTL, keyEncryptionKey)
+	if err != nil {
+		log.Error("failed to create token manager", "error", err)
+		os.Exit(1)
+	}
+
+	accrualClientConfig := accrual.ClientConfig{
+		Timeout:               cfg.AccrualTimeout,
+		DialTimeout:           cfg.AccrualDialTimeout,
+		TLSHandshakeTimeout:   cfg.AccrualTLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.AccrualResponseHeaderTimeout,
+		MaxIdleConns:          cfg.AccrualMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.AccrualMaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.AccrualMaxConnsPerHost,
+		IdleConnTimeout:       cfg.AccrualIdleConnTimeout,
+		CAFile:                cfg.AccrualCAFile,
+		CertFile:              cfg.AccrualCertFile,
+		KeyFile:               cfg.AccrualKeyFile,
+	}
+	accrualClient, err := accrual.NewClient(accrualClientConfig)
+	if err != nil {
+		log.Error("failed to create accrual client", "error", err)
+		os.Exit(1)
+	}
 
-	accrualAdapter := accrual.NewAdapter(cfg.AccrualAddr)
+	newBreaker := func(provider string) *accrual.Breaker {
+		return accrual.NewBreaker(
+			cfg.AccrualBreakerFailures,
+			cfg.AccrualBreakerCoolDown,
+			cfg.AccrualBreakerProbes,
+			&logger.Logger{Logger: log.With("accrual_provider", provider)},
+		)
+	}
 
-	pool := workerpool.NewPool(cfg.ConcurrencyLimit, cfg.QueueSize)
+	accrualRouter := accrual.NewRouter(accrual.NewAdapter(
+		cfg.AccrualAddr,
+		accrual.WithClient(accrualClient),
+		accrual.WithUserAgent(cfg.AccrualUserAgent),
+		accrual.WithLimiter(accrual.NewLimiter(cfg.AccrualRateLimit)),
+		accrual.WithBreaker(newBreaker(accrual.DefaultProvider)),
+	))
+
+	if cfg.AccrualProvidersFile != "" {
+		providers, err := accrual.LoadProviders(cfg.AccrualProvidersFile)
+		if err != nil {
+			log.Error("failed to load accrual providers", "error", err)
+			os.Exit(1)
+		}
+
+		for _, p := range providers {
+			rules, err := p.CompileRules()
+			if err != nil {
+				log.Error("failed to load accrual providers", "error", err)
+				os.Exit(1)
+			}
+
+			adapter, err := p.NewAdapter(
+				accrualClientConfig,
+				accrual.WithUserAgent(cfg.AccrualUserAgent),
+				accrual.WithBreaker(newBreaker(p.Name)),
+			)
+			if err != nil {
+				log.Error("failed to create accrual adapter", "provider", p.Name, "error", err)
+				os.Exit(1)
+			}
+
+			if err := accrualRouter.Add(p.Name, adapter, rules...); err != nil {
+				log.Error("failed to add accrual provider", "error", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	pool := workerpool.NewPool(
+		cfg.ConcurrencyLimit,
+		cfg.QueueSize,
+		workerpool.WithKeyLimit(cfg.UserConcurrency),
+		workerpool.WithKeyQueueLimit(cfg.UserQueueLimit),
+		workerpool.WithLogger(log),
+	)
 	pool.Start()
 
-	srv := service.NewService(store, argon, jwt, accrualAdapter, pool)
+	credentialPolicy, err := auth.NewCredentialPolicy(auth.CredentialPolicyConfig{
+		LoginMinLength:        cfg.LoginMinLength,
+		LoginMaxLength:        cfg.LoginMaxLength,
+		LoginPattern:          cfg.LoginPattern,
+		PasswordMinLength:     cfg.PasswordMinLength,
+		PasswordMinClasses:    cfg.PasswordMinClasses,
+		BreachedPasswordsFile: cfg.BreachedPasswordsFile,
+	})
+	if err != nil {
+		log.Error("failed to create credential policy", "error", err)
+		os.Exit(1)
+	}
+
+	serviceOpts := []service.Option{
+		service.WithPollBatchSize(cfg.PollBatchSize),
+		service.WithLogger(log),
+		service.WithOrderLease(cfg.OrderLease),
+		service.WithOrderRetryInterval(cfg.OrderRetryInterval),
+		service.WithMaxOrderAttempts(cfg.OrderMaxAttempts),
+		service.WithReconcileBatchSize(cfg.ReconcileBatchSize),
+		service.WithReconcileWindow(cfg.ReconcileWindow),
+		service.WithReconcilePeriod(cfg.ReconcilePeriod),
+		service.WithRefreshTokenTTL(cfg.RefreshTokenTTL),
+		service.WithSessionCheckInterval(cfg.SessionCheckInterval),
+		service.WithSigningKeyRotation(cfg.JWTKeyRotation),
+		service.WithSigningKeyPublishLead(cfg.JWTKeyPublishLead),
+		service.WithLoginThrottle(auth.NewThrottle("login", auth.ThrottleConfig{
+			FreeFailures: cfg.LoginFreeFailures,
+			Delay:        cfg.LoginThrottleDelay,
+			MaxDelay:     cfg.LoginMaxDelay,
+			Window:       cfg.LoginFailureWindow,
+		}, log)),
+		service.WithLoginClientThrottle(auth.NewThrottle("login_client", auth.ThrottleConfig{
+			FreeFailures:    cfg.LoginFreeFailures,
+			Delay:           cfg.LoginThrottleDelay,
+			LockoutFailures: cfg.LoginLockoutFailures,
+			Lockout:         cfg.LoginLockout,
+			Window:          cfg.LoginFailureWindow,
+		}, log)),
+		service.WithClientThrottle(auth.NewThrottle("client", auth.ThrottleConfig{
+			FreeFailures:    cfg.ClientFreeFailures,
+			Delay:           cfg.LoginThrottleDelay,
+			LockoutFailures: cfg.ClientLockoutFailures,
+			Lockout:         cfg.LoginLockout,
+			Window:          cfg.LoginFailureWindow,
+		}, log)),
+		service.WithCredentialPolicy(credentialPolicy),
+	}
+	if cfg.AccrualWebhookSecret != "" {
+		serviceOpts = append(serviceOpts, service.WithPushFallback(cfg.AccrualPushFallback))
+	}
+	if cfg.ReconcileAutoAdjust {
+		serviceOpts = append(serviceOpts, service.WithAutoAdjust())
+	}
+
+	srv := service.NewService(store, argon, jwt, accrualRouter, pool, serviceOpts...)
+
+	// tokens can't be signed until keys are loaded
+	if err := srv.RotateSigningKeys(context.Background()); err != nil {
+		log.Error("failed to rotate signing keys", "error", err)
+		os.Exit(1)
+	}
+
+	var pollers sync.WaitGroup
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	pollers.Add(1)
+	go func() {
+		defer pollers.Done()
+		poller.New(srv, cfg.PollInterval, log).Run(pollCtx)
+	}()
+	pollers.Add(1)
+	go func() {
+		defer pollers.Done()
+		rotator.New(srv, cfg.JWTKeyRotateInterval, log).Run(pollCtx)
+	}()
+	if cfg.ReconcileInterval > 0 {
+		pollers.Add(1)
+		go func() {
+			defer pollers.Done()
+			reconciler.New(srv, cfg.ReconcileInterval, log).Run(pollCtx)
+		}()
+	}
 
 	r := router.NewRouter()
 
-	r.RegisterRoutes(srv, jwt, log)
+	routerOpts := []router.Option{
+		router.WithAccrualBreaker(accrualRouter),
+		router.WithJWKS(jwt),
+	}
+	if cfg.AccrualWebhookSecret != "" {
+		routerOpts = append(routerOpts, router.WithAccrualEvents(cfg.AccrualWebhookSecret))
+	}
+	if cfg.TrustProxyHeaders {
+		routerOpts = append(routerOpts, router.WithRealIP())
+	}
+	if cfg.AdminToken != "" {
+		routerOpts = append(routerOpts, router.WithAdmin(cfg.AdminToken))
+	}
+
+	r.RegisterRoutes(srv, jwt, log, routerOpts...)
+
+	server := &http.Server{
+		Addr:    cfg.RunAddr,
+		Handler: r,
+	}
 
 	go func() {
 		log.Info("server started", "address", cfg.RunAddr)
-		err = http.ListenAndServe(cfg.RunAddr, r)
-		if err != nil {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error("error running server", "error", err)
 			os.Exit(1)
 		}
@@ -84,5 +276,26 @@ func main() {
 
 	<-sigChan
 	log.Info("received interruption signal, exitting")
-	pool.Stop()
+
+	// requests are drained first since they submit jobs, then pollers stop claiming orders,
+	// then the pool finishes or hands back claimed orders while the database is still open
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to shut down server", "error", err)
+	}
+
+	stopPolling()
+	pollers.Wait()
+
+	if err := pool.Shutdown(shutdownCtx); err != nil {
+		log.Error("worker pool didn't finish jobs in time", "error", err)
+	}
+
+	if err := store.Close(); err != nil {
+		log.Error("failed to close db conn", "error", err)
+	}
+
+	log.Info("server stopped")
 }
@@ -1,20 +1,91 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
 	"flag"
+	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
 
 type Config struct {
-	RunAddr      string `env:"RUN_ADDRESS"`
-	DatabaseDSN  string `env:"DATABASE_URI"`
-	AccrualAddr  string `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	LogLevel     string `env:"LOG_LEVEL"`
-	JWTSecretKey string `env:"JWT_SECRET_KEY"`
+	RunAddr     string `env:"RUN_ADDRESS"`
+	DatabaseDSN string `env:"DATABASE_URI"`
+	AccrualAddr string `env:"ACCRUAL_SYSTEM_ADDRESS"`
+	LogLevel    string `env:"LOG_LEVEL"`
+	AdminToken  string `env:"ADMIN_TOKEN"`
+
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL"`
+	// SessionCheckInterval is for how long a session found active is not checked again.
+	SessionCheckInterval time.Duration `env:"SESSION_CHECK_INTERVAL"`
+
+	JWTAlgorithm         string        `env:"JWT_ALGORITHM"`
+	JWTKeyRotation       time.Duration `env:"JWT_KEY_ROTATION"`
+	JWTKeyPublishLead    time.Duration `env:"JWT_KEY_PUBLISH_LEAD"`
+	JWTKeyRotateInterval time.Duration `env:"JWT_KEY_ROTATE_INTERVAL"`
+	// JWTKeyEncryptionKey is base64 encoded 32 bytes key private signing keys are encrypted with.
+	JWTKeyEncryptionKey string `env:"JWT_KEY_ENCRYPTION_KEY"`
+
+	LoginFreeFailures     int           `env:"LOGIN_FREE_FAILURES"`
+	LoginLockoutFailures  int           `env:"LOGIN_LOCKOUT_FAILURES"`
+	ClientFreeFailures    int           `env:"CLIENT_FREE_FAILURES"`
+	ClientLockoutFailures int           `env:"CLIENT_LOCKOUT_FAILURES"`
+	LoginThrottleDelay    time.Duration `env:"LOGIN_THROTTLE_DELAY"`
+	LoginMaxDelay         time.Duration `env:"LOGIN_MAX_DELAY"`
+	LoginLockout          time.Duration `env:"LOGIN_LOCKOUT"`
+	LoginFailureWindow    time.Duration `env:"LOGIN_FAILURE_WINDOW"`
+	TrustProxyHeaders     bool          `env:"TRUST_PROXY_HEADERS"`
+
+	LoginMinLength        int    `env:"LOGIN_MIN_LENGTH"`
+	LoginMaxLength        int    `env:"LOGIN_MAX_LENGTH"`
+	LoginPattern          string `env:"LOGIN_PATTERN"`
+	PasswordMinLength     int    `env:"PASSWORD_MIN_LENGTH"`
+	PasswordMinClasses    int    `env:"PASSWORD_MIN_CLASSES"`
+	BreachedPasswordsFile string `env:"BREACHED_PASSWORDS_FILE"`
+
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT"`
+
+	AccrualRateLimit     int           `env:"ACCRUAL_RATE_LIMIT"`
+	AccrualWebhookSecret string        `env:"ACCRUAL_WEBHOOK_SECRET"`
+	AccrualPushFallback  time.Duration `env:"ACCRUAL_PUSH_FALLBACK"`
+
+	AccrualTimeout               time.Duration `env:"ACCRUAL_TIMEOUT"`
+	AccrualDialTimeout           time.Duration `env:"ACCRUAL_DIAL_TIMEOUT"`
+	AccrualTLSHandshakeTimeout   time.Duration `env:"ACCRUAL_TLS_HANDSHAKE_TIMEOUT"`
+	AccrualResponseHeaderTimeout time.Duration `env:"ACCRUAL_RESPONSE_HEADER_TIMEOUT"`
+	AccrualMaxIdleConns          int           `env:"ACCRUAL_MAX_IDLE_CONNS"`
+	AccrualMaxIdleConnsPerHost   int           `env:"ACCRUAL_MAX_IDLE_CONNS_PER_HOST"`
+	AccrualMaxConnsPerHost       int           `env:"ACCRUAL_MAX_CONNS_PER_HOST"`
+	AccrualIdleConnTimeout       time.Duration `env:"ACCRUAL_IDLE_CONN_TIMEOUT"`
+	AccrualCAFile                string        `env:"ACCRUAL_CA_FILE"`
+	AccrualCertFile              string        `env:"ACCRUAL_CERT_FILE"`
+	AccrualKeyFile               string        `env:"ACCRUAL_KEY_FILE"`
+	AccrualUserAgent             string        `env:"ACCRUAL_USER_AGENT"`
+	AccrualProvidersFile         string        `env:"ACCRUAL_PROVIDERS_FILE"`
+
+	AccrualBreakerFailures int           `env:"ACCRUAL_BREAKER_FAILURES"`
+	AccrualBreakerCoolDown time.Duration `env:"ACCRUAL_BREAKER_COOLDOWN"`
+	AccrualBreakerProbes   int           `env:"ACCRUAL_BREAKER_PROBES"`
 
 	ConcurrencyLimit int `env:"CONCURRENCY_LIMIT"`
 	QueueSize        int `env:"QUEUE_SIZE"`
+	UserConcurrency  int `env:"USER_CONCURRENCY_LIMIT"`
+	UserQueueLimit   int `env:"USER_QUEUE_LIMIT"`
+
+	PollInterval       time.Duration `env:"POLL_INTERVAL"`
+	PollBatchSize      int           `env:"POLL_BATCH_SIZE"`
+	OrderLease         time.Duration `env:"ORDER_LEASE"`
+	OrderRetryInterval time.Duration `env:"ORDER_RETRY_INTERVAL"`
+	OrderMaxAttempts   int           `env:"ORDER_MAX_ATTEMPTS"`
+
+	ReconcileInterval   time.Duration `env:"RECONCILE_INTERVAL"`
+	ReconcileBatchSize  int           `env:"RECONCILE_BATCH_SIZE"`
+	ReconcileWindow     time.Duration `env:"RECONCILE_WINDOW"`
+	ReconcilePeriod     time.Duration `env:"RECONCILE_PERIOD"`
+	ReconcileAutoAdjust bool          `env:"RECONCILE_AUTO_ADJUST"`
 
 	ArgonSalt    string `env:"ARGON_SALT"`
 	ArgonTime    int    `env:"ARGON_TIME"`
@@ -30,12 +101,72 @@ func Initialize() (*Config, error) {
 	flag.StringVar(&config.DatabaseDSN, "d", "", "string for connecting to postgres")
 	flag.StringVar(&config.AccrualAddr, "r", "", "accrual system address")
 	flag.StringVar(&config.LogLevel, "l", "DEBUG", "log level")
-	flag.StringVar(&config.JWTSecretKey, "j", "secret", "jwt secret key")
+	flag.StringVar(&config.AdminToken, "adm", "", "token for admin endpoints, empty disables them")
+	flag.DurationVar(&config.AccessTokenTTL, "jat", 15*time.Minute, "for how long access token is valid")
+	flag.DurationVar(&config.RefreshTokenTTL, "jrt", 30*24*time.Hour, "for how long refresh token can be exchanged for new tokens")
+	flag.DurationVar(&config.SessionCheckInterval, "sci", 10*time.Second, "for how long a session found active is not checked again, access tokens of sessions revoked on other instances work till then")
+	flag.StringVar(&config.JWTAlgorithm, "ja", "EdDSA", "algorithm of new access token signing keys, EdDSA or RS256")
+	flag.DurationVar(&config.JWTKeyRotation, "jkr", 24*time.Hour, "for how long a key signs access tokens before the next one replaces it")
+	flag.DurationVar(&config.JWTKeyPublishLead, "jkp", 1*time.Hour, "for how long the next signing key is published before it signs")
+	flag.DurationVar(&config.JWTKeyRotateInterval, "jki", 1*time.Minute, "interval between checks of signing keys")
+	flag.StringVar(&config.JWTKeyEncryptionKey, "jke", "", "base64 encoded 32 bytes key private signing keys are encrypted with")
+	flag.IntVar(&config.LoginFreeFailures, "lff", 3, "failed logins to one account that are not delayed")
+	flag.IntVar(&config.LoginLockoutFailures, "llf", 10, "failed logins to one account from one address that lock the account out for the address, 0 disables lockout")
+	flag.IntVar(&config.ClientFreeFailures, "cff", 10, "failed logins from one address that are not delayed")
+	flag.IntVar(&config.ClientLockoutFailures, "clf", 100, "failed logins that lock the address out, 0 disables lockout")
+	flag.DurationVar(&config.LoginThrottleDelay, "ltd", 1*time.Second, "delay after the first failed login over free ones, doubles with every next one")
+	flag.DurationVar(&config.LoginMaxDelay, "lmd", 30*time.Second, "max delay of failed logins to one account from all addresses, they never lock the account out")
+	flag.DurationVar(&config.LoginLockout, "llo", 15*time.Minute, "for how long too many failed logins lock the account or address out")
+	flag.DurationVar(&config.LoginFailureWindow, "lfw", 1*time.Hour, "for how long failed logins are remembered after the last one")
+	flag.BoolVar(&config.TrustProxyHeaders, "tph", false, "take client address from X-Forwarded-For and X-Real-IP, only behind a trusted proxy")
+	flag.IntVar(&config.LoginMinLength, "lmin", 1, "min login length in characters")
+	flag.IntVar(&config.LoginMaxLength, "lmax", 64, "max login length in characters, up to 64")
+	flag.StringVar(&config.LoginPattern, "lp", `[\p{L}\p{N}._@+-]+`, "regular expression the whole login must match, empty allows any characters")
+	flag.IntVar(&config.PasswordMinLength, "pmin", 8, "min password length in characters")
+	flag.IntVar(&config.PasswordMinClasses, "pcl", 1, "how many of lowercase letters, uppercase letters, digits and other characters password must contain")
+	flag.StringVar(&config.BreachedPasswordsFile, "pbf", "", "file with passwords known from breaches, one per line, which are not accepted")
+	flag.DurationVar(&config.ShutdownTimeout, "st", 30*time.Second, "time given to in-flight requests and jobs to finish on shutdown")
+
+	flag.IntVar(&config.AccrualRateLimit, "rl", 0, "initial accrual requests per minute limit, 0 means no limit")
+	flag.StringVar(&config.AccrualWebhookSecret, "ws", "", "secret for accrual events signature, empty disables push mode")
+	flag.DurationVar(&config.AccrualPushFallback, "pf", 5*time.Minute, "in push mode, poll orders that got no event within this time")
+
+	flag.DurationVar(&config.AccrualTimeout, "at", 10*time.Second, "timeout of the whole accrual request")
+	flag.DurationVar(&config.AccrualDialTimeout, "adt", 5*time.Second, "timeout of connecting to accrual system")
+	flag.DurationVar(&config.AccrualTLSHandshakeTimeout, "att", 5*time.Second, "timeout of TLS handshake with accrual system")
+	flag.DurationVar(&config.AccrualResponseHeaderTimeout, "art", 5*time.Second, "timeout of waiting for accrual response headers")
+	flag.IntVar(&config.AccrualMaxIdleConns, "amic", 100, "max idle connections to accrual system")
+	flag.IntVar(&config.AccrualMaxIdleConnsPerHost, "amich", 10, "max idle connections per accrual host")
+	flag.IntVar(&config.AccrualMaxConnsPerHost, "amch", 0, "max connections per accrual host, 0 means no limit")
+	flag.DurationVar(&config.AccrualIdleConnTimeout, "aict", 90*time.Second, "how long idle connection to accrual system is kept")
+	flag.StringVar(&config.AccrualCAFile, "aca", "", "PEM file with CA trusted for accrual system")
+	flag.StringVar(&config.AccrualCertFile, "acert", "", "PEM file with client certificate for accrual system")
+	flag.StringVar(&config.AccrualKeyFile, "akey", "", "PEM file with client key for accrual system")
+	flag.StringVar(&config.AccrualUserAgent, "aua", "gophermart", "user agent of requests to accrual system")
+	flag.StringVar(&config.AccrualProvidersFile, "apf", "", "JSON or YAML file with partner accrual providers and their routing rules")
+
+	flag.IntVar(&config.AccrualBreakerFailures, "bf", 5, "consecutive accrual failures that open circuit breaker")
+	flag.DurationVar(&config.AccrualBreakerCoolDown, "bc", 30*time.Second, "time circuit breaker stays open before probing accrual system")
+	flag.IntVar(&config.AccrualBreakerProbes, "bp", 1, "successful probes that close circuit breaker")
 
 	flag.IntVar(&config.ConcurrencyLimit, "cl", 5, "number of workers in pool")
 	flag.IntVar(&config.QueueSize, "qs", 0, "length of queue of jobs")
+	flag.IntVar(&config.UserConcurrency, "ucl", 0, "number of orders of one user checked at once, 0 means no limit")
+	flag.IntVar(&config.UserQueueLimit, "uql", 10, "number of orders of one user waiting in queue, 0 means no limit")
+
+	flag.DurationVar(&config.PollInterval, "pi", 1*time.Second, "interval between polls of due orders")
+	flag.IntVar(&config.PollBatchSize, "pb", 100, "number of orders claimed by one poll")
+	flag.DurationVar(&config.OrderLease, "ol", 1*time.Minute, "for how long a claimed order is hidden from other pollers")
+	flag.DurationVar(&config.OrderRetryInterval, "ori", 1*time.Second, "delay between checks of an order")
+	flag.IntVar(&config.OrderMaxAttempts, "oma", 100, "failed checks in a row after which order is moved to dead letters, 0 means no limit")
 
-	flag.StringVar(&config.ArgonSalt, "as", "saltsalt", "argon salt")
+	flag.DurationVar(&config.ReconcileInterval, "rci", 10*time.Minute, "interval between reconciliations of finalized orders, 0 disables them")
+	flag.IntVar(&config.ReconcileBatchSize, "rcb", 100, "number of orders reconciled at once")
+	flag.DurationVar(&config.ReconcileWindow, "rcw", 7*24*time.Hour, "for how long after finalization orders are reconciled")
+	flag.DurationVar(&config.ReconcilePeriod, "rcp", 24*time.Hour, "how often the same order is reconciled")
+	flag.BoolVar(&config.ReconcileAutoAdjust, "rca", false, "correct orders that don't match accrual system without an administrator")
+
+	flag.StringVar(&config.ArgonSalt, "as", "saltsalt", "argon salt of legacy password hashes")
 	flag.IntVar(&config.ArgonTime, "atime", 1, "argon time parameter")
 	flag.IntVar(&config.ArgonMemory, "amem", 47104, "argon memory parameter")
 	flag.IntVar(&config.ArgonThreads, "athreads", 1, "argon threads parameter")
@@ -48,5 +179,29 @@ func Initialize() (*Config, error) {
 		return nil, err
 	}
 
+	if _, err := config.KeyEncryptionKey(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
+
+// keyEncryptionKeyLen is the length of AES-256 key private signing keys are encrypted with.
+const keyEncryptionKeyLen = 32
+
+// KeyEncryptionKey decodes JWTKeyEncryptionKey, which is required.
+func (c *Config) KeyEncryptionKey() ([]byte, error) {
+	if c.JWTKeyEncryptionKey == "" {
+		return nil, errors.New("JWT_KEY_ENCRYPTION_KEY (-jke) is required: base64 encoded 32 bytes key private signing keys are encrypted with")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(c.JWTKeyEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY (-jke) is not valid base64: %w", err)
+	}
+	if len(key) != keyEncryptionKeyLen {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY (-jke) must be %d bytes long, got %d", keyEncryptionKeyLen, len(key))
+	}
+
+	return key, nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_KeyEncryptionKey(t *testing.T) {
+	tests := map[string]struct {
+		key         string
+		expectedLen int
+		expectedErr string
+	}{
+		"missing": {
+			expectedErr: "JWT_KEY_ENCRYPTION_KEY (-jke) is required",
+		},
+		"not base64": {
+			key:         "not a key",
+			expectedErr: "JWT_KEY_ENCRYPTION_KEY (-jke) is not valid base64",
+		},
+		"too short": {
+			key:         "MDEyMzQ1Njc4OWFiY2RlZg==",
+			expectedErr: "JWT_KEY_ENCRYPTION_KEY (-jke) must be 32 bytes long, got 16",
+		},
+		"valid": {
+			key:         "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+			expectedLen: 32,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			c := &Config{JWTKeyEncryptionKey: tt.key}
+
+			key, err := c.KeyEncryptionKey()
+
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, key, tt.expectedLen)
+		})
+	}
+}
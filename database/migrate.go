@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"embed"
 
+	_ "github.com/dtroode/gophermart/database/migrations" // go migrations
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN attempts integer NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN next_attempt_at timestamptz DEFAULT now();
+ALTER TABLE orders ADD COLUMN last_error text;
+
+CREATE INDEX IF NOT EXISTS orders_pending_idx ON orders (next_attempt_at)
+WHERE status IN ('NEW', 'PROCESSING');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_pending_idx;
+
+ALTER TABLE orders DROP COLUMN last_error;
+ALTER TABLE orders DROP COLUMN next_attempt_at;
+ALTER TABLE orders DROP COLUMN attempts;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS order_events (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id uuid NOT NULL references orders(id),
+    created_at timestamptz NOT NULL DEFAULT clock_timestamp(),
+    type varchar(32) NOT NULL,
+    status order_status,
+    accrual integer,
+    error text
+);
+
+CREATE INDEX IF NOT EXISTS order_events_order_id_idx ON order_events (order_id, created_at);
+
+INSERT INTO order_events (order_id, created_at, type, status)
+SELECT id, created_at, 'UPLOADED', 'NEW' FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_events;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders ADD COLUMN reconciled_at timestamptz;
+-- orders are leased while they are compared, so a failed comparison is retried after the lease
+ALTER TABLE orders ADD COLUMN reconcile_lease_until timestamptz;
+
+CREATE TABLE IF NOT EXISTS order_discrepancies (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id uuid NOT NULL references orders(id),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    status order_status NOT NULL,
+    accrual integer NOT NULL,
+    expected_status varchar(32) NOT NULL,
+    expected_accrual integer NOT NULL,
+    adjusted_at timestamptz,
+    adjustment integer,
+    reason text
+);
+
+-- an order has at most one discrepancy waiting for adjustment
+CREATE UNIQUE INDEX IF NOT EXISTS order_discrepancies_open_idx ON order_discrepancies (order_id)
+WHERE adjusted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_discrepancies;
+
+ALTER TABLE orders DROP COLUMN reconcile_lease_until;
+ALTER TABLE orders DROP COLUMN reconciled_at;
+-- +goose StatementEnd
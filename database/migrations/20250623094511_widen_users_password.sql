@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN password TYPE varchar(256);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN password TYPE varchar(64);
+-- +goose StatementEnd
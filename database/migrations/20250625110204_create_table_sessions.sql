@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sessions (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id uuid NOT NULL DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL references users(id),
+    token_hash varchar(64) NOT NULL UNIQUE,
+    user_agent text NOT NULL DEFAULT '',
+    started_at timestamptz NOT NULL DEFAULT now(),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    rotated_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS sessions_family_idx ON sessions (family_id);
+CREATE INDEX IF NOT EXISTS sessions_user_idx ON sessions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
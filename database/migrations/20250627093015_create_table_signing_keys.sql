@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id uuid PRIMARY KEY,
+    algorithm varchar(16) NOT NULL,
+    -- PKCS #8 key encrypted with AES-GCM under the key encryption key, nonce first
+    private_key bytea NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    active_at timestamptz NOT NULL,
+    retire_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS signing_keys;
+-- +goose StatementEnd
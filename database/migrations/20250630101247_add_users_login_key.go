@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddUsersLoginKey, downAddUsersLoginKey)
+}
+
+type userLogin struct {
+	id    string
+	login string
+}
+
+// upAddUsersLoginKey adds login keys logins are unique by and makes them for existing users
+// in Go, as lower and normalize of postgres don't fold case the way the service does.
+func upAddUsersLoginKey(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE users ADD COLUMN login_key varchar(256)`); err != nil {
+		return fmt.Errorf("failed to add login key column: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, login FROM users ORDER BY created_at, id`)
+	if err != nil {
+		return fmt.Errorf("failed to select users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []userLogin
+	for rows.Next() {
+		var u userLogin
+		if err := rows.Scan(&u.id, &u.login); err != nil {
+			return fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select users: %w", err)
+	}
+
+	ids, keys := loginKeys(users)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET login_key = k.key
+		FROM unnest($1::uuid[], $2::text[]) AS k(id, key)
+		WHERE users.id = k.id`,
+		ids, keys,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set login keys: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS users_login_key_idx ON users (login_key)`); err != nil {
+		return fmt.Errorf("failed to create login key index: %w", err)
+	}
+
+	return nil
+}
+
+func downAddUsersLoginKey(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `DROP INDEX IF EXISTS users_login_key_idx`); err != nil {
+		return fmt.Errorf("failed to drop login key index: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE users DROP COLUMN IF EXISTS login_key`); err != nil {
+		return fmt.Errorf("failed to drop login key column: %w", err)
+	}
+
+	return nil
+}
+
+// loginKeys returns keys of users ordered from the oldest. Of logins with the same key
+// the oldest gets it, others are found by exact login only.
+func loginKeys(users []userLogin) ([]string, []string) {
+	ids := make([]string, 0, len(users))
+	keys := make([]string, 0, len(users))
+	seen := make(map[string]struct{}, len(users))
+	for _, u := range users {
+		key := loginKey(u.login)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		ids = append(ids, u.id)
+		keys = append(keys, key)
+	}
+
+	return ids, keys
+}
+
+// loginKey is the key the service made logins unique by when the migration was written.
+// It is a copy, so the migration does the same whatever the service does later.
+func loginKey(login string) string {
+	return norm.NFKC.String(cases.Fold().String(norm.NFKC.String(login)))
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- due orders of every user are claimed separately, so users with a large backlog don't hold up the others
+CREATE INDEX IF NOT EXISTS orders_user_pending_idx ON orders (user_id, next_attempt_at)
+WHERE status IN ('NEW', 'PROCESSING');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_user_pending_idx;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- errors are classified for users when they happen, the error text itself may leak internals
+ALTER TABLE order_events ADD COLUMN IF NOT EXISTS public_error text;
+
+UPDATE order_events SET public_error = CASE
+    WHEN error LIKE '%order is not registered%' THEN 'order is not registered in accrual system yet'
+    WHEN error LIKE '%too many requests%' OR error LIKE '%accrual service is unavailable%'
+        THEN 'accrual system is temporarily unavailable, order will be checked later'
+    ELSE 'failed to check order in accrual system'
+END
+WHERE error IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_events DROP COLUMN IF EXISTS public_error;
+-- +goose StatementEnd
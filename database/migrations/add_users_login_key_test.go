@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/dtroode/gophermart/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginKeys(t *testing.T) {
+	users := []userLogin{
+		{id: "1", login: "Straße"},
+		{id: "2", login: "ＵＳＥＲ"},
+		{id: "3", login: "José"},
+		{id: "4", login: "Σίσυφος"},
+		// newer logins with taken keys get none
+		{id: "5", login: "STRASSE"},
+		{id: "6", login: "user"},
+	}
+
+	ids, keys := loginKeys(users)
+
+	assert.Equal(t, []string{"1", "2", "3", "4"}, ids)
+	// keys differ from lower(normalize(login, NFKC)) of postgres
+	assert.Equal(t, []string{"strasse", "user", "josé", "σίσυφοσ"}, keys)
+}
+
+// TestLoginKey_MatchesService fails when the service changes how it makes login keys,
+// such a change needs a new migration making keys of existing users again.
+func TestLoginKey_MatchesService(t *testing.T) {
+	p, err := auth.NewCredentialPolicy(auth.CredentialPolicyConfig{LoginMaxLength: 64})
+	require.NoError(t, err)
+
+	for _, login := range []string{"Straße", "ＵＳＥＲ", "José", "Σίσυφος", "ǅemal", "ﬁ"} {
+		_, key := p.NormalizeLogin(login)
+		assert.Equal(t, key, loginKey(login), login)
+	}
+}
@@ -22,6 +22,476 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/orders/dead-letters": {
+            "get": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Get orders that failed processing and are not checked anymore",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List order dead letters",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.OrderDeadLetter"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No dead letters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/dead-letters/retry": {
+            "post": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Schedule all dead lettered orders for a check",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Retry all order dead letters",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.RetriedOrders"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/dead-letters/{number}/invalidate": {
+            "post": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Mark dead lettered order invalid, so it is never checked again",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Invalidate order dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order invalidated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/dead-letters/{number}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Schedule dead lettered order for a check",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Retry order dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Order scheduled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/discrepancies": {
+            "get": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Get finalized orders that don't match accrual system, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List order discrepancies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.OrderDiscrepancy"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No discrepancies",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/discrepancies/{id}/adjust": {
+            "post": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Correct the order to match accrual system and credit or debit the difference to the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Adjust order discrepancy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Discrepancy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Adjustment details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.AdjustOrderDiscrepancy"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.OrderDiscrepancy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Discrepancy not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Already adjusted or user balance is not enough",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Empty reason or order is not final in accrual system",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/workerpool": {
+            "get": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Get load of the pool checking orders",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get worker pool stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.WorkerPoolStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Admin": []
+                    }
+                ],
+                "description": "Change the number of workers checking orders without dropping queued jobs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Resize worker pool",
+                "parameters": [
+                    {
+                        "description": "New size",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.ResizeWorkerPool"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.WorkerPoolStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Size is not positive",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/accrual/events": {
+            "post": {
+                "description": "Receive order status update pushed by accrual system. Timestamp and body joined with \".\" must be signed with HMAC-SHA256 using shared secret, requests signed more than 5 minutes ago are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Accrual order event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hex encoded HMAC-SHA256 of the timestamp and the body",
+                        "name": "X-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix time in seconds the request was signed at",
+                        "name": "X-Signature-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Order status in accrual system",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_model.AccrualOrder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event applied",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong or stale signature",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/user/balance": {
             "get": {
                 "security": [
@@ -148,25 +618,294 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.Login"
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.Login"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access token is also sent as Bearer token in Authorization header",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.Tokens"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, retry after the time in Retry-After header",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revoke the session of the access token, its refresh token can't be used anymore",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Logout user",
+                "responses": {
+                    "200": {
+                        "description": "Session revoked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get all orders for the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List user orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.UserOrder"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "No orders found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Upload a new order for the authenticated user",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Upload order",
+                "parameters": [
+                    {
+                        "description": "Order number",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "202": {
+                        "description": "Order accepted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Order registered by another user",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid order number",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Order saved, but service is busy to check it now",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/orders/{number}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get order of the authenticated user with its processing timeline",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get user order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.UserOrderDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "description": "Register a new user in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register new user",
+                "parameters": [
+                    {
+                        "description": "User registration details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.RegisterUser"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Bearer token in Authorization header",
+                        "description": "Access token is also sent as Bearer token in Authorization header",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.Tokens"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid input, credentials that violate policy come with the broken rules",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.PolicyViolations"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "User already exists",
                         "schema": {
                             "type": "string"
                         }
@@ -180,33 +919,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/orders": {
+        "/user/sessions": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get all orders for the authenticated user",
+                "description": "Get devices the authenticated user is signed in on",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "auth"
                 ],
-                "summary": "List user orders",
+                "summary": "List user sessions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.UserOrder"
+                                "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.UserSession"
                             }
                         }
                     },
                     "204": {
-                        "description": "No orders found",
+                        "description": "No sessions found",
                         "schema": {
                             "type": "string"
                         }
@@ -225,49 +964,69 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Upload a new order for the authenticated user",
-                "consumes": [
-                    "text/plain"
+                "description": "Sign the authenticated user out on all devices",
+                "tags": [
+                    "auth"
                 ],
-                "produces": [
-                    "application/json"
+                "summary": "Revoke all user sessions",
+                "responses": {
+                    "200": {
+                        "description": "Sessions revoked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Sign the authenticated user out on the device of the session",
                 "tags": [
-                    "orders"
+                    "auth"
                 ],
-                "summary": "Upload order",
+                "summary": "Revoke user session",
                 "parameters": [
                     {
-                        "description": "Order number",
-                        "name": "order",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order already exists",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "202": {
-                        "description": "Order accepted",
+                        "description": "Session revoked",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid session ID",
                         "schema": {
                             "type": "string"
                         }
@@ -278,14 +1037,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Order registered by another user",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "422": {
-                        "description": "Invalid order number",
+                    "404": {
+                        "description": "Session not found",
                         "schema": {
                             "type": "string"
                         }
@@ -299,9 +1052,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/register": {
+        "/user/token/refresh": {
             "post": {
-                "description": "Register a new user in the system",
+                "description": "Exchange refresh token for new access and refresh tokens. Each refresh token can be exchanged once, using it again signs out the device.",
                 "consumes": [
                     "application/json"
                 ],
@@ -311,23 +1064,23 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Register new user",
+                "summary": "Refresh tokens",
                 "parameters": [
                     {
-                        "description": "User registration details",
+                        "description": "Refresh token",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.RegisterUser"
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_api_http_request.RefreshToken"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Bearer token in Authorization header",
+                        "description": "Access token is also sent as Bearer token in Authorization header",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.Tokens"
                         }
                     },
                     "400": {
@@ -336,8 +1089,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "User already exists",
+                    "401": {
+                        "description": "Refresh token is expired, revoked or already used",
                         "schema": {
                             "type": "string"
                         }
@@ -399,6 +1152,15 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "github_com_dtroode_gophermart_internal_api_http_request.AdjustOrderDiscrepancy": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "Why the order is corrected, saved for audit\nRequired: true",
+                    "type": "string"
+                }
+            }
+        },
         "github_com_dtroode_gophermart_internal_api_http_request.Login": {
             "type": "object",
             "properties": {
@@ -412,6 +1174,15 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_dtroode_gophermart_internal_api_http_request.RefreshToken": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "description": "Refresh token issued at login or previous refresh\nRequired: true",
+                    "type": "string"
+                }
+            }
+        },
         "github_com_dtroode_gophermart_internal_api_http_request.RegisterUser": {
             "type": "object",
             "properties": {
@@ -425,6 +1196,15 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_dtroode_gophermart_internal_api_http_request.ResizeWorkerPool": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "description": "Number of workers\nRequired: true",
+                    "type": "integer"
+                }
+            }
+        },
         "github_com_dtroode_gophermart_internal_api_http_request.WithdrawBonuses": {
             "type": "object",
             "properties": {
@@ -438,6 +1218,161 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_dtroode_gophermart_internal_application_model.AccrualOrder": {
+            "type": "object",
+            "required": [
+                "order",
+                "status"
+            ],
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_model.AccrualOrderStatus"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_model.AccrualOrderStatus": {
+            "type": "string",
+            "enum": [
+                "REGISTERED",
+                "INVALID",
+                "PROCESSING",
+                "PROCESSED"
+            ],
+            "x-enum-varnames": [
+                "AccrualOrderStatusRegistered",
+                "AccrualOrderStatusInvalid",
+                "AccrualOrderStatusProcessing",
+                "AccrualOrderStatusProcessed"
+            ]
+        },
+        "github_com_dtroode_gophermart_internal_application_response.OrderDeadLetter": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.OrderDiscrepancy": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "adjusted_at": {
+                    "type": "string"
+                },
+                "adjustment": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expected_accrual": {
+                    "type": "number"
+                },
+                "expected_status": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.OrderEvent": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.PolicyViolation": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.PolicyViolations": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.PolicyViolation"
+                    }
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.RetriedOrders": {
+            "type": "object",
+            "properties": {
+                "retried": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.Tokens": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_dtroode_gophermart_internal_application_response.UserBalance": {
             "type": "object",
             "properties": {
@@ -466,6 +1401,52 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_dtroode_gophermart_internal_application_response.UserOrderDetails": {
+            "type": "object",
+            "properties": {
+                "accrual": {
+                    "type": "number"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "timeline": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dtroode_gophermart_internal_application_response.OrderEvent"
+                    }
+                },
+                "uploaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.UserSession": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_dtroode_gophermart_internal_application_response.UserWithdrawal": {
             "type": "object",
             "properties": {
@@ -479,9 +1460,50 @@ const docTemplate = `{
                     "type": "number"
                 }
             }
+        },
+        "github_com_dtroode_gophermart_internal_application_response.WorkerPoolStats": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "avg_run_time": {
+                    "type": "string"
+                },
+                "completed": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "joined": {
+                    "type": "integer"
+                },
+                "queue_size": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "scheduled": {
+                    "type": "integer"
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "Admin": {
+            "description": "Type \"Bearer\" followed by a space and admin token.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
         "Bearer": {
             "description": "Type \"Bearer\" followed by a space and JWT token.",
             "type": "apiKey",
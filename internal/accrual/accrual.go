@@ -4,21 +4,87 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/dtroode/gophermart/internal/application"
 	"github.com/dtroode/gophermart/internal/application/model"
 )
 
+// defaultRetryAfter is used when accrual system limits requests without telling for how long.
+const defaultRetryAfter = time.Minute
+
+var rateLimitRe = regexp.MustCompile(`(\d+)\s+requests?\s+per\s+minute`)
+
+type Option func(*Adapter)
+
+// WithLimiter makes adapter share limiter with other adapters.
+func WithLimiter(l *Limiter) Option {
+	return func(a *Adapter) {
+		a.limiter = l
+	}
+}
+
+// WithClient makes adapter send requests with c.
+func WithClient(c *http.Client) Option {
+	return func(a *Adapter) {
+		a.client = c
+	}
+}
+
+// WithUserAgent sets User-Agent header of requests to accrual system.
+func WithUserAgent(ua string) Option {
+	return func(a *Adapter) {
+		a.userAgent = ua
+	}
+}
+
+// WithBreaker stops requests while accrual system keeps failing.
+func WithBreaker(b *Breaker) Option {
+	return func(a *Adapter) {
+		a.breaker = b
+	}
+}
+
+// WithHeader adds header to every request to accrual system,
+// e.g. credentials of a partner provider.
+func WithHeader(key, value string) Option {
+	return func(a *Adapter) {
+		a.headers.Add(key, value)
+	}
+}
+
 type Adapter struct {
-	endpoint string
+	endpoint  string
+	client    *http.Client
+	userAgent string
+	headers   http.Header
+	limiter   *Limiter
+	breaker   *Breaker
 }
 
-func NewAdapter(endpoint string) *Adapter {
-	return &Adapter{
-		endpoint: endpoint,
+func NewAdapter(endpoint string, opts ...Option) *Adapter {
+	a := &Adapter{
+		endpoint:  endpoint,
+		userAgent: DefaultUserAgent,
+		headers:   http.Header{},
+		limiter:   NewLimiter(0),
+	}
+
+	for _, opt := range opts {
+		opt(a)
 	}
+
+	if a.client == nil {
+		// default config has no TLS files, so it can't fail
+		a.client, _ = NewClient(DefaultClientConfig())
+	}
+
+	return a
 }
 
 func (a *Adapter) GetOrder(ctx context.Context, orderNumber string) (*model.AccrualOrder, error) {
@@ -27,25 +93,98 @@ func (a *Adapter) GetOrder(ctx context.Context, orderNumber string) (*model.Accr
 		return nil, fmt.Errorf("failed to join path: %w", err)
 	}
 
-	resp, err := http.Get(u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range a.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", a.userAgent)
+
+	// requests rejected by the breaker don't take turns of the limiter
+	if a.breaker != nil && !a.breaker.Allow() {
+		return nil, application.ErrAccrualUnavailable
+	}
+
+	if err := a.limiter.Wait(ctx); err != nil {
+		if a.breaker != nil {
+			a.breaker.Cancel()
+		}
+		return nil, fmt.Errorf("failed to wait for rate limiter: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to request order: %w", err)
+		a.recordFailure(ctx)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to request order: %w", err)
+		}
+		// connection errors and client timeouts are the accrual system being unreachable
+		return nil, fmt.Errorf("%w: %w", application.ErrAccrualUnavailable, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		a.recordFailure(ctx)
+		return nil, application.ErrAccrualUnavailable
+	}
+	if a.breaker != nil {
+		a.breaker.Success()
+	}
+
 	switch resp.StatusCode {
 	case http.StatusOK:
 		order := &model.AccrualOrder{}
 		if err := json.NewDecoder(resp.Body).Decode(order); err != nil {
-			return nil, fmt.Errorf("failed to decode response: %w", err)
+			return nil, fmt.Errorf("%w: %w", application.ErrAccrualBadResponse, err)
 		}
 
 		return order, nil
 	case http.StatusNoContent:
 		return nil, application.ErrAccrualOrderNotRegistered
 	case http.StatusTooManyRequests:
+		a.throttle(resp)
 		return nil, application.ErrAccrualTooManyRequests
 	default:
 		return nil, application.ErrAccrualInternal
 	}
 }
+
+func (a *Adapter) recordFailure(ctx context.Context) {
+	if a.breaker == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		a.breaker.Cancel()
+		return
+	}
+	a.breaker.Failure()
+}
+
+// throttle pauses all requests for the time accrual system asked for
+// and adapts request rate to the limit from response body.
+func (a *Adapter) throttle(resp *http.Response) {
+	a.limiter.Pause(time.Now().Add(parseRetryAfter(resp.Header.Get("Retry-After"))))
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return
+	}
+	if match := rateLimitRe.FindSubmatch(body); match != nil {
+		if limit, err := strconv.Atoi(string(match[1])); err == nil {
+			a.limiter.SetRate(limit)
+		}
+	}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return defaultRetryAfter
+}
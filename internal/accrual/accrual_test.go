@@ -2,14 +2,18 @@ package accrual_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/dtroode/gophermart/internal/accrual"
 	"github.com/dtroode/gophermart/internal/application"
 	"github.com/dtroode/gophermart/internal/application/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAdapter_GetOrder(t *testing.T) {
@@ -59,6 +63,12 @@ func TestAdapter_GetOrder(t *testing.T) {
 			}),
 			expectedErr: application.ErrAccrualInternal,
 		},
+		"accrual error unavailable": {
+			accrualHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}),
+			expectedErr: application.ErrAccrualUnavailable,
+		},
 	}
 
 	for tn, tt := range tests {
@@ -74,3 +84,167 @@ func TestAdapter_GetOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestAdapter_GetOrder_Throttle(t *testing.T) {
+	var calls atomic.Int32
+	httpserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("No more than 600 requests per minute allowed"))
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"order": "1234", "status": "PROCESSING"}`))
+	}))
+	defer httpserver.Close()
+
+	a := accrual.NewAdapter(httpserver.URL)
+
+	_, err := a.GetOrder(context.Background(), "1234")
+	require.ErrorIs(t, err, application.ErrAccrualTooManyRequests)
+
+	start := time.Now()
+	_, err = a.GetOrder(context.Background(), "1234")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond, "callers should wait for Retry-After")
+
+	start = time.Now()
+	_, err = a.GetOrder(context.Background(), "1234")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "request rate should follow the limit from response")
+}
+
+func TestAdapter_GetOrder_Breaker(t *testing.T) {
+	var calls atomic.Int32
+	httpserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer httpserver.Close()
+
+	breaker := accrual.NewBreaker(1, time.Minute, 1, dummyLogger)
+	a := accrual.NewAdapter(httpserver.URL, accrual.WithBreaker(breaker))
+
+	_, err := a.GetOrder(context.Background(), "1234")
+	require.ErrorIs(t, err, application.ErrAccrualUnavailable)
+	assert.Equal(t, accrual.BreakerOpen, breaker.State())
+
+	_, err = a.GetOrder(context.Background(), "1234")
+	require.ErrorIs(t, err, application.ErrAccrualUnavailable)
+	assert.Equal(t, int32(1), calls.Load(), "open breaker should not let requests through")
+}
+
+func TestAdapter_GetOrder_BreakerBeforeLimiter(t *testing.T) {
+	limiter := accrual.NewLimiter(0)
+	breaker := accrual.NewBreaker(1, time.Minute, 1, dummyLogger)
+	breaker.Failure()
+	a := accrual.NewAdapter("http://accrual", accrual.WithLimiter(limiter), accrual.WithBreaker(breaker))
+
+	// rejected request doesn't wait for the limiter
+	limiter.Pause(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := a.GetOrder(ctx, "1234")
+	require.ErrorIs(t, err, application.ErrAccrualUnavailable)
+}
+
+func TestAdapter_GetOrder_LimiterCancelsProbe(t *testing.T) {
+	limiter := accrual.NewLimiter(0)
+	breaker := accrual.NewBreaker(1, 0, 1, dummyLogger)
+	breaker.Failure()
+	a := accrual.NewAdapter("http://accrual", accrual.WithLimiter(limiter), accrual.WithBreaker(breaker))
+
+	limiter.Pause(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := a.GetOrder(ctx, "1234")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the probe that never reached accrual system is released for the next request
+	assert.True(t, breaker.Allow())
+}
+
+func TestAdapter_GetOrder_Context(t *testing.T) {
+	release := make(chan struct{})
+	httpserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer httpserver.Close()
+	defer close(release)
+
+	a := accrual.NewAdapter(httpserver.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := a.GetOrder(ctx, "1234")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// roundTripFunc is transport failing requests without reaching any server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestAdapter_GetOrder_Transport(t *testing.T) {
+	t.Run("connection error", func(t *testing.T) {
+		client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})}
+		breaker := accrual.NewBreaker(5, time.Minute, 1, dummyLogger)
+		a := accrual.NewAdapter("http://accrual", accrual.WithClient(client), accrual.WithBreaker(breaker))
+
+		_, err := a.GetOrder(context.Background(), "1234")
+		require.ErrorIs(t, err, application.ErrAccrualUnavailable, "network errors should be retried")
+		assert.Contains(t, err.Error(), "connection refused")
+		assert.Equal(t, accrual.BreakerClosed, breaker.State())
+	})
+
+	t.Run("client timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		httpserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer httpserver.Close()
+		defer close(release)
+
+		a := accrual.NewAdapter(httpserver.URL, accrual.WithClient(&http.Client{Timeout: 50 * time.Millisecond}))
+
+		_, err := a.GetOrder(context.Background(), "1234")
+		require.ErrorIs(t, err, application.ErrAccrualUnavailable)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			cancel()
+			return nil, context.Canceled
+		})}
+		a := accrual.NewAdapter("http://accrual", accrual.WithClient(client))
+
+		_, err := a.GetOrder(ctx, "1234")
+		require.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, application.ErrAccrualUnavailable, "canceled check is not a failure of accrual system")
+	})
+}
+
+func TestAdapter_GetOrder_UserAgent(t *testing.T) {
+	var userAgent string
+	httpserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpserver.Close()
+
+	a := accrual.NewAdapter(httpserver.URL, accrual.WithUserAgent("gophermart-test"))
+
+	_, err := a.GetOrder(context.Background(), "1234")
+	require.ErrorIs(t, err, application.ErrAccrualOrderNotRegistered)
+	assert.Equal(t, "gophermart-test", userAgent)
+}
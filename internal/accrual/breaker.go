@@ -0,0 +1,188 @@
+package accrual
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+)
+
+// BreakerState is the state of circuit breaker
+type BreakerState string
+
+// List of possible breaker states
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerSnapshot describes breaker state at some moment
+type BreakerSnapshot struct {
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	OpenedAt *time.Time   `json:"opened_at,omitempty"`
+}
+
+// Breaker stops requests to accrual system after failureThreshold consecutive failures.
+// After coolDown it lets a single probe request through and closes again
+// when successThreshold probes succeed in a row.
+type Breaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	successes int
+	probing   bool
+	openedAt  time.Time
+
+	failureThreshold int
+	successThreshold int
+	coolDown         time.Duration
+	logger           *logger.Logger
+}
+
+func NewBreaker(failureThreshold int, coolDown time.Duration, successThreshold int, l *logger.Logger) *Breaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	if successThreshold < 1 {
+		successThreshold = 1
+	}
+
+	return &Breaker{
+		state:            BreakerClosed,
+		failureThreshold: failureThreshold,
+		successThreshold: successThreshold,
+		coolDown:         coolDown,
+		logger:           l,
+	}
+}
+
+// Allow reports whether a request may be sent. Every allowed request
+// must be followed by Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.coolDown {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records successful request.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+
+	if b.state != BreakerHalfOpen {
+		return
+	}
+
+	b.probing = false
+	b.successes++
+	if b.successes >= b.successThreshold {
+		b.setState(BreakerClosed)
+	}
+}
+
+// Failure records failed request.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probing = false
+		b.open()
+	case BreakerClosed:
+		if b.failures >= b.failureThreshold {
+			b.open()
+		}
+	}
+}
+
+// Cancel releases the permit of a request that was interrupted by the caller,
+// so it counts neither as success nor as failure.
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// State returns current breaker state.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Snapshot returns current breaker state with details.
+func (b *Breaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{
+		State:    b.state,
+		Failures: b.failures,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+
+	return snapshot
+}
+
+// ServeHTTP reports breaker state as JSON.
+func (b *Breaker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(b.Snapshot()); err != nil {
+		b.logger.Error("failed to encode breaker state", "error", err)
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = time.Now()
+	b.setState(BreakerOpen)
+}
+
+func (b *Breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	b.logger.Warn("accrual circuit breaker state changed",
+		"from", b.state,
+		"to", state,
+		"failures", b.failures,
+	)
+
+	b.state = state
+	b.successes = 0
+}
@@ -0,0 +1,102 @@
+package accrual_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/accrual"
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dummyLogger = &logger.Logger{Logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+
+func TestBreaker(t *testing.T) {
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		t.Parallel()
+
+		b := accrual.NewBreaker(3, time.Minute, 1, dummyLogger)
+
+		for range 2 {
+			require.True(t, b.Allow())
+			b.Failure()
+		}
+		require.True(t, b.Allow())
+		b.Success()
+		assert.Equal(t, accrual.BreakerClosed, b.State(), "success should reset failures")
+
+		for range 3 {
+			require.True(t, b.Allow())
+			b.Failure()
+		}
+		assert.Equal(t, accrual.BreakerOpen, b.State())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("half-open lets single probe through", func(t *testing.T) {
+		t.Parallel()
+
+		b := accrual.NewBreaker(1, 10*time.Millisecond, 2, dummyLogger)
+
+		b.Failure()
+		require.Equal(t, accrual.BreakerOpen, b.State())
+
+		time.Sleep(20 * time.Millisecond)
+
+		require.True(t, b.Allow())
+		assert.Equal(t, accrual.BreakerHalfOpen, b.State())
+		assert.False(t, b.Allow(), "only one probe at a time")
+
+		b.Success()
+		assert.Equal(t, accrual.BreakerHalfOpen, b.State(), "one more probe should succeed")
+
+		require.True(t, b.Allow())
+		b.Success()
+		assert.Equal(t, accrual.BreakerClosed, b.State())
+	})
+
+	t.Run("failed probe opens again", func(t *testing.T) {
+		t.Parallel()
+
+		b := accrual.NewBreaker(1, 10*time.Millisecond, 1, dummyLogger)
+
+		b.Failure()
+		time.Sleep(20 * time.Millisecond)
+
+		require.True(t, b.Allow())
+		b.Failure()
+		assert.Equal(t, accrual.BreakerOpen, b.State())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("cancelled probe releases permit", func(t *testing.T) {
+		t.Parallel()
+
+		b := accrual.NewBreaker(1, 10*time.Millisecond, 1, dummyLogger)
+
+		b.Failure()
+		time.Sleep(20 * time.Millisecond)
+
+		require.True(t, b.Allow())
+		b.Cancel()
+		assert.True(t, b.Allow())
+	})
+}
+
+func TestBreaker_ServeHTTP(t *testing.T) {
+	b := accrual.NewBreaker(1, time.Minute, 1, dummyLogger)
+	b.Failure()
+
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/breaker", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"open"`)
+	assert.Contains(t, w.Body.String(), `"failures":1`)
+	assert.Contains(t, w.Body.String(), `"opened_at"`)
+}
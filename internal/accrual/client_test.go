@@ -0,0 +1,79 @@
+package accrual_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dtroode/gophermart/internal/accrual"
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	dir := t.TempDir()
+
+	notPEM := filepath.Join(dir, "not.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := map[string]struct {
+		cfg     accrual.ClientConfig
+		wantErr bool
+	}{
+		"default": {
+			cfg: accrual.DefaultClientConfig(),
+		},
+		"missing CA file": {
+			cfg:     accrual.ClientConfig{CAFile: filepath.Join(dir, "missing.pem")},
+			wantErr: true,
+		},
+		"CA file without certificates": {
+			cfg:     accrual.ClientConfig{CAFile: notPEM},
+			wantErr: true,
+		},
+		"client certificate without key": {
+			cfg:     accrual.ClientConfig{CertFile: notPEM},
+			wantErr: true,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := accrual.NewClient(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.cfg.Timeout, c.Timeout)
+		})
+	}
+}
+
+func TestNewClient_CustomCA(t *testing.T) {
+	httpserver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpserver.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: httpserver.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	cfg := accrual.DefaultClientConfig()
+	cfg.CAFile = caFile
+	c, err := accrual.NewClient(cfg)
+	require.NoError(t, err)
+
+	a := accrual.NewAdapter(httpserver.URL, accrual.WithClient(c))
+
+	_, err = a.GetOrder(context.Background(), "1234")
+	assert.ErrorIs(t, err, application.ErrAccrualOrderNotRegistered)
+}
@@ -0,0 +1,87 @@
+package accrual
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter spaces out requests to the accrual system and pauses all callers
+// while the system asks to slow down. It is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewLimiter creates limiter allowing requestsPerMinute requests.
+// Zero means that requests are not limited until accrual system reports its limit.
+func NewLimiter(requestsPerMinute int) *Limiter {
+	l := &Limiter{}
+	l.SetRate(requestsPerMinute)
+
+	return l
+}
+
+// SetRate changes the number of requests allowed per minute.
+func (l *Limiter) SetRate(requestsPerMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if requestsPerMinute <= 0 {
+		l.interval = 0
+		return
+	}
+	l.interval = time.Minute / time.Duration(requestsPerMinute)
+}
+
+// Pause holds all requests until the moment passed.
+func (l *Limiter) Pause(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(l.next) {
+		l.next = until
+	}
+}
+
+// Wait blocks until the caller is allowed to send a request or ctx is done.
+// If ctx is done first, the turn of the caller is given back when no one has taken a later one.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	end := start.Add(l.interval)
+	l.next = end
+	l.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(start, end)
+		return ctx.Err()
+	}
+}
+
+// cancel gives back the turn from start to end if it is still the last one.
+// Later turns are already being waited for, so they are not moved.
+func (l *Limiter) cancel(start, end time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.next.Equal(end) {
+		l.next = start
+	}
+}
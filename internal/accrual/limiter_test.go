@@ -0,0 +1,76 @@
+package accrual_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/accrual"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Wait(t *testing.T) {
+	t.Run("unlimited", func(t *testing.T) {
+		t.Parallel()
+
+		l := accrual.NewLimiter(0)
+
+		start := time.Now()
+		for range 10 {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("spaces requests by rate", func(t *testing.T) {
+		t.Parallel()
+
+		l := accrual.NewLimiter(1200)
+
+		start := time.Now()
+		for range 3 {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("paused", func(t *testing.T) {
+		t.Parallel()
+
+		l := accrual.NewLimiter(0)
+		l.Pause(time.Now().Add(100 * time.Millisecond))
+
+		start := time.Now()
+		require.NoError(t, l.Wait(context.Background()))
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	})
+
+	t.Run("cancelled wait gives back its turn", func(t *testing.T) {
+		t.Parallel()
+
+		l := accrual.NewLimiter(60)
+		require.NoError(t, l.Wait(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, l.Wait(ctx), context.Canceled)
+
+		// the next caller gets the turn of the cancelled one, a second later instead of two
+		ctx, cancel = context.WithTimeout(context.Background(), 1500*time.Millisecond)
+		defer cancel()
+		require.NoError(t, l.Wait(ctx))
+	})
+
+	t.Run("context is done", func(t *testing.T) {
+		t.Parallel()
+
+		l := accrual.NewLimiter(0)
+		l.Pause(time.Now().Add(time.Hour))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	})
+}
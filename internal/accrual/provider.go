@@ -0,0 +1,119 @@
+package accrual
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProviderConfig describes accrual system of a partner marketplace.
+type ProviderConfig struct {
+	Name     string `json:"name" yaml:"name"`
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// RateLimit is initial requests per minute limit, 0 means no limit.
+	RateLimit int    `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	UserAgent string `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`
+	// Headers are sent with every request, values are expanded from environment
+	// so credentials don't have to be kept in the file, e.g. "Bearer ${PARTNER_TOKEN}".
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	CAFile   string            `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	CertFile string            `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string            `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	Rules    []RuleConfig      `json:"rules" yaml:"rules"`
+}
+
+// RuleConfig is Rule with pattern written as regular expression.
+type RuleConfig struct {
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Length int    `json:"length,omitempty" yaml:"length,omitempty"`
+	Regex  string `json:"regex,omitempty" yaml:"regex,omitempty"`
+}
+
+type providersFile struct {
+	Providers []ProviderConfig `json:"providers" yaml:"providers"`
+}
+
+// LoadProviders reads providers from JSON or YAML file, depending on its extension.
+func LoadProviders(path string) ([]ProviderConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read providers file: %w", err)
+	}
+
+	file := &providersFile{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, file)
+	default:
+		err = json.Unmarshal(data, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse providers file: %w", err)
+	}
+
+	for _, p := range file.Providers {
+		if p.Name == "" || p.Endpoint == "" {
+			return nil, fmt.Errorf("provider must have name and endpoint")
+		}
+		if len(p.Rules) == 0 {
+			return nil, fmt.Errorf("provider %q has no rules", p.Name)
+		}
+	}
+
+	return file.Providers, nil
+}
+
+// CompileRules returns rules of the provider.
+func (p ProviderConfig) CompileRules() ([]Rule, error) {
+	rules := make([]Rule, 0, len(p.Rules))
+	for _, rc := range p.Rules {
+		rule := Rule{
+			Prefix: rc.Prefix,
+			Length: rc.Length,
+		}
+		if rc.Regex != "" {
+			pattern, err := regexp.Compile(rc.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compile rule of provider %q: %w", p.Name, err)
+			}
+			rule.Pattern = pattern
+		}
+		if rule == (Rule{}) {
+			return nil, fmt.Errorf("provider %q has empty rule", p.Name)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// NewAdapter creates adapter of the provider with its own client and limiter.
+// Timeouts and pool sizes are taken from base, TLS files are the provider's own.
+func (p ProviderConfig) NewAdapter(base ClientConfig, opts ...Option) (*Adapter, error) {
+	cfg := base
+	cfg.CAFile = p.CAFile
+	cfg.CertFile = p.CertFile
+	cfg.KeyFile = p.KeyFile
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client of provider %q: %w", p.Name, err)
+	}
+
+	opts = append(opts,
+		WithClient(client),
+		WithLimiter(NewLimiter(p.RateLimit)),
+	)
+	if p.UserAgent != "" {
+		opts = append(opts, WithUserAgent(p.UserAgent))
+	}
+	for key, value := range p.Headers {
+		opts = append(opts, WithHeader(key, os.ExpandEnv(value)))
+	}
+
+	return NewAdapter(p.Endpoint, opts...), nil
+}
@@ -0,0 +1,152 @@
+package accrual_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dtroode/gophermart/internal/accrual"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadProviders(t *testing.T) {
+	expected := []accrual.ProviderConfig{
+		{
+			Name:      "partner",
+			Endpoint:  "https://partner.example",
+			RateLimit: 60,
+			Headers:   map[string]string{"Authorization": "Bearer ${PARTNER_TOKEN}"},
+			Rules: []accrual.RuleConfig{
+				{Prefix: "99"},
+				{Length: 12, Regex: `^7`},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		name        string
+		content     string
+		expected    []accrual.ProviderConfig
+		expectedErr bool
+	}{
+		"json": {
+			name: "providers.json",
+			content: `{"providers": [{
+				"name": "partner",
+				"endpoint": "https://partner.example",
+				"rate_limit": 60,
+				"headers": {"Authorization": "Bearer ${PARTNER_TOKEN}"},
+				"rules": [{"prefix": "99"}, {"length": 12, "regex": "^7"}]
+			}]}`,
+			expected: expected,
+		},
+		"yaml": {
+			name: "providers.yaml",
+			content: `
+providers:
+  - name: partner
+    endpoint: https://partner.example
+    rate_limit: 60
+    headers:
+      Authorization: Bearer ${PARTNER_TOKEN}
+    rules:
+      - prefix: "99"
+      - length: 12
+        regex: ^7
+`,
+			expected: expected,
+		},
+		"provider without endpoint": {
+			name:        "providers.json",
+			content:     `{"providers": [{"name": "partner", "rules": [{"prefix": "99"}]}]}`,
+			expectedErr: true,
+		},
+		"provider without rules": {
+			name:        "providers.json",
+			content:     `{"providers": [{"name": "partner", "endpoint": "https://partner.example"}]}`,
+			expectedErr: true,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			providers, err := accrual.LoadProviders(writeFile(t, tt.name, tt.content))
+
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, providers)
+		})
+	}
+}
+
+func TestProviderConfig_CompileRules(t *testing.T) {
+	t.Run("rules", func(t *testing.T) {
+		p := accrual.ProviderConfig{Name: "partner", Rules: []accrual.RuleConfig{
+			{Prefix: "99"},
+			{Regex: `^7\d{11}$`},
+		}}
+
+		rules, err := p.CompileRules()
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, "99", rules[0].Prefix)
+		assert.True(t, rules[1].Match("712345678901"))
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		p := accrual.ProviderConfig{Name: "partner", Rules: []accrual.RuleConfig{{Regex: `(`}}}
+
+		_, err := p.CompileRules()
+		assert.Error(t, err)
+	})
+
+	t.Run("empty rule", func(t *testing.T) {
+		p := accrual.ProviderConfig{Name: "partner", Rules: []accrual.RuleConfig{{}}}
+
+		_, err := p.CompileRules()
+		assert.Error(t, err)
+	})
+}
+
+func TestProviderConfig_NewAdapter(t *testing.T) {
+	t.Setenv("PARTNER_TOKEN", "secret")
+
+	var auth, userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := accrual.ProviderConfig{
+		Name:      "partner",
+		Endpoint:  server.URL,
+		UserAgent: "gophermart-partner",
+		Headers:   map[string]string{"Authorization": "Bearer ${PARTNER_TOKEN}"},
+	}
+
+	a, err := p.NewAdapter(accrual.DefaultClientConfig(), accrual.WithUserAgent("gophermart"))
+	require.NoError(t, err)
+
+	_, _ = a.GetOrder(context.Background(), "1234")
+
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, "gophermart-partner", userAgent)
+}
@@ -0,0 +1,122 @@
+package accrual
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+)
+
+// DefaultProvider serves orders that match no rule and orders
+// uploaded before providers were introduced.
+const DefaultProvider = "default"
+
+// Rule matches order numbers by prefix, length and pattern.
+// Empty fields are not checked, so a rule with several fields set
+// matches only numbers satisfying all of them.
+type Rule struct {
+	Prefix  string
+	Length  int
+	Pattern *regexp.Regexp
+}
+
+// Match reports whether the order number satisfies the rule.
+func (r Rule) Match(number string) bool {
+	if r.Prefix != "" && !strings.HasPrefix(number, r.Prefix) {
+		return false
+	}
+	if r.Length > 0 && len(number) != r.Length {
+		return false
+	}
+	if r.Pattern != nil && !r.Pattern.MatchString(number) {
+		return false
+	}
+
+	return true
+}
+
+type route struct {
+	provider string
+	rules    []Rule
+}
+
+// Router sends requests to one of several accrual providers.
+// The provider for a new order is picked by rules in order of adding,
+// later checks of the order go to the provider it was routed to.
+type Router struct {
+	adapters map[string]*Adapter
+	routes   []route
+}
+
+func NewRouter(defaultAdapter *Adapter) *Router {
+	return &Router{
+		adapters: map[string]*Adapter{DefaultProvider: defaultAdapter},
+	}
+}
+
+// Add registers provider serving orders that match any of the rules.
+func (r *Router) Add(provider string, a *Adapter, rules ...Rule) error {
+	if provider == "" {
+		return fmt.Errorf("provider name is empty")
+	}
+	if _, ok := r.adapters[provider]; ok {
+		return fmt.Errorf("provider %q is already registered", provider)
+	}
+
+	r.adapters[provider] = a
+	r.routes = append(r.routes, route{provider: provider, rules: rules})
+
+	return nil
+}
+
+// Route returns name of provider responsible for the order.
+func (r *Router) Route(orderNumber string) string {
+	for _, route := range r.routes {
+		for _, rule := range route.rules {
+			if rule.Match(orderNumber) {
+				return route.provider
+			}
+		}
+	}
+
+	return DefaultProvider
+}
+
+// GetOrder asks provider about the order. Empty provider means the default one.
+func (r *Router) GetOrder(ctx context.Context, provider, orderNumber string) (*model.AccrualOrder, error) {
+	a, err := r.adapter(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.GetOrder(ctx, orderNumber)
+}
+
+// ServeHTTP reports circuit breaker state of provider from "provider" query parameter,
+// the default provider is reported when the parameter is omitted.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	a, err := r.adapter(req.URL.Query().Get("provider"))
+	if err != nil || a.breaker == nil {
+		http.Error(w, "unknown provider", http.StatusNotFound)
+		return
+	}
+
+	a.breaker.ServeHTTP(w, req)
+}
+
+func (r *Router) adapter(provider string) (*Adapter, error) {
+	if provider == "" {
+		provider = DefaultProvider
+	}
+
+	a, ok := r.adapters[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", application.ErrAccrualUnknownProvider, provider)
+	}
+
+	return a, nil
+}
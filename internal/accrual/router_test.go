@@ -0,0 +1,157 @@
+package accrual_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/accrual"
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRule_Match(t *testing.T) {
+	tests := map[string]struct {
+		rule     accrual.Rule
+		number   string
+		expected bool
+	}{
+		"prefix matches": {
+			rule:     accrual.Rule{Prefix: "99"},
+			number:   "9912345",
+			expected: true,
+		},
+		"prefix doesn't match": {
+			rule:   accrual.Rule{Prefix: "99"},
+			number: "1299345",
+		},
+		"length matches": {
+			rule:     accrual.Rule{Length: 4},
+			number:   "1234",
+			expected: true,
+		},
+		"length doesn't match": {
+			rule:   accrual.Rule{Length: 4},
+			number: "12345",
+		},
+		"pattern matches": {
+			rule:     accrual.Rule{Pattern: regexp.MustCompile(`^7\d+5$`)},
+			number:   "71235",
+			expected: true,
+		},
+		"all fields match": {
+			rule:     accrual.Rule{Prefix: "7", Length: 5, Pattern: regexp.MustCompile(`5$`)},
+			number:   "71235",
+			expected: true,
+		},
+		"one of fields doesn't match": {
+			rule:   accrual.Rule{Prefix: "7", Length: 6, Pattern: regexp.MustCompile(`5$`)},
+			number: "71235",
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rule.Match(tt.number))
+		})
+	}
+}
+
+func newProviderServer(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.Write([]byte(`{"order": "` + name + `", "status": "PROCESSING"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRouter(t *testing.T) {
+	defaultServer := newProviderServer(t, "default")
+	partnerServer := newProviderServer(t, "partner")
+
+	r := accrual.NewRouter(accrual.NewAdapter(defaultServer.URL))
+	require.NoError(t, r.Add("partner", accrual.NewAdapter(partnerServer.URL),
+		accrual.Rule{Prefix: "99"},
+		accrual.Rule{Length: 4},
+	))
+
+	t.Run("route", func(t *testing.T) {
+		assert.Equal(t, "partner", r.Route("9912345"))
+		assert.Equal(t, "partner", r.Route("1234"))
+		assert.Equal(t, accrual.DefaultProvider, r.Route("12345"))
+	})
+
+	t.Run("provider is registered once", func(t *testing.T) {
+		assert.Error(t, r.Add("partner", accrual.NewAdapter(partnerServer.URL)))
+		assert.Error(t, r.Add(accrual.DefaultProvider, accrual.NewAdapter(partnerServer.URL)))
+	})
+
+	t.Run("get order", func(t *testing.T) {
+		order, err := r.GetOrder(context.Background(), "partner", "1234")
+		require.NoError(t, err)
+		assert.Equal(t, "partner", order.Number)
+
+		order, err = r.GetOrder(context.Background(), accrual.DefaultProvider, "1234")
+		require.NoError(t, err)
+		assert.Equal(t, "default", order.Number)
+
+		// orders uploaded before routing have no provider
+		order, err = r.GetOrder(context.Background(), "", "1234")
+		require.NoError(t, err)
+		assert.Equal(t, "default", order.Number)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := r.GetOrder(context.Background(), "gone", "1234")
+		assert.ErrorIs(t, err, application.ErrAccrualUnknownProvider)
+	})
+}
+
+func TestRouter_ServeHTTP(t *testing.T) {
+	partnerBreaker := accrual.NewBreaker(1, time.Minute, 1, dummyLogger)
+	partnerBreaker.Failure()
+
+	r := accrual.NewRouter(accrual.NewAdapter("http://default",
+		accrual.WithBreaker(accrual.NewBreaker(1, time.Minute, 1, dummyLogger))))
+	require.NoError(t, r.Add("partner", accrual.NewAdapter("http://partner",
+		accrual.WithBreaker(partnerBreaker)), accrual.Rule{Prefix: "99"}))
+
+	tests := map[string]struct {
+		target       string
+		expectedCode int
+		expectedBody string
+	}{
+		"default provider": {
+			target:       "/breaker",
+			expectedCode: http.StatusOK,
+			expectedBody: `"state":"closed"`,
+		},
+		"partner provider": {
+			target:       "/breaker?provider=partner",
+			expectedCode: http.StatusOK,
+			expectedBody: `"state":"open"`,
+		},
+		"unknown provider": {
+			target:       "/breaker?provider=gone",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
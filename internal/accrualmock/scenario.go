@@ -0,0 +1,98 @@
+package accrualmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Step describes a single response of the mock. Steps of a scenario are
+// served one per request, the last step repeats forever.
+type Step struct {
+	// Status is accrual order status sent with 200 response.
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	// Accrual is sent with PROCESSED status.
+	Accrual float64 `json:"accrual,omitempty" yaml:"accrual,omitempty"`
+	// HTTPStatus overrides response code. When it is not 200 no body is sent.
+	HTTPStatus int `json:"http_status,omitempty" yaml:"http_status,omitempty"`
+	// RetryAfter is sent in Retry-After header of 429 response, in seconds.
+	RetryAfter int `json:"retry_after,omitempty" yaml:"retry_after,omitempty"`
+	// RateLimit is mentioned in 429 response body as requests per minute.
+	RateLimit int `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	// Delay is waited before responding.
+	Delay Duration `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+func (s Step) code() int {
+	if s.HTTPStatus != 0 {
+		return s.HTTPStatus
+	}
+	if s.Status == "" {
+		return http.StatusNoContent
+	}
+
+	return http.StatusOK
+}
+
+// Scenarios maps order number to the steps served for it.
+// Default steps are served for orders without their own scenario.
+type Scenarios struct {
+	Orders  map[string][]Step `json:"orders" yaml:"orders"`
+	Default []Step            `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// LoadScenarios reads scenarios from JSON or YAML file, depending on its extension.
+func LoadScenarios(path string) (*Scenarios, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenarios file: %w", err)
+	}
+
+	scenarios := &Scenarios{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, scenarios)
+	default:
+		err = json.Unmarshal(data, scenarios)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scenarios file: %w", err)
+	}
+
+	return scenarios, nil
+}
+
+// Duration is time.Duration written as string like "1.5s" in scenario files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("failed to parse duration: %w", err)
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package accrualmock implements accrual system API driven by scripted scenarios.
+// It is meant for local development and tests and can be mounted into httptest.Server.
+package accrualmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Server serves GET /api/orders/{number} following scenarios and
+// exposes control API under /control to change them at runtime.
+type Server struct {
+	chi.Router
+
+	mu        sync.Mutex
+	scenarios map[string][]Step
+	fallback  []Step
+	calls     map[string]int
+}
+
+// OrderState reports scenario of an order and how many times it was requested.
+type OrderState struct {
+	Steps []Step `json:"steps"`
+	Calls int    `json:"calls"`
+}
+
+func New(scenarios *Scenarios) *Server {
+	s := &Server{
+		Router:    chi.NewRouter(),
+		scenarios: make(map[string][]Step),
+		calls:     make(map[string]int),
+	}
+	if scenarios != nil {
+		for number, steps := range scenarios.Orders {
+			s.scenarios[number] = steps
+		}
+		s.fallback = scenarios.Default
+	}
+
+	s.Get("/api/orders/{number}", s.getOrder)
+
+	s.Route("/control", func(r chi.Router) {
+		r.Get("/orders/{number}", s.getState)
+		r.Put("/orders/{number}", s.putScenario)
+		r.Delete("/orders/{number}", s.deleteScenario)
+		r.Post("/reset", s.reset)
+	})
+
+	return s
+}
+
+// SetScenario replaces steps of the order and restarts it from the first step.
+func (s *Server) SetScenario(number string, steps ...Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scenarios[number] = steps
+	delete(s.calls, number)
+}
+
+// Calls returns how many times the order was requested.
+func (s *Server) Calls(number string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[number]
+}
+
+// Reset restarts all scenarios from the first step.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = make(map[string]int)
+}
+
+func (s *Server) next(number string) (Step, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	steps, ok := s.scenarios[number]
+	if !ok {
+		steps = s.fallback
+	}
+
+	call := s.calls[number]
+	s.calls[number] = call + 1
+
+	if len(steps) == 0 {
+		return Step{}, false
+	}
+
+	return steps[min(call, len(steps)-1)], true
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
+	number := chi.URLParam(r, "number")
+
+	step, ok := s.next(number)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if step.Delay > 0 {
+		select {
+		case <-time.After(time.Duration(step.Delay)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	code := step.code()
+	switch code {
+	case http.StatusOK:
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(struct {
+			Order   string  `json:"order"`
+			Status  string  `json:"status"`
+			Accrual float64 `json:"accrual,omitempty"`
+		}{
+			Order:   number,
+			Status:  step.Status,
+			Accrual: step.Accrual,
+		})
+	case http.StatusTooManyRequests:
+		if step.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(step.RetryAfter))
+		}
+		w.Header().Set("content-type", "text/plain")
+		w.WriteHeader(http.StatusTooManyRequests)
+		if step.RateLimit > 0 {
+			fmt.Fprintf(w, "No more than %d requests per minute allowed", step.RateLimit)
+		}
+	default:
+		w.WriteHeader(code)
+	}
+}
+
+func (s *Server) getState(w http.ResponseWriter, r *http.Request) {
+	number := chi.URLParam(r, "number")
+
+	s.mu.Lock()
+	state := OrderState{
+		Steps: s.scenarios[number],
+		Calls: s.calls[number],
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+func (s *Server) putScenario(w http.ResponseWriter, r *http.Request) {
+	steps := []Step{}
+	if err := json.NewDecoder(r.Body).Decode(&steps); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.SetScenario(chi.URLParam(r, "number"), steps...)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteScenario(w http.ResponseWriter, r *http.Request) {
+	number := chi.URLParam(r, "number")
+
+	s.mu.Lock()
+	delete(s.scenarios, number)
+	delete(s.calls, number)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) reset(w http.ResponseWriter, _ *http.Request) {
+	s.Reset()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package accrualmock_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/accrual"
+	"github.com/dtroode/gophermart/internal/accrualmock"
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Scenarios(t *testing.T) {
+	mock := accrualmock.New(&accrualmock.Scenarios{
+		Orders: map[string][]accrualmock.Step{
+			"1": {
+				{Status: "REGISTERED"},
+				{Status: "PROCESSING"},
+				{Status: "PROCESSED", Accrual: 500},
+			},
+			"2": {{Status: "INVALID"}},
+			"3": {{HTTPStatus: http.StatusInternalServerError}},
+			"4": {{HTTPStatus: http.StatusTooManyRequests, RetryAfter: 60, RateLimit: 10}},
+		},
+	})
+	httpserver := httptest.NewServer(mock)
+	defer httpserver.Close()
+
+	a := accrual.NewAdapter(httpserver.URL)
+	ctx := context.Background()
+
+	for _, expected := range []model.AccrualOrderStatus{
+		model.AccrualOrderStatusRegistered,
+		model.AccrualOrderStatusProcessing,
+		model.AccrualOrderStatusProcessed,
+		model.AccrualOrderStatusProcessed,
+	} {
+		order, err := a.GetOrder(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, expected, order.Status)
+	}
+	assert.Equal(t, 4, mock.Calls("1"))
+
+	order, err := a.GetOrder(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, float32(500), order.Accrual)
+
+	order, err = a.GetOrder(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, model.AccrualOrderStatusInvalid, order.Status)
+
+	_, err = a.GetOrder(ctx, "3")
+	assert.ErrorIs(t, err, application.ErrAccrualUnavailable)
+
+	_, err = a.GetOrder(ctx, "unknown")
+	assert.ErrorIs(t, err, application.ErrAccrualOrderNotRegistered)
+
+	resp, err := http.Get(httpserver.URL + "/api/orders/4")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+}
+
+func TestServer_Delay(t *testing.T) {
+	mock := accrualmock.New(nil)
+	mock.SetScenario("1", accrualmock.Step{Status: "PROCESSING", Delay: accrualmock.Duration(time.Second)})
+	httpserver := httptest.NewServer(mock)
+	defer httpserver.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := accrual.NewAdapter(httpserver.URL).GetOrder(ctx, "1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServer_Control(t *testing.T) {
+	mock := accrualmock.New(nil)
+	httpserver := httptest.NewServer(mock)
+	defer httpserver.Close()
+
+	req, err := http.NewRequest(http.MethodPut, httpserver.URL+"/control/orders/1",
+		strings.NewReader(`[{"status": "PROCESSING"}, {"status": "PROCESSED", "accrual": 10}]`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	a := accrual.NewAdapter(httpserver.URL)
+
+	order, err := a.GetOrder(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, model.AccrualOrderStatusProcessing, order.Status)
+
+	resp, err = http.Post(httpserver.URL+"/control/reset", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	order, err = a.GetOrder(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, model.AccrualOrderStatusProcessing, order.Status, "reset should restart scenario")
+
+	req, err = http.NewRequest(http.MethodDelete, httpserver.URL+"/control/orders/1", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = a.GetOrder(context.Background(), "1")
+	assert.ErrorIs(t, err, application.ErrAccrualOrderNotRegistered)
+}
+
+func TestLoadScenarios(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonFile := filepath.Join(dir, "scenarios.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{
+		"orders": {"1": [{"http_status": 429, "retry_after": 5}, {"status": "PROCESSED", "accrual": 5, "delay": "100ms"}]},
+		"default": [{"status": "INVALID"}]
+	}`), 0o600))
+
+	yamlFile := filepath.Join(dir, "scenarios.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+orders:
+  "1":
+    - http_status: 429
+      retry_after: 5
+    - status: PROCESSED
+      accrual: 5
+      delay: 100ms
+default:
+  - status: INVALID
+`), 0o600))
+
+	expected := &accrualmock.Scenarios{
+		Orders: map[string][]accrualmock.Step{
+			"1": {
+				{HTTPStatus: 429, RetryAfter: 5},
+				{Status: "PROCESSED", Accrual: 5, Delay: accrualmock.Duration(100 * time.Millisecond)},
+			},
+		},
+		Default: []accrualmock.Step{{Status: "INVALID"}},
+	}
+
+	for _, file := range []string{jsonFile, yamlFile} {
+		t.Run(filepath.Ext(file), func(t *testing.T) {
+			scenarios, err := accrualmock.LoadScenarios(file)
+			require.NoError(t, err)
+			assert.Equal(t, expected, scenarios)
+		})
+	}
+}
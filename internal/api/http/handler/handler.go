@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/dtroode/gophermart/internal/api/http/request"
 	"github.com/dtroode/gophermart/internal/application"
@@ -15,19 +18,38 @@ import (
 	"github.com/dtroode/gophermart/internal/application/response"
 	"github.com/dtroode/gophermart/internal/auth"
 	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type Service interface {
-	RegisterUser(ctx context.Context, dto *dto.RegisterUser) (string, error)
-	Login(ctx context.Context, dto *dto.Login) (string, error)
+	RegisterUser(ctx context.Context, dto *dto.RegisterUser) (*response.Tokens, error)
+	Login(ctx context.Context, dto *dto.Login) (*response.Tokens, error)
+	RefreshToken(ctx context.Context, dto *dto.RefreshToken) (*response.Tokens, error)
+	Logout(ctx context.Context, userID, sessionID uuid.UUID) error
+	ListUserSessions(ctx context.Context, userID, currentID uuid.UUID) ([]*response.UserSession, error)
+	RevokeUserSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
 	UploadOrder(ctx context.Context, dto *dto.UploadOrder) (*model.Order, error)
 	ListUserOrders(ctx context.Context, id uuid.UUID) ([]*response.UserOrder, error)
+	GetUserOrder(ctx context.Context, userID uuid.UUID, number string) (*response.UserOrderDetails, error)
 	GetUserBalance(ctx context.Context, id uuid.UUID) (*response.UserBalance, error)
 	WithdrawUserBonuses(ctx context.Context, dto *dto.WithdrawBonuses) error
 	ListUserWithdrawals(ctx context.Context, id uuid.UUID) ([]*response.UserWithdrawal, error)
+	ApplyAccrualEvent(ctx context.Context, event *model.AccrualOrder) (*model.Order, error)
+	ListOrderDeadLetters(ctx context.Context) ([]*response.OrderDeadLetter, error)
+	RetryOrderDeadLetter(ctx context.Context, number string) error
+	RetryAllOrderDeadLetters(ctx context.Context) (*response.RetriedOrders, error)
+	InvalidateOrderDeadLetter(ctx context.Context, number string) error
+	ListOrderDiscrepancies(ctx context.Context) ([]*response.OrderDiscrepancy, error)
+	AdjustOrderDiscrepancy(ctx context.Context, dto *dto.AdjustOrderDiscrepancy) (*response.OrderDiscrepancy, error)
+	GetWorkerPoolStats(ctx context.Context) *response.WorkerPoolStats
+	ResizeWorkerPool(ctx context.Context, dto *dto.ResizeWorkerPool) (*response.WorkerPoolStats, error)
 }
 
+// busyRetryAfter is sent in Retry-After header, in seconds, when the service is overloaded.
+const busyRetryAfter = "5"
+
 type Handler struct {
 	service Service
 	logger  *logger.Logger
@@ -47,8 +69,8 @@ func New(s Service, l *logger.Logger) *Handler {
 // @Accept json
 // @Produce json
 // @Param request body request.RegisterUser true "User registration details"
-// @Success 200 {string} string "Bearer token in Authorization header"
-// @Failure 400 {string} string "Invalid input"
+// @Success 200 {object} response.Tokens "Access token is also sent as Bearer token in Authorization header"
+// @Failure 400 {object} response.PolicyViolations "Invalid input, credentials that violate policy come with the broken rules"
 // @Failure 409 {string} string "User already exists"
 // @Failure 500 {string} string "Internal server error"
 // @Router /user/register [post]
@@ -61,22 +83,27 @@ func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.service.RegisterUser(ctx, &dto.RegisterUser{
-		Login:    req.Login,
-		Password: req.Password,
+	tokens, err := h.service.RegisterUser(ctx, &dto.RegisterUser{
+		Login:     req.Login,
+		Password:  req.Password,
+		UserAgent: r.UserAgent(),
 	})
 	if err != nil {
 		if errors.Is(err, application.ErrConflict) {
 			w.WriteHeader(http.StatusConflict)
 			return
 		}
+		var policyErr *application.PolicyError
+		if errors.As(err, &policyErr) {
+			h.writePolicyViolations(w, policyErr)
+			return
+		}
 		h.logger.Error("failed to register user", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("authorization", fmt.Sprintf("Bearer %s", token))
-	w.WriteHeader(http.StatusOK)
+	h.writeTokens(w, tokens)
 }
 
 // Login godoc
@@ -86,9 +113,10 @@ func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body request.Login true "User login credentials"
-// @Success 200 {string} string "Bearer token in Authorization header"
+// @Success 200 {object} response.Tokens "Access token is also sent as Bearer token in Authorization header"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 429 {string} string "Too many failed attempts, retry after the time in Retry-After header"
 // @Failure 500 {string} string "Internal server error"
 // @Router /user/login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
@@ -100,21 +128,251 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.service.Login(ctx, &dto.Login{
-		Login:    req.Login,
-		Password: req.Password,
+	tokens, err := h.service.Login(ctx, &dto.Login{
+		Login:     req.Login,
+		Password:  req.Password,
+		UserAgent: r.UserAgent(),
+		ClientIP:  clientIP(r),
 	})
 	if err != nil {
 		if errors.Is(err, application.ErrUnauthorized) {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
+		var throttled *application.ThrottledError
+		if errors.As(err, &throttled) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
 		h.logger.Error("failed to login user", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("authorization", fmt.Sprintf("Bearer %s", token))
+	h.writeTokens(w, tokens)
+}
+
+// writePolicyViolations tells the client every rule of credential policy the credentials break.
+func (h *Handler) writePolicyViolations(w http.ResponseWriter, policyErr *application.PolicyError) {
+	violations := &response.PolicyViolations{
+		Errors: make([]*response.PolicyViolation, 0, len(policyErr.Violations)),
+	}
+	for _, v := range policyErr.Violations {
+		violations.Errors = append(violations.Errors, &response.PolicyViolation{
+			Field:   v.Field,
+			Rule:    v.Rule,
+			Message: v.Message,
+		})
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	if err := json.NewEncoder(w).Encode(violations); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// clientIP returns address of the client without port. Behind a proxy it is
+// the address set by RealIP middleware.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// writeTokens sends access token in Authorization header, as clients expect it there,
+// and both tokens in the body.
+func (h *Handler) writeTokens(w http.ResponseWriter, tokens *response.Tokens) {
+	w.Header().Set("authorization", fmt.Sprintf("Bearer %s", tokens.AccessToken))
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(tokens); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// RefreshToken godoc
+// @Summary Refresh tokens
+// @Description Exchange refresh token for new access and refresh tokens. Each refresh token can be exchanged once, using it again signs out the device.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body request.RefreshToken true "Refresh token"
+// @Success 200 {object} response.Tokens "Access token is also sent as Bearer token in Authorization header"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Refresh token is expired, revoked or already used"
+// @Failure 500 {string} string "Internal server error"
+// @Router /user/token/refresh [post]
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req := &request.RefreshToken{}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.service.RefreshToken(ctx, &dto.RefreshToken{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, application.ErrUnauthorized) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.logger.Error("failed to refresh token", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, tokens)
+}
+
+// Logout godoc
+// @Summary Logout user
+// @Description Revoke the session of the access token, its refresh token can't be used anymore
+// @Tags auth
+// @Security Bearer
+// @Success 200 {string} string "Session revoked"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /user/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		h.logger.Error("failed to get user id from context")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	sessionID, _ := auth.GetSessionIDFromContext(ctx)
+
+	if err := h.service.Logout(ctx, userID, sessionID); err != nil {
+		h.logger.Error("failed to logout user", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListUserSessions godoc
+// @Summary List user sessions
+// @Description Get devices the authenticated user is signed in on
+// @Tags auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} response.UserSession
+// @Success 204 {string} string "No sessions found"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /user/sessions [get]
+func (h *Handler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		h.logger.Error("failed to get user id from context")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	sessionID, _ := auth.GetSessionIDFromContext(ctx)
+
+	sessions, err := h.service.ListUserSessions(ctx, userID, sessionID)
+	if err != nil {
+		if errors.Is(err, application.ErrNoData) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.logger.Error("failed to list user sessions", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// RevokeUserSession godoc
+// @Summary Revoke user session
+// @Description Sign the authenticated user out on the device of the session
+// @Tags auth
+// @Security Bearer
+// @Param id path string true "Session ID"
+// @Success 200 {string} string "Session revoked"
+// @Failure 400 {string} string "Invalid session ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Session not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /user/sessions/{id} [delete]
+func (h *Handler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		h.logger.Error("failed to get user id from context")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeUserSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to revoke user session", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all user sessions
+// @Description Sign the authenticated user out on all devices
+// @Tags auth
+// @Security Bearer
+// @Success 200 {string} string "Sessions revoked"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /user/sessions [delete]
+func (h *Handler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		h.logger.Error("failed to get user id from context")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.service.RevokeUserSessions(ctx, userID); err != nil {
+		h.logger.Error("failed to revoke user sessions", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -133,6 +391,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 // @Failure 409 {string} string "Order registered by another user"
 // @Failure 422 {string} string "Invalid order number"
 // @Failure 500 {string} string "Internal server error"
+// @Failure 503 {string} string "Order saved, but service is busy to check it now"
 // @Router /user/orders [post]
 func (h *Handler) UploadOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -167,6 +426,12 @@ func (h *Handler) UploadOrder(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			return
 		}
+		if errors.Is(err, application.ErrBusy) {
+			h.logger.Warn("order check rejected", "error", err)
+			w.Header().Set("Retry-After", busyRetryAfter)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		h.logger.Error("failed to upload order", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -216,6 +481,48 @@ func (h *Handler) ListUserOrders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetUserOrder godoc
+// @Summary Get user order
+// @Description Get order of the authenticated user with its processing timeline
+// @Tags orders
+// @Produce json
+// @Security Bearer
+// @Param number path string true "Order number"
+// @Success 200 {object} response.UserOrderDetails
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Order not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /user/orders/{number} [get]
+func (h *Handler) GetUserOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		h.logger.Error("failed to get user id from context")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	order, err := h.service.GetUserOrder(ctx, userID, chi.URLParam(r, "number"))
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to get user order", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 // GetUserBalance godoc
 // @Summary Get user balance
 // @Description Get current balance for the authenticated user
@@ -343,3 +650,338 @@ func (h *Handler) ListUserWithdrawals(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// AccrualEvent godoc
+// @Summary Accrual order event
+// @Description Receive order status update pushed by accrual system. Timestamp and body joined with "." must be signed with HMAC-SHA256 using shared secret, requests signed more than 5 minutes ago are rejected.
+// @Tags internal
+// @Accept json
+// @Param X-Signature header string true "Hex encoded HMAC-SHA256 of the timestamp and the body"
+// @Param X-Signature-Timestamp header integer true "Unix time in seconds the request was signed at"
+// @Param event body model.AccrualOrder true "Order status in accrual system"
+// @Success 200 {string} string "Event applied"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Wrong or stale signature"
+// @Failure 404 {string} string "Order not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /internal/accrual/events [post]
+func (h *Handler) AccrualEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	event := &model.AccrualOrder{}
+
+	if err := json.NewDecoder(r.Body).Decode(event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch event.Status {
+	case model.AccrualOrderStatusRegistered,
+		model.AccrualOrderStatusProcessing,
+		model.AccrualOrderStatusInvalid,
+		model.AccrualOrderStatusProcessed:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if event.Number == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.service.ApplyAccrualEvent(ctx, event); err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to apply accrual event", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListOrderDeadLetters godoc
+// @Summary List order dead letters
+// @Description Get orders that failed processing and are not checked anymore
+// @Tags admin
+// @Produce json
+// @Security Admin
+// @Success 200 {array} response.OrderDeadLetter
+// @Success 204 {string} string "No dead letters"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/orders/dead-letters [get]
+func (h *Handler) ListOrderDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	deadLetters, err := h.service.ListOrderDeadLetters(ctx)
+	if err != nil {
+		if errors.Is(err, application.ErrNoData) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.logger.Error("failed to list order dead letters", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(deadLetters); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// RetryOrderDeadLetter godoc
+// @Summary Retry order dead letter
+// @Description Schedule dead lettered order for a check
+// @Tags admin
+// @Security Admin
+// @Param number path string true "Order number"
+// @Success 202 {string} string "Order scheduled"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Dead letter not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/orders/dead-letters/{number}/retry [post]
+func (h *Handler) RetryOrderDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := h.service.RetryOrderDeadLetter(ctx, chi.URLParam(r, "number")); err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to retry order dead letter", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// RetryAllOrderDeadLetters godoc
+// @Summary Retry all order dead letters
+// @Description Schedule all dead lettered orders for a check
+// @Tags admin
+// @Produce json
+// @Security Admin
+// @Success 202 {object} response.RetriedOrders
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/orders/dead-letters/retry [post]
+func (h *Handler) RetryAllOrderDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	retried, err := h.service.RetryAllOrderDeadLetters(ctx)
+	if err != nil {
+		h.logger.Error("failed to retry order dead letters", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err := json.NewEncoder(w).Encode(retried); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// InvalidateOrderDeadLetter godoc
+// @Summary Invalidate order dead letter
+// @Description Mark dead lettered order invalid, so it is never checked again
+// @Tags admin
+// @Security Admin
+// @Param number path string true "Order number"
+// @Success 200 {string} string "Order invalidated"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Dead letter not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/orders/dead-letters/{number}/invalidate [post]
+func (h *Handler) InvalidateOrderDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := h.service.InvalidateOrderDeadLetter(ctx, chi.URLParam(r, "number")); err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to invalidate order dead letter", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListOrderDiscrepancies godoc
+// @Summary List order discrepancies
+// @Description Get finalized orders that don't match accrual system, newest first
+// @Tags admin
+// @Produce json
+// @Security Admin
+// @Success 200 {array} response.OrderDiscrepancy
+// @Success 204 {string} string "No discrepancies"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/orders/discrepancies [get]
+func (h *Handler) ListOrderDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	discrepancies, err := h.service.ListOrderDiscrepancies(ctx)
+	if err != nil {
+		if errors.Is(err, application.ErrNoData) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.logger.Error("failed to list order discrepancies", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(discrepancies); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// AdjustOrderDiscrepancy godoc
+// @Summary Adjust order discrepancy
+// @Description Correct the order to match accrual system and credit or debit the difference to the user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Admin
+// @Param id path string true "Discrepancy ID"
+// @Param request body request.AdjustOrderDiscrepancy true "Adjustment details"
+// @Success 200 {object} response.OrderDiscrepancy
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Discrepancy not found"
+// @Failure 409 {string} string "Already adjusted or user balance is not enough"
+// @Failure 422 {string} string "Empty reason or order is not final in accrual system"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/orders/discrepancies/{id}/adjust [post]
+func (h *Handler) AdjustOrderDiscrepancy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := &request.AdjustOrderDiscrepancy{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	discrepancy, err := h.service.AdjustOrderDiscrepancy(ctx, &dto.AdjustOrderDiscrepancy{
+		ID:     id,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, application.ErrConflict) || errors.Is(err, application.ErrNotEnoughBonuses) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if errors.Is(err, application.ErrUnprocessable) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		h.logger.Error("failed to adjust order discrepancy", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(discrepancy); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// GetWorkerPoolStats godoc
+// @Summary Get worker pool stats
+// @Description Get load of the pool checking orders
+// @Tags admin
+// @Produce json
+// @Security Admin
+// @Success 200 {object} response.WorkerPoolStats
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/workerpool [get]
+func (h *Handler) GetWorkerPoolStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	stats := h.service.GetWorkerPoolStats(ctx)
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
+
+// ResizeWorkerPool godoc
+// @Summary Resize worker pool
+// @Description Change the number of workers checking orders without dropping queued jobs
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Admin
+// @Param request body request.ResizeWorkerPool true "New size"
+// @Success 200 {object} response.WorkerPoolStats
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 422 {string} string "Size is not positive"
+// @Failure 500 {string} string "Internal server error"
+// @Router /admin/workerpool [put]
+func (h *Handler) ResizeWorkerPool(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &request.ResizeWorkerPool{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.service.ResizeWorkerPool(ctx, &dto.ResizeWorkerPool{
+		Size: req.Size,
+	})
+	if err != nil {
+		if errors.Is(err, application.ErrUnprocessable) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		h.logger.Error("failed to resize worker pool", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("worker pool resized", "size", req.Size)
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+		return
+	}
+}
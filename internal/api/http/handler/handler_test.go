@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dtroode/gophermart/internal/api/http/handler"
 	"github.com/dtroode/gophermart/internal/api/http/handler/mocks"
@@ -18,6 +19,7 @@ import (
 	"github.com/dtroode/gophermart/internal/application/response"
 	"github.com/dtroode/gophermart/internal/auth"
 	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -42,6 +44,7 @@ func TestHandler_RegisterUser(t *testing.T) {
 		wantError          bool
 		expectedStatusCode int
 		expectedAuthHeader string
+		expectedResponse   string
 	}{
 		"failed to decode body": {
 			requestBody:        `s`,
@@ -53,22 +56,47 @@ func TestHandler_RegisterUser(t *testing.T) {
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
 				service.On("RegisterUser", mock.Anything, &dto.RegisterUser{
-					Login:    "testuser",
-					Password: "testpassword",
-				}).Once().Return("", application.ErrConflict)
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+				}).Once().Return(nil, application.ErrConflict)
 				return service
 			}(),
 			wantError:          true,
 			expectedStatusCode: http.StatusConflict,
 		},
+		"service error policy violation": {
+			requestBody: `{"login": "test user", "password": "short"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("RegisterUser", mock.Anything, &dto.RegisterUser{
+					Login:     "test user",
+					Password:  "short",
+					UserAgent: "test-agent",
+				}).Once().Return(nil, &application.PolicyError{
+					Violations: []application.PolicyViolation{
+						{Field: "login", Rule: "charset", Message: "contains characters that are not allowed"},
+						{Field: "password", Rule: "min_length", Message: "must be at least 8 characters long"},
+					},
+				})
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse: `{"errors":[
+				{"field":"login","rule":"charset","message":"contains characters that are not allowed"},
+				{"field":"password","rule":"min_length","message":"must be at least 8 characters long"}
+			]}`,
+		},
 		"service error internal": {
 			requestBody: `{"login": "testuser", "password": "testpassword"}`,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
 				service.On("RegisterUser", mock.Anything, &dto.RegisterUser{
-					Login:    "testuser",
-					Password: "testpassword",
-				}).Once().Return("", errors.New("service error"))
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+				}).Once().Return(nil, errors.New("service error"))
 				return service
 			}(),
 			wantError:          true,
@@ -79,13 +107,18 @@ func TestHandler_RegisterUser(t *testing.T) {
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
 				service.On("RegisterUser", mock.Anything, &dto.RegisterUser{
-					Login:    "testuser",
-					Password: "testpassword",
-				}).Once().Return("testtoken", nil)
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+				}).Once().Return(&response.Tokens{
+					AccessToken:  "testtoken",
+					RefreshToken: "refreshtoken",
+				}, nil)
 				return service
 			}(),
 			expectedStatusCode: http.StatusOK,
 			expectedAuthHeader: "Bearer testtoken",
+			expectedResponse:   `{"access_token":"testtoken","refresh_token":"refreshtoken"}`,
 		},
 	}
 
@@ -95,6 +128,7 @@ func TestHandler_RegisterUser(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest("POST", "/user/register", strings.NewReader(tt.requestBody))
+			r.Header.Set("user-agent", "test-agent")
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
@@ -106,6 +140,11 @@ func TestHandler_RegisterUser(t *testing.T) {
 			if !tt.wantError {
 				assert.Equal(t, tt.expectedAuthHeader, res.Header.Get("authorization"))
 			}
+			if tt.expectedResponse != "" {
+				resBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.expectedResponse, string(resBody))
+			}
 		})
 	}
 }
@@ -121,6 +160,8 @@ func TestHandler_Login(t *testing.T) {
 		expectedStatusCode int
 		wantError          bool
 		expectedAuthHeader string
+		expectedRetryAfter string
+		expectedResponse   string
 	}{
 		"failed to decode body": {
 			requestBody:        `s`,
@@ -132,22 +173,42 @@ func TestHandler_Login(t *testing.T) {
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
 				service.On("Login", mock.Anything, &dto.Login{
-					Login:    "testuser",
-					Password: "testpassword",
-				}).Once().Return("", application.ErrUnauthorized)
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+					ClientIP:  "192.0.2.1",
+				}).Once().Return(nil, application.ErrUnauthorized)
 				return service
 			}(),
 			wantError:          true,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		"service error too many attempts": {
+			requestBody: `{"login": "testuser", "password": "testpassword"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("Login", mock.Anything, &dto.Login{
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+					ClientIP:  "192.0.2.1",
+				}).Once().Return(nil, &application.ThrottledError{RetryAfter: 2500 * time.Millisecond})
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedRetryAfter: "3",
+		},
 		"service error internal": {
 			requestBody: `{"login": "testuser", "password": "testpassword"}`,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
 				service.On("Login", mock.Anything, &dto.Login{
-					Login:    "testuser",
-					Password: "testpassword",
-				}).Once().Return("", errors.New("service error"))
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+					ClientIP:  "192.0.2.1",
+				}).Once().Return(nil, errors.New("service error"))
 				return service
 			}(),
 			wantError:          true,
@@ -158,13 +219,19 @@ func TestHandler_Login(t *testing.T) {
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
 				service.On("Login", mock.Anything, &dto.Login{
-					Login:    "testuser",
-					Password: "testpassword",
-				}).Once().Return("testtoken", nil)
+					Login:     "testuser",
+					Password:  "testpassword",
+					UserAgent: "test-agent",
+					ClientIP:  "192.0.2.1",
+				}).Once().Return(&response.Tokens{
+					AccessToken:  "testtoken",
+					RefreshToken: "refreshtoken",
+				}, nil)
 				return service
 			}(),
 			expectedStatusCode: http.StatusOK,
 			expectedAuthHeader: "Bearer testtoken",
+			expectedResponse:   `{"access_token":"testtoken","refresh_token":"refreshtoken"}`,
 		},
 	}
 
@@ -174,6 +241,7 @@ func TestHandler_Login(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest("POST", "/user/login", strings.NewReader(tt.requestBody))
+			r.Header.Set("user-agent", "test-agent")
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
@@ -182,99 +250,80 @@ func TestHandler_Login(t *testing.T) {
 			defer res.Body.Close()
 
 			require.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedRetryAfter, res.Header.Get("Retry-After"))
 			if !tt.wantError {
 				assert.Equal(t, tt.expectedAuthHeader, res.Header.Get("authorization"))
+
+				resBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.expectedResponse, string(resBody))
 			}
 		})
 	}
 }
 
-func TestHandler_UploadOrder(t *testing.T) {
+func TestHandler_RefreshToken(t *testing.T) {
 	dummyLogger := &logger.Logger{
 		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
 	}
 
-	userID := uuid.New()
-
 	tests := map[string]struct {
-		ctx                context.Context
-		requestBody        io.Reader
+		requestBody        string
 		serviceMock        *mocks.Service
+		wantError          bool
 		expectedStatusCode int
+		expectedAuthHeader string
+		expectedResponse   string
 	}{
-		"failed to get user id from context": {
-			ctx:                context.Background(),
-			expectedStatusCode: http.StatusInternalServerError,
-		},
-		"failed to read body": {
-			ctx:                auth.SetUserIDToContext(context.Background(), userID),
-			requestBody:        &failReader{},
+		"failed to decode body": {
+			requestBody:        `s`,
+			wantError:          true,
 			expectedStatusCode: http.StatusBadRequest,
 		},
-		"service error already exists": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader("1234"),
-			serviceMock: func() *mocks.Service {
-				service := mocks.NewService(t)
-				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
-					UserID:      userID,
-					OrderNumber: "1234",
-				}).Once().Return(nil, application.ErrAlreadyExist)
-				return service
-			}(),
-			expectedStatusCode: http.StatusOK,
-		},
-		"service error conflict": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader("1234"),
-			serviceMock: func() *mocks.Service {
-				service := mocks.NewService(t)
-				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
-					UserID:      userID,
-					OrderNumber: "1234",
-				}).Once().Return(nil, application.ErrConflict)
-				return service
-			}(),
-			expectedStatusCode: http.StatusConflict,
+		"empty refresh token": {
+			requestBody:        `{"refresh_token": ""}`,
+			wantError:          true,
+			expectedStatusCode: http.StatusBadRequest,
 		},
-		"service error unprocessable": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader("1234"),
+		"service error unauthorized": {
+			requestBody: `{"refresh_token": "refreshtoken"}`,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
-					UserID:      userID,
-					OrderNumber: "1234",
-				}).Once().Return(nil, application.ErrUnprocessable)
+				service.On("RefreshToken", mock.Anything, &dto.RefreshToken{
+					RefreshToken: "refreshtoken",
+				}).Once().Return(nil, application.ErrUnauthorized)
 				return service
 			}(),
-			expectedStatusCode: http.StatusUnprocessableEntity,
+			wantError:          true,
+			expectedStatusCode: http.StatusUnauthorized,
 		},
 		"service error internal": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader("1234"),
+			requestBody: `{"refresh_token": "refreshtoken"}`,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
-					UserID:      userID,
-					OrderNumber: "1234",
+				service.On("RefreshToken", mock.Anything, &dto.RefreshToken{
+					RefreshToken: "refreshtoken",
 				}).Once().Return(nil, errors.New("service error"))
 				return service
 			}(),
+			wantError:          true,
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 		"success": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader("1234"),
+			requestBody: `{"refresh_token": "refreshtoken"}`,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
-					UserID:      userID,
-					OrderNumber: "1234",
-				}).Once().Return(&model.Order{}, nil)
+				service.On("RefreshToken", mock.Anything, &dto.RefreshToken{
+					RefreshToken: "refreshtoken",
+				}).Once().Return(&response.Tokens{
+					AccessToken:  "newtoken",
+					RefreshToken: "newrefreshtoken",
+				}, nil)
 				return service
 			}(),
-			expectedStatusCode: http.StatusAccepted,
+			expectedStatusCode: http.StatusOK,
+			expectedAuthHeader: "Bearer newtoken",
+			expectedResponse:   `{"access_token":"newtoken","refresh_token":"newrefreshtoken"}`,
 		},
 	}
 
@@ -283,82 +332,69 @@ func TestHandler_UploadOrder(t *testing.T) {
 			t.Parallel()
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("POST", "/user/orders", tt.requestBody)
-			r = r.WithContext(tt.ctx)
+			r := httptest.NewRequest("POST", "/user/token/refresh", strings.NewReader(tt.requestBody))
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
-			h.UploadOrder(w, r)
+			h.RefreshToken(w, r)
+			res := w.Result()
+			defer res.Body.Close()
 
-			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			require.Equal(t, tt.expectedStatusCode, w.Code)
+			if !tt.wantError {
+				assert.Equal(t, tt.expectedAuthHeader, res.Header.Get("authorization"))
+
+				resBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.expectedResponse, string(resBody))
+			}
 		})
 	}
 }
 
-func TestHandler_ListUserOrders(t *testing.T) {
+func TestHandler_Logout(t *testing.T) {
 	dummyLogger := &logger.Logger{
 		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
 	}
 
 	userID := uuid.New()
+	sessionID := uuid.New()
 
 	tests := map[string]struct {
 		ctx                context.Context
 		serviceMock        *mocks.Service
-		wantError          bool
 		expectedStatusCode int
-		expectedResponse   string
 	}{
 		"failed to get user id from context": {
 			ctx:                context.Background(),
-			wantError:          true,
 			expectedStatusCode: http.StatusInternalServerError,
 		},
-		"service error no data": {
-			ctx: auth.SetUserIDToContext(context.Background(), userID),
+		"service error internal": {
+			ctx: auth.SetSessionIDToContext(auth.SetUserIDToContext(context.Background(), userID), sessionID),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("ListUserOrders", mock.Anything, userID).Once().Return(nil, application.ErrNoData)
+				service.On("Logout", mock.Anything, userID, sessionID).Once().Return(errors.New("service error"))
 				return service
 			}(),
-			wantError:          true,
-			expectedStatusCode: http.StatusNoContent,
+			expectedStatusCode: http.StatusInternalServerError,
 		},
-		"service error internal": {
+		"token without session": {
 			ctx: auth.SetUserIDToContext(context.Background(), userID),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("ListUserOrders", mock.Anything, userID).Once().Return(nil, errors.New("service error"))
+				service.On("Logout", mock.Anything, userID, uuid.Nil).Once().Return(nil)
 				return service
 			}(),
-			wantError:          true,
-			expectedStatusCode: http.StatusInternalServerError,
+			expectedStatusCode: http.StatusOK,
 		},
 		"success": {
-			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			ctx: auth.SetSessionIDToContext(auth.SetUserIDToContext(context.Background(), userID), sessionID),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("ListUserOrders", mock.Anything, userID).Once().
-					Return([]*response.UserOrder{
-						{
-							Number:     "1234",
-							Status:     "PROCESSING",
-							Accrual:    0,
-							UploadedAt: "some-time",
-						},
-						{
-							Number:     "5678",
-							Status:     "PROCESSED",
-							Accrual:    5,
-							UploadedAt: "diff-time",
-						},
-					}, nil)
+				service.On("Logout", mock.Anything, userID, sessionID).Once().Return(nil)
 				return service
 			}(),
 			expectedStatusCode: http.StatusOK,
-			expectedResponse: `[
-			{"number": "1234", "status": "PROCESSING", "uploaded_at": "some-time"},
-			{"number": "5678", "status": "PROCESSED", "accrual": 5, "uploaded_at": "diff-time"}]`,
 		},
 	}
 
@@ -367,34 +403,26 @@ func TestHandler_ListUserOrders(t *testing.T) {
 			t.Parallel()
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("GET", "/user/orders", nil)
+			r := httptest.NewRequest("POST", "/user/logout", nil)
 			r = r.WithContext(tt.ctx)
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
-			h.ListUserOrders(w, r)
-
-			res := w.Result()
-			defer res.Body.Close()
-
-			require.Equal(t, tt.expectedStatusCode, w.Code)
-			if !tt.wantError {
-				resBody, err := io.ReadAll(res.Body)
-				require.NoError(t, err)
+			h.Logout(w, r)
 
-				assert.Equal(t, "application/json", res.Header.Get("content-type"))
-				assert.JSONEq(t, tt.expectedResponse, string(resBody))
-			}
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
 		})
 	}
 }
 
-func TestHandler_GetUserBalance(t *testing.T) {
+func TestHandler_ListUserSessions(t *testing.T) {
 	dummyLogger := &logger.Logger{
 		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
 	}
 
 	userID := uuid.New()
+	sessionID := uuid.New()
+	ctx := auth.SetSessionIDToContext(auth.SetUserIDToContext(context.Background(), userID), sessionID)
 
 	tests := map[string]struct {
 		ctx                context.Context
@@ -408,11 +436,22 @@ func TestHandler_GetUserBalance(t *testing.T) {
 			wantError:          true,
 			expectedStatusCode: http.StatusInternalServerError,
 		},
-		"service error intenal": {
-			ctx: auth.SetUserIDToContext(context.Background(), userID),
+		"service error no data": {
+			ctx: ctx,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("GetUserBalance", mock.Anything, userID).Once().
+				service.On("ListUserSessions", mock.Anything, userID, sessionID).Once().
+					Return(nil, application.ErrNoData)
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"service error internal": {
+			ctx: ctx,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserSessions", mock.Anything, userID, sessionID).Once().
 					Return(nil, errors.New("service error"))
 				return service
 			}(),
@@ -420,18 +459,25 @@ func TestHandler_GetUserBalance(t *testing.T) {
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 		"success": {
-			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			ctx: ctx,
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("GetUserBalance", mock.Anything, userID).Once().
-					Return(&response.UserBalance{
-						Current:   5,
-						Withdrawn: 55.8,
+				service.On("ListUserSessions", mock.Anything, userID, sessionID).Once().
+					Return([]*response.UserSession{
+						{
+							ID:         sessionID.String(),
+							UserAgent:  "test-agent",
+							StartedAt:  "start-time",
+							LastUsedAt: "used-time",
+							ExpiresAt:  "expire-time",
+							Current:    true,
+						},
 					}, nil)
 				return service
 			}(),
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   `{"current": 5, "withdrawn": 55.8}`,
+			expectedResponse: `[{"id": "` + sessionID.String() + `", "user_agent": "test-agent",
+			"started_at": "start-time", "last_used_at": "used-time", "expires_at": "expire-time", "current": true}]`,
 		},
 	}
 
@@ -440,12 +486,12 @@ func TestHandler_GetUserBalance(t *testing.T) {
 			t.Parallel()
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("GET", "/user/balance", nil)
+			r := httptest.NewRequest("GET", "/user/sessions", nil)
 			r = r.WithContext(tt.ctx)
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
-			h.GetUserBalance(w, r)
+			h.ListUserSessions(w, r)
 
 			res := w.Result()
 			defer res.Body.Close()
@@ -462,80 +508,56 @@ func TestHandler_GetUserBalance(t *testing.T) {
 	}
 }
 
-func TestHandler_WithdrawUserBonuses(t *testing.T) {
+func TestHandler_RevokeUserSession(t *testing.T) {
 	dummyLogger := &logger.Logger{
 		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
 	}
 
 	userID := uuid.New()
+	sessionID := uuid.New()
 
 	tests := map[string]struct {
 		ctx                context.Context
-		requestBody        io.Reader
+		id                 string
 		serviceMock        *mocks.Service
 		expectedStatusCode int
 	}{
 		"failed to get user id from context": {
 			ctx:                context.Background(),
+			id:                 sessionID.String(),
 			expectedStatusCode: http.StatusInternalServerError,
 		},
-		"failed to decode request body": {
+		"invalid session id": {
 			ctx:                auth.SetUserIDToContext(context.Background(), userID),
-			requestBody:        &failReader{},
+			id:                 "session",
 			expectedStatusCode: http.StatusBadRequest,
 		},
-		"service error not enough bonuses": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
-			serviceMock: func() *mocks.Service {
-				service := mocks.NewService(t)
-				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
-					UserID:      userID,
-					OrderNumber: "1234",
-					Sum:         50,
-				}).Once().Return(application.ErrNotEnoughBonuses)
-				return service
-			}(),
-			expectedStatusCode: http.StatusPaymentRequired,
-		},
-		"service error unprocessable": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+		"service error not found": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			id:  sessionID.String(),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
-					UserID:      userID,
-					OrderNumber: "1234",
-					Sum:         50,
-				}).Once().Return(application.ErrUnprocessable)
+				service.On("RevokeUserSession", mock.Anything, userID, sessionID).Once().Return(application.ErrNotFound)
 				return service
 			}(),
-			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedStatusCode: http.StatusNotFound,
 		},
 		"service error internal": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			id:  sessionID.String(),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
-					UserID:      userID,
-					OrderNumber: "1234",
-					Sum:         50,
-				}).Once().Return(errors.New("service error"))
+				service.On("RevokeUserSession", mock.Anything, userID, sessionID).Once().Return(errors.New("service error"))
 				return service
 			}(),
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 		"success": {
-			ctx:         auth.SetUserIDToContext(context.Background(), userID),
-			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			id:  sessionID.String(),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
-					UserID:      userID,
-					OrderNumber: "1234",
-					Sum:         50,
-				}).Once().Return(nil)
+				service.On("RevokeUserSession", mock.Anything, userID, sessionID).Once().Return(nil)
 				return service
 			}(),
 			expectedStatusCode: http.StatusOK,
@@ -547,19 +569,19 @@ func TestHandler_WithdrawUserBonuses(t *testing.T) {
 			t.Parallel()
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("POST", "/user/balance/withdraw", tt.requestBody)
-			r = r.WithContext(tt.ctx)
+			r := httptest.NewRequest("DELETE", "/user/sessions/"+tt.id, nil)
+			r = withURLParam(r.WithContext(tt.ctx), "id", tt.id)
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
-			h.WithdrawUserBonuses(w, r)
+			h.RevokeUserSession(w, r)
 
 			assert.Equal(t, tt.expectedStatusCode, w.Code)
 		})
 	}
 }
 
-func TestHandler_ListUserWithdrawals(t *testing.T) {
+func TestHandler_RevokeUserSessions(t *testing.T) {
 	dummyLogger := &logger.Logger{
 		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
 	}
@@ -569,60 +591,235 @@ func TestHandler_ListUserWithdrawals(t *testing.T) {
 	tests := map[string]struct {
 		ctx                context.Context
 		serviceMock        *mocks.Service
-		wantError          bool
 		expectedStatusCode int
-		expectedResponse   string
 	}{
 		"failed to get user id from context": {
 			ctx:                context.Background(),
-			wantError:          true,
 			expectedStatusCode: http.StatusInternalServerError,
 		},
-		"service error no data": {
-			ctx: auth.SetUserIDToContext(context.Background(), userID),
-			serviceMock: func() *mocks.Service {
-				service := mocks.NewService(t)
-				service.On("ListUserWithdrawals", mock.Anything, userID).Once().
-					Return(nil, application.ErrNoData)
-				return service
-			}(),
-			wantError:          true,
-			expectedStatusCode: http.StatusNoContent,
-		},
 		"service error internal": {
 			ctx: auth.SetUserIDToContext(context.Background(), userID),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("ListUserWithdrawals", mock.Anything, userID).Once().
-					Return(nil, errors.New("service error"))
+				service.On("RevokeUserSessions", mock.Anything, userID).Once().Return(errors.New("service error"))
 				return service
 			}(),
-			wantError:          true,
 			expectedStatusCode: http.StatusInternalServerError,
 		},
 		"success": {
 			ctx: auth.SetUserIDToContext(context.Background(), userID),
 			serviceMock: func() *mocks.Service {
 				service := mocks.NewService(t)
-				service.On("ListUserWithdrawals", mock.Anything, userID).Once().
-					Return([]*response.UserWithdrawal{
-						{
-							OrderNumber: "1234",
-							Sum:         50.8,
-							ProcessedAt: "some-time",
+				service.On("RevokeUserSessions", mock.Anything, userID).Once().Return(nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("DELETE", "/user/sessions", nil)
+			r = r.WithContext(tt.ctx)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.RevokeUserSessions(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_UploadOrder(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		ctx                context.Context
+		requestBody        io.Reader
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+		expectedRetryAfter string
+	}{
+		"failed to get user id from context": {
+			ctx:                context.Background(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"failed to read body": {
+			ctx:                auth.SetUserIDToContext(context.Background(), userID),
+			requestBody:        &failReader{},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"service error already exists": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader("1234"),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
+					UserID:      userID,
+					OrderNumber: "1234",
+				}).Once().Return(nil, application.ErrAlreadyExist)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+		},
+		"service error conflict": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader("1234"),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
+					UserID:      userID,
+					OrderNumber: "1234",
+				}).Once().Return(nil, application.ErrConflict)
+				return service
+			}(),
+			expectedStatusCode: http.StatusConflict,
+		},
+		"service error unprocessable": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader("1234"),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
+					UserID:      userID,
+					OrderNumber: "1234",
+				}).Once().Return(nil, application.ErrUnprocessable)
+				return service
+			}(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		"service error internal": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader("1234"),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
+					UserID:      userID,
+					OrderNumber: "1234",
+				}).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"service busy": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader("1234"),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
+					UserID:      userID,
+					OrderNumber: "1234",
+				}).Once().Return(nil, application.ErrBusy)
+				return service
+			}(),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedRetryAfter: "5",
+		},
+		"success": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader("1234"),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("UploadOrder", mock.Anything, &dto.UploadOrder{
+					UserID:      userID,
+					OrderNumber: "1234",
+				}).Once().Return(&model.Order{}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusAccepted,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/user/orders", tt.requestBody)
+			r = r.WithContext(tt.ctx)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.UploadOrder(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectedRetryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestHandler_ListUserOrders(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		ctx                context.Context
+		serviceMock        *mocks.Service
+		wantError          bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		"failed to get user id from context": {
+			ctx:                context.Background(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"service error no data": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserOrders", mock.Anything, userID).Once().Return(nil, application.ErrNoData)
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"service error internal": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserOrders", mock.Anything, userID).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserOrders", mock.Anything, userID).Once().
+					Return([]*response.UserOrder{
+						{
+							Number:     "1234",
+							Status:     "PROCESSING",
+							Accrual:    0,
+							UploadedAt: "some-time",
 						},
 						{
-							OrderNumber: "5678",
-							Sum:         3,
-							ProcessedAt: "diff-time",
+							Number:     "5678",
+							Status:     "PROCESSED",
+							Accrual:    5,
+							UploadedAt: "diff-time",
 						},
 					}, nil)
 				return service
 			}(),
 			expectedStatusCode: http.StatusOK,
 			expectedResponse: `[
-			{"order": "1234", "sum": 50.8, "processed_at": "some-time"},
-			{"order": "5678", "sum": 3, "processed_at": "diff-time"}]`,
+			{"number": "1234", "status": "PROCESSING", "uploaded_at": "some-time"},
+			{"number": "5678", "status": "PROCESSED", "accrual": 5, "uploaded_at": "diff-time"}]`,
 		},
 	}
 
@@ -631,12 +828,12 @@ func TestHandler_ListUserWithdrawals(t *testing.T) {
 			t.Parallel()
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("GET", "/user/withdrawals", nil)
+			r := httptest.NewRequest("GET", "/user/orders", nil)
 			r = r.WithContext(tt.ctx)
 
 			h := handler.New(tt.serviceMock, dummyLogger)
 
-			h.ListUserWithdrawals(w, r)
+			h.ListUserOrders(w, r)
 
 			res := w.Result()
 			defer res.Body.Close()
@@ -652,3 +849,917 @@ func TestHandler_ListUserWithdrawals(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetUserOrder(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		ctx                context.Context
+		serviceMock        *mocks.Service
+		wantError          bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		"failed to get user id from context": {
+			ctx:                context.Background(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"service error not found": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("GetUserOrder", mock.Anything, userID, "1234").Once().Return(nil, application.ErrNotFound)
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"service error internal": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("GetUserOrder", mock.Anything, userID, "1234").Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("GetUserOrder", mock.Anything, userID, "1234").Once().
+					Return(&response.UserOrderDetails{
+						Number:     "1234",
+						Status:     "PROCESSED",
+						Accrual:    5,
+						UploadedAt: "some-time",
+						Timeline: []*response.OrderEvent{
+							{Type: "UPLOADED", Status: "NEW", CreatedAt: "some-time"},
+							{Type: "CHECKED", Status: "NEW", Error: "order is not registered in accrual system yet", CreatedAt: "diff-time"},
+							{Type: "STATUS_CHANGED", Status: "PROCESSED", Accrual: 5, CreatedAt: "last-time"},
+						},
+					}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: `{"number": "1234", "status": "PROCESSED", "accrual": 5, "uploaded_at": "some-time", "timeline": [
+			{"type": "UPLOADED", "status": "NEW", "created_at": "some-time"},
+			{"type": "CHECKED", "status": "NEW", "error": "order is not registered in accrual system yet", "created_at": "diff-time"},
+			{"type": "STATUS_CHANGED", "status": "PROCESSED", "accrual": 5, "created_at": "last-time"}]}`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/user/orders/1234", nil)
+			r = withURLParam(r.WithContext(tt.ctx), "number", "1234")
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.GetUserOrder(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatusCode, w.Code)
+			if !tt.wantError {
+				resBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, "application/json", res.Header.Get("content-type"))
+				assert.JSONEq(t, tt.expectedResponse, string(resBody))
+			}
+		})
+	}
+}
+
+func TestHandler_GetUserBalance(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		ctx                context.Context
+		serviceMock        *mocks.Service
+		wantError          bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		"failed to get user id from context": {
+			ctx:                context.Background(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"service error intenal": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("GetUserBalance", mock.Anything, userID).Once().
+					Return(nil, errors.New("service error"))
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("GetUserBalance", mock.Anything, userID).Once().
+					Return(&response.UserBalance{
+						Current:   5,
+						Withdrawn: 55.8,
+					}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"current": 5, "withdrawn": 55.8}`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/user/balance", nil)
+			r = r.WithContext(tt.ctx)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.GetUserBalance(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatusCode, w.Code)
+			if !tt.wantError {
+				resBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, "application/json", res.Header.Get("content-type"))
+				assert.JSONEq(t, tt.expectedResponse, string(resBody))
+			}
+		})
+	}
+}
+
+func TestHandler_WithdrawUserBonuses(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		ctx                context.Context
+		requestBody        io.Reader
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+	}{
+		"failed to get user id from context": {
+			ctx:                context.Background(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"failed to decode request body": {
+			ctx:                auth.SetUserIDToContext(context.Background(), userID),
+			requestBody:        &failReader{},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"service error not enough bonuses": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
+					UserID:      userID,
+					OrderNumber: "1234",
+					Sum:         50,
+				}).Once().Return(application.ErrNotEnoughBonuses)
+				return service
+			}(),
+			expectedStatusCode: http.StatusPaymentRequired,
+		},
+		"service error unprocessable": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
+					UserID:      userID,
+					OrderNumber: "1234",
+					Sum:         50,
+				}).Once().Return(application.ErrUnprocessable)
+				return service
+			}(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		"service error internal": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
+					UserID:      userID,
+					OrderNumber: "1234",
+					Sum:         50,
+				}).Once().Return(errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			ctx:         auth.SetUserIDToContext(context.Background(), userID),
+			requestBody: strings.NewReader(`{"order": "1234", "sum": 50}`),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("WithdrawUserBonuses", mock.Anything, &dto.WithdrawBonuses{
+					UserID:      userID,
+					OrderNumber: "1234",
+					Sum:         50,
+				}).Once().Return(nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/user/balance/withdraw", tt.requestBody)
+			r = r.WithContext(tt.ctx)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.WithdrawUserBonuses(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_ListUserWithdrawals(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		ctx                context.Context
+		serviceMock        *mocks.Service
+		wantError          bool
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		"failed to get user id from context": {
+			ctx:                context.Background(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"service error no data": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserWithdrawals", mock.Anything, userID).Once().
+					Return(nil, application.ErrNoData)
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"service error internal": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserWithdrawals", mock.Anything, userID).Once().
+					Return(nil, errors.New("service error"))
+				return service
+			}(),
+			wantError:          true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			ctx: auth.SetUserIDToContext(context.Background(), userID),
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListUserWithdrawals", mock.Anything, userID).Once().
+					Return([]*response.UserWithdrawal{
+						{
+							OrderNumber: "1234",
+							Sum:         50.8,
+							ProcessedAt: "some-time",
+						},
+						{
+							OrderNumber: "5678",
+							Sum:         3,
+							ProcessedAt: "diff-time",
+						},
+					}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: `[
+			{"order": "1234", "sum": 50.8, "processed_at": "some-time"},
+			{"order": "5678", "sum": 3, "processed_at": "diff-time"}]`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/user/withdrawals", nil)
+			r = r.WithContext(tt.ctx)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.ListUserWithdrawals(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatusCode, w.Code)
+			if !tt.wantError {
+				resBody, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, "application/json", res.Header.Get("content-type"))
+				assert.JSONEq(t, tt.expectedResponse, string(resBody))
+			}
+		})
+	}
+}
+
+func TestHandler_AccrualEvent(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		requestBody        string
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+	}{
+		"failed to decode body": {
+			requestBody:        `s`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"unknown status": {
+			requestBody:        `{"order": "1234", "status": "DONE"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"no order number": {
+			requestBody:        `{"status": "PROCESSED", "accrual": 50}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"service error not found": {
+			requestBody: `{"order": "1234", "status": "PROCESSED", "accrual": 50}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ApplyAccrualEvent", mock.Anything, &model.AccrualOrder{
+					Number:  "1234",
+					Status:  model.AccrualOrderStatusProcessed,
+					Accrual: 50,
+				}).Once().Return(nil, application.ErrNotFound)
+				return service
+			}(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"service error internal": {
+			requestBody: `{"order": "1234", "status": "PROCESSED", "accrual": 50}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ApplyAccrualEvent", mock.Anything, &model.AccrualOrder{
+					Number:  "1234",
+					Status:  model.AccrualOrderStatusProcessed,
+					Accrual: 50,
+				}).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			requestBody: `{"order": "1234", "status": "PROCESSED", "accrual": 50}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ApplyAccrualEvent", mock.Anything, &model.AccrualOrder{
+					Number:  "1234",
+					Status:  model.AccrualOrderStatusProcessed,
+					Accrual: 50,
+				}).Once().Return(&model.Order{}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/internal/accrual/events", strings.NewReader(tt.requestBody))
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.AccrualEvent(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_ListOrderDeadLetters(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"no dead letters": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListOrderDeadLetters", mock.Anything).Once().Return(nil, application.ErrNoData)
+				return service
+			}(),
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"service error": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListOrderDeadLetters", mock.Anything).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListOrderDeadLetters", mock.Anything).Once().Return([]*response.OrderDeadLetter{
+					{
+						Number:    "1234",
+						Error:     "internal service error",
+						Attempts:  3,
+						CreatedAt: "2025-06-10T10:00:00Z",
+						UpdatedAt: "2025-06-10T11:00:00Z",
+					},
+				}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `[{"number":"1234","error":"internal service error","attempts":3,"created_at":"2025-06-10T10:00:00Z","updated_at":"2025-06-10T11:00:00Z"}]`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/admin/orders/dead-letters", nil)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.ListOrderDeadLetters(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RetryOrderDeadLetter(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+	}{
+		"not found": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("RetryOrderDeadLetter", mock.Anything, "1234").Once().Return(application.ErrNotFound)
+				return service
+			}(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"service error": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("RetryOrderDeadLetter", mock.Anything, "1234").Once().Return(errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("RetryOrderDeadLetter", mock.Anything, "1234").Once().Return(nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusAccepted,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := withURLParam(httptest.NewRequest("POST", "/admin/orders/dead-letters/1234/retry", nil), "number", "1234")
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.RetryOrderDeadLetter(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_RetryAllOrderDeadLetters(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"service error": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("RetryAllOrderDeadLetters", mock.Anything).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("RetryAllOrderDeadLetters", mock.Anything).Once().Return(&response.RetriedOrders{Retried: 2}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusAccepted,
+			expectedBody:       `{"retried":2}`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/admin/orders/dead-letters/retry", nil)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.RetryAllOrderDeadLetters(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_InvalidateOrderDeadLetter(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+	}{
+		"not found": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("InvalidateOrderDeadLetter", mock.Anything, "1234").Once().Return(application.ErrNotFound)
+				return service
+			}(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"service error": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("InvalidateOrderDeadLetter", mock.Anything, "1234").Once().Return(errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("InvalidateOrderDeadLetter", mock.Anything, "1234").Once().Return(nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := withURLParam(httptest.NewRequest("POST", "/admin/orders/dead-letters/1234/invalidate", nil), "number", "1234")
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.InvalidateOrderDeadLetter(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_ListOrderDiscrepancies(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"no discrepancies": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListOrderDiscrepancies", mock.Anything).Once().Return(nil, application.ErrNoData)
+				return service
+			}(),
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"service error": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListOrderDiscrepancies", mock.Anything).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ListOrderDiscrepancies", mock.Anything).Once().Return([]*response.OrderDiscrepancy{
+					{
+						ID:              "6c5a1bd8-8f2e-4d7f-9a57-2a5b4f3c9e10",
+						Number:          "1234",
+						Status:          "PROCESSED",
+						Accrual:         50,
+						ExpectedStatus:  "PROCESSED",
+						ExpectedAccrual: 75.5,
+						CreatedAt:       "2025-06-19T10:00:00Z",
+						UpdatedAt:       "2025-06-19T11:00:00Z",
+					},
+				}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `[{"id":"6c5a1bd8-8f2e-4d7f-9a57-2a5b4f3c9e10","number":"1234","status":"PROCESSED","accrual":50,"expected_status":"PROCESSED","expected_accrual":75.5,"created_at":"2025-06-19T10:00:00Z","updated_at":"2025-06-19T11:00:00Z"}]`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/admin/orders/discrepancies", nil)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.ListOrderDiscrepancies(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_AdjustOrderDiscrepancy(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	id := uuid.New()
+	params := &dto.AdjustOrderDiscrepancy{ID: id, Reason: "accrual corrected"}
+
+	tests := map[string]struct {
+		id                 string
+		body               string
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"invalid id": {
+			id:                 "1234",
+			body:               `{"reason":"accrual corrected"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"invalid body": {
+			id:                 id.String(),
+			body:               `{"reason":`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"not found": {
+			id:   id.String(),
+			body: `{"reason":"accrual corrected"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("AdjustOrderDiscrepancy", mock.Anything, params).Once().Return(nil, application.ErrNotFound)
+				return service
+			}(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"already adjusted": {
+			id:   id.String(),
+			body: `{"reason":"accrual corrected"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("AdjustOrderDiscrepancy", mock.Anything, params).Once().Return(nil, application.ErrConflict)
+				return service
+			}(),
+			expectedStatusCode: http.StatusConflict,
+		},
+		"not enough bonuses": {
+			id:   id.String(),
+			body: `{"reason":"accrual corrected"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("AdjustOrderDiscrepancy", mock.Anything, params).Once().Return(nil, application.ErrNotEnoughBonuses)
+				return service
+			}(),
+			expectedStatusCode: http.StatusConflict,
+		},
+		"not adjustable": {
+			id:   id.String(),
+			body: `{"reason":""}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("AdjustOrderDiscrepancy", mock.Anything, &dto.AdjustOrderDiscrepancy{ID: id}).Once().Return(nil, application.ErrUnprocessable)
+				return service
+			}(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		"service error": {
+			id:   id.String(),
+			body: `{"reason":"accrual corrected"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("AdjustOrderDiscrepancy", mock.Anything, params).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			id:   id.String(),
+			body: `{"reason":"accrual corrected"}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("AdjustOrderDiscrepancy", mock.Anything, params).Once().Return(&response.OrderDiscrepancy{
+					ID:              id.String(),
+					Number:          "1234",
+					Status:          "PROCESSED",
+					Accrual:         50,
+					ExpectedStatus:  "PROCESSED",
+					ExpectedAccrual: 75.5,
+					Adjustment:      25.5,
+					Reason:          "accrual corrected",
+					CreatedAt:       "2025-06-19T10:00:00Z",
+					UpdatedAt:       "2025-06-19T11:00:00Z",
+					AdjustedAt:      "2025-06-19T11:00:00Z",
+				}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"id":"` + id.String() + `","number":"1234","status":"PROCESSED","accrual":50,"expected_status":"PROCESSED","expected_accrual":75.5,"adjustment":25.5,"reason":"accrual corrected","created_at":"2025-06-19T10:00:00Z","updated_at":"2025-06-19T11:00:00Z","adjusted_at":"2025-06-19T11:00:00Z"}`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := withURLParam(
+				httptest.NewRequest("POST", "/admin/orders/discrepancies/"+tt.id+"/adjust", strings.NewReader(tt.body)),
+				"id", tt.id,
+			)
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.AdjustOrderDiscrepancy(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandler_ResizeWorkerPool(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	tests := map[string]struct {
+		requestBody        string
+		serviceMock        *mocks.Service
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"invalid body": {
+			requestBody:        "size",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"size is not positive": {
+			requestBody: `{"size":0}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ResizeWorkerPool", mock.Anything, &dto.ResizeWorkerPool{Size: 0}).Once().Return(nil, application.ErrUnprocessable)
+				return service
+			}(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		"service error": {
+			requestBody: `{"size":10}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ResizeWorkerPool", mock.Anything, &dto.ResizeWorkerPool{Size: 10}).Once().Return(nil, errors.New("service error"))
+				return service
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		"success": {
+			requestBody: `{"size":10}`,
+			serviceMock: func() *mocks.Service {
+				service := mocks.NewService(t)
+				service.On("ResizeWorkerPool", mock.Anything, &dto.ResizeWorkerPool{Size: 10}).Once().Return(&response.WorkerPoolStats{
+					Workers:    10,
+					QueueSize:  25,
+					AvgRunTime: "0s",
+				}, nil)
+				return service
+			}(),
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"workers":10,"active":0,"queued":0,"queue_size":25,"scheduled":0,` +
+				`"completed":0,"failed":0,"rejected":0,"joined":0,"avg_run_time":"0s"}`,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("PUT", "/admin/workerpool", strings.NewReader(tt.requestBody))
+
+			h := handler.New(tt.serviceMock, dummyLogger)
+
+			h.ResizeWorkerPool(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
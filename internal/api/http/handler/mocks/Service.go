@@ -29,6 +29,124 @@ func (_m *Service) EXPECT() *Service_Expecter {
 	return &Service_Expecter{mock: &_m.Mock}
 }
 
+// AdjustOrderDiscrepancy provides a mock function with given fields: ctx, dto
+func (_m *Service) AdjustOrderDiscrepancy(ctx context.Context, dto *request.AdjustOrderDiscrepancy) (*response.OrderDiscrepancy, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdjustOrderDiscrepancy")
+	}
+
+	var r0 *response.OrderDiscrepancy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *request.AdjustOrderDiscrepancy) (*response.OrderDiscrepancy, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *request.AdjustOrderDiscrepancy) *response.OrderDiscrepancy); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.OrderDiscrepancy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *request.AdjustOrderDiscrepancy) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_AdjustOrderDiscrepancy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdjustOrderDiscrepancy'
+type Service_AdjustOrderDiscrepancy_Call struct {
+	*mock.Call
+}
+
+// AdjustOrderDiscrepancy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *request.AdjustOrderDiscrepancy
+func (_e *Service_Expecter) AdjustOrderDiscrepancy(ctx interface{}, dto interface{}) *Service_AdjustOrderDiscrepancy_Call {
+	return &Service_AdjustOrderDiscrepancy_Call{Call: _e.mock.On("AdjustOrderDiscrepancy", ctx, dto)}
+}
+
+func (_c *Service_AdjustOrderDiscrepancy_Call) Run(run func(ctx context.Context, dto *request.AdjustOrderDiscrepancy)) *Service_AdjustOrderDiscrepancy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*request.AdjustOrderDiscrepancy))
+	})
+	return _c
+}
+
+func (_c *Service_AdjustOrderDiscrepancy_Call) Return(_a0 *response.OrderDiscrepancy, _a1 error) *Service_AdjustOrderDiscrepancy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_AdjustOrderDiscrepancy_Call) RunAndReturn(run func(context.Context, *request.AdjustOrderDiscrepancy) (*response.OrderDiscrepancy, error)) *Service_AdjustOrderDiscrepancy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ApplyAccrualEvent provides a mock function with given fields: ctx, event
+func (_m *Service) ApplyAccrualEvent(ctx context.Context, event *model.AccrualOrder) (*model.Order, error) {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyAccrualEvent")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.AccrualOrder) (*model.Order, error)); ok {
+		return rf(ctx, event)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *model.AccrualOrder) *model.Order); ok {
+		r0 = rf(ctx, event)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *model.AccrualOrder) error); ok {
+		r1 = rf(ctx, event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_ApplyAccrualEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyAccrualEvent'
+type Service_ApplyAccrualEvent_Call struct {
+	*mock.Call
+}
+
+// ApplyAccrualEvent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - event *model.AccrualOrder
+func (_e *Service_Expecter) ApplyAccrualEvent(ctx interface{}, event interface{}) *Service_ApplyAccrualEvent_Call {
+	return &Service_ApplyAccrualEvent_Call{Call: _e.mock.On("ApplyAccrualEvent", ctx, event)}
+}
+
+func (_c *Service_ApplyAccrualEvent_Call) Run(run func(ctx context.Context, event *model.AccrualOrder)) *Service_ApplyAccrualEvent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*model.AccrualOrder))
+	})
+	return _c
+}
+
+func (_c *Service_ApplyAccrualEvent_Call) Return(_a0 *model.Order, _a1 error) *Service_ApplyAccrualEvent_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_ApplyAccrualEvent_Call) RunAndReturn(run func(context.Context, *model.AccrualOrder) (*model.Order, error)) *Service_ApplyAccrualEvent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUserBalance provides a mock function with given fields: ctx, id
 func (_m *Service) GetUserBalance(ctx context.Context, id uuid.UUID) (*response.UserBalance, error) {
 	ret := _m.Called(ctx, id)
@@ -88,29 +206,29 @@ func (_c *Service_GetUserBalance_Call) RunAndReturn(run func(context.Context, uu
 	return _c
 }
 
-// ListUserOrders provides a mock function with given fields: ctx, id
-func (_m *Service) ListUserOrders(ctx context.Context, id uuid.UUID) ([]*response.UserOrder, error) {
-	ret := _m.Called(ctx, id)
+// GetUserOrder provides a mock function with given fields: ctx, userID, number
+func (_m *Service) GetUserOrder(ctx context.Context, userID uuid.UUID, number string) (*response.UserOrderDetails, error) {
+	ret := _m.Called(ctx, userID, number)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListUserOrders")
+		panic("no return value specified for GetUserOrder")
 	}
 
-	var r0 []*response.UserOrder
+	var r0 *response.UserOrderDetails
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*response.UserOrder, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (*response.UserOrderDetails, error)); ok {
+		return rf(ctx, userID, number)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*response.UserOrder); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) *response.UserOrderDetails); ok {
+		r0 = rf(ctx, userID, number)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*response.UserOrder)
+			r0 = ret.Get(0).(*response.UserOrderDetails)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, userID, number)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,58 +236,154 @@ func (_m *Service) ListUserOrders(ctx context.Context, id uuid.UUID) ([]*respons
 	return r0, r1
 }
 
-// Service_ListUserOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserOrders'
-type Service_ListUserOrders_Call struct {
+// Service_GetUserOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserOrder'
+type Service_GetUserOrder_Call struct {
 	*mock.Call
 }
 
-// ListUserOrders is a helper method to define mock.On call
+// GetUserOrder is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id uuid.UUID
-func (_e *Service_Expecter) ListUserOrders(ctx interface{}, id interface{}) *Service_ListUserOrders_Call {
-	return &Service_ListUserOrders_Call{Call: _e.mock.On("ListUserOrders", ctx, id)}
+//   - userID uuid.UUID
+//   - number string
+func (_e *Service_Expecter) GetUserOrder(ctx interface{}, userID interface{}, number interface{}) *Service_GetUserOrder_Call {
+	return &Service_GetUserOrder_Call{Call: _e.mock.On("GetUserOrder", ctx, userID, number)}
 }
 
-func (_c *Service_ListUserOrders_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Service_ListUserOrders_Call {
+func (_c *Service_GetUserOrder_Call) Run(run func(ctx context.Context, userID uuid.UUID, number string)) *Service_GetUserOrder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *Service_ListUserOrders_Call) Return(_a0 []*response.UserOrder, _a1 error) *Service_ListUserOrders_Call {
+func (_c *Service_GetUserOrder_Call) Return(_a0 *response.UserOrderDetails, _a1 error) *Service_GetUserOrder_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Service_ListUserOrders_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*response.UserOrder, error)) *Service_ListUserOrders_Call {
+func (_c *Service_GetUserOrder_Call) RunAndReturn(run func(context.Context, uuid.UUID, string) (*response.UserOrderDetails, error)) *Service_GetUserOrder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListUserWithdrawals provides a mock function with given fields: ctx, id
-func (_m *Service) ListUserWithdrawals(ctx context.Context, id uuid.UUID) ([]*response.UserWithdrawal, error) {
-	ret := _m.Called(ctx, id)
+// GetWorkerPoolStats provides a mock function with given fields: ctx
+func (_m *Service) GetWorkerPoolStats(ctx context.Context) *response.WorkerPoolStats {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListUserWithdrawals")
+		panic("no return value specified for GetWorkerPoolStats")
 	}
 
-	var r0 []*response.UserWithdrawal
+	var r0 *response.WorkerPoolStats
+	if rf, ok := ret.Get(0).(func(context.Context) *response.WorkerPoolStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.WorkerPoolStats)
+		}
+	}
+
+	return r0
+}
+
+// Service_GetWorkerPoolStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkerPoolStats'
+type Service_GetWorkerPoolStats_Call struct {
+	*mock.Call
+}
+
+// GetWorkerPoolStats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Service_Expecter) GetWorkerPoolStats(ctx interface{}) *Service_GetWorkerPoolStats_Call {
+	return &Service_GetWorkerPoolStats_Call{Call: _e.mock.On("GetWorkerPoolStats", ctx)}
+}
+
+func (_c *Service_GetWorkerPoolStats_Call) Run(run func(ctx context.Context)) *Service_GetWorkerPoolStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Service_GetWorkerPoolStats_Call) Return(_a0 *response.WorkerPoolStats) *Service_GetWorkerPoolStats_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Service_GetWorkerPoolStats_Call) RunAndReturn(run func(context.Context) *response.WorkerPoolStats) *Service_GetWorkerPoolStats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InvalidateOrderDeadLetter provides a mock function with given fields: ctx, number
+func (_m *Service) InvalidateOrderDeadLetter(ctx context.Context, number string) error {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InvalidateOrderDeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, number)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Service_InvalidateOrderDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InvalidateOrderDeadLetter'
+type Service_InvalidateOrderDeadLetter_Call struct {
+	*mock.Call
+}
+
+// InvalidateOrderDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - number string
+func (_e *Service_Expecter) InvalidateOrderDeadLetter(ctx interface{}, number interface{}) *Service_InvalidateOrderDeadLetter_Call {
+	return &Service_InvalidateOrderDeadLetter_Call{Call: _e.mock.On("InvalidateOrderDeadLetter", ctx, number)}
+}
+
+func (_c *Service_InvalidateOrderDeadLetter_Call) Run(run func(ctx context.Context, number string)) *Service_InvalidateOrderDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Service_InvalidateOrderDeadLetter_Call) Return(_a0 error) *Service_InvalidateOrderDeadLetter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Service_InvalidateOrderDeadLetter_Call) RunAndReturn(run func(context.Context, string) error) *Service_InvalidateOrderDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListOrderDeadLetters provides a mock function with given fields: ctx
+func (_m *Service) ListOrderDeadLetters(ctx context.Context) ([]*response.OrderDeadLetter, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOrderDeadLetters")
+	}
+
+	var r0 []*response.OrderDeadLetter
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*response.UserWithdrawal, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*response.OrderDeadLetter, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*response.UserWithdrawal); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context) []*response.OrderDeadLetter); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*response.UserWithdrawal)
+			r0 = ret.Get(0).([]*response.OrderDeadLetter)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -177,56 +391,57 @@ func (_m *Service) ListUserWithdrawals(ctx context.Context, id uuid.UUID) ([]*re
 	return r0, r1
 }
 
-// Service_ListUserWithdrawals_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserWithdrawals'
-type Service_ListUserWithdrawals_Call struct {
+// Service_ListOrderDeadLetters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListOrderDeadLetters'
+type Service_ListOrderDeadLetters_Call struct {
 	*mock.Call
 }
 
-// ListUserWithdrawals is a helper method to define mock.On call
+// ListOrderDeadLetters is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id uuid.UUID
-func (_e *Service_Expecter) ListUserWithdrawals(ctx interface{}, id interface{}) *Service_ListUserWithdrawals_Call {
-	return &Service_ListUserWithdrawals_Call{Call: _e.mock.On("ListUserWithdrawals", ctx, id)}
+func (_e *Service_Expecter) ListOrderDeadLetters(ctx interface{}) *Service_ListOrderDeadLetters_Call {
+	return &Service_ListOrderDeadLetters_Call{Call: _e.mock.On("ListOrderDeadLetters", ctx)}
 }
 
-func (_c *Service_ListUserWithdrawals_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Service_ListUserWithdrawals_Call {
+func (_c *Service_ListOrderDeadLetters_Call) Run(run func(ctx context.Context)) *Service_ListOrderDeadLetters_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *Service_ListUserWithdrawals_Call) Return(_a0 []*response.UserWithdrawal, _a1 error) *Service_ListUserWithdrawals_Call {
+func (_c *Service_ListOrderDeadLetters_Call) Return(_a0 []*response.OrderDeadLetter, _a1 error) *Service_ListOrderDeadLetters_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Service_ListUserWithdrawals_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*response.UserWithdrawal, error)) *Service_ListUserWithdrawals_Call {
+func (_c *Service_ListOrderDeadLetters_Call) RunAndReturn(run func(context.Context) ([]*response.OrderDeadLetter, error)) *Service_ListOrderDeadLetters_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Login provides a mock function with given fields: ctx, dto
-func (_m *Service) Login(ctx context.Context, dto *request.Login) (string, error) {
-	ret := _m.Called(ctx, dto)
+// ListOrderDiscrepancies provides a mock function with given fields: ctx
+func (_m *Service) ListOrderDiscrepancies(ctx context.Context) ([]*response.OrderDiscrepancy, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Login")
+		panic("no return value specified for ListOrderDiscrepancies")
 	}
 
-	var r0 string
+	var r0 []*response.OrderDiscrepancy
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *request.Login) (string, error)); ok {
-		return rf(ctx, dto)
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*response.OrderDiscrepancy, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *request.Login) string); ok {
-		r0 = rf(ctx, dto)
+	if rf, ok := ret.Get(0).(func(context.Context) []*response.OrderDiscrepancy); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*response.OrderDiscrepancy)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *request.Login) error); ok {
-		r1 = rf(ctx, dto)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -234,56 +449,57 @@ func (_m *Service) Login(ctx context.Context, dto *request.Login) (string, error
 	return r0, r1
 }
 
-// Service_Login_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Login'
-type Service_Login_Call struct {
+// Service_ListOrderDiscrepancies_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListOrderDiscrepancies'
+type Service_ListOrderDiscrepancies_Call struct {
 	*mock.Call
 }
 
-// Login is a helper method to define mock.On call
+// ListOrderDiscrepancies is a helper method to define mock.On call
 //   - ctx context.Context
-//   - dto *request.Login
-func (_e *Service_Expecter) Login(ctx interface{}, dto interface{}) *Service_Login_Call {
-	return &Service_Login_Call{Call: _e.mock.On("Login", ctx, dto)}
+func (_e *Service_Expecter) ListOrderDiscrepancies(ctx interface{}) *Service_ListOrderDiscrepancies_Call {
+	return &Service_ListOrderDiscrepancies_Call{Call: _e.mock.On("ListOrderDiscrepancies", ctx)}
 }
 
-func (_c *Service_Login_Call) Run(run func(ctx context.Context, dto *request.Login)) *Service_Login_Call {
+func (_c *Service_ListOrderDiscrepancies_Call) Run(run func(ctx context.Context)) *Service_ListOrderDiscrepancies_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*request.Login))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *Service_Login_Call) Return(_a0 string, _a1 error) *Service_Login_Call {
+func (_c *Service_ListOrderDiscrepancies_Call) Return(_a0 []*response.OrderDiscrepancy, _a1 error) *Service_ListOrderDiscrepancies_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Service_Login_Call) RunAndReturn(run func(context.Context, *request.Login) (string, error)) *Service_Login_Call {
+func (_c *Service_ListOrderDiscrepancies_Call) RunAndReturn(run func(context.Context) ([]*response.OrderDiscrepancy, error)) *Service_ListOrderDiscrepancies_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RegisterUser provides a mock function with given fields: ctx, dto
-func (_m *Service) RegisterUser(ctx context.Context, dto *request.RegisterUser) (string, error) {
-	ret := _m.Called(ctx, dto)
+// ListUserOrders provides a mock function with given fields: ctx, id
+func (_m *Service) ListUserOrders(ctx context.Context, id uuid.UUID) ([]*response.UserOrder, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RegisterUser")
+		panic("no return value specified for ListUserOrders")
 	}
 
-	var r0 string
+	var r0 []*response.UserOrder
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *request.RegisterUser) (string, error)); ok {
-		return rf(ctx, dto)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*response.UserOrder, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *request.RegisterUser) string); ok {
-		r0 = rf(ctx, dto)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*response.UserOrder); ok {
+		r0 = rf(ctx, id)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*response.UserOrder)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *request.RegisterUser) error); ok {
-		r1 = rf(ctx, dto)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -291,31 +507,634 @@ func (_m *Service) RegisterUser(ctx context.Context, dto *request.RegisterUser)
 	return r0, r1
 }
 
-// Service_RegisterUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterUser'
-type Service_RegisterUser_Call struct {
+// Service_ListUserOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserOrders'
+type Service_ListUserOrders_Call struct {
 	*mock.Call
 }
 
-// RegisterUser is a helper method to define mock.On call
+// ListUserOrders is a helper method to define mock.On call
 //   - ctx context.Context
-//   - dto *request.RegisterUser
-func (_e *Service_Expecter) RegisterUser(ctx interface{}, dto interface{}) *Service_RegisterUser_Call {
-	return &Service_RegisterUser_Call{Call: _e.mock.On("RegisterUser", ctx, dto)}
+//   - id uuid.UUID
+func (_e *Service_Expecter) ListUserOrders(ctx interface{}, id interface{}) *Service_ListUserOrders_Call {
+	return &Service_ListUserOrders_Call{Call: _e.mock.On("ListUserOrders", ctx, id)}
 }
 
-func (_c *Service_RegisterUser_Call) Run(run func(ctx context.Context, dto *request.RegisterUser)) *Service_RegisterUser_Call {
+func (_c *Service_ListUserOrders_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Service_ListUserOrders_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*request.RegisterUser))
+		run(args[0].(context.Context), args[1].(uuid.UUID))
 	})
 	return _c
 }
 
-func (_c *Service_RegisterUser_Call) Return(_a0 string, _a1 error) *Service_RegisterUser_Call {
+func (_c *Service_ListUserOrders_Call) Return(_a0 []*response.UserOrder, _a1 error) *Service_ListUserOrders_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Service_RegisterUser_Call) RunAndReturn(run func(context.Context, *request.RegisterUser) (string, error)) *Service_RegisterUser_Call {
+func (_c *Service_ListUserOrders_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*response.UserOrder, error)) *Service_ListUserOrders_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUserSessions provides a mock function with given fields: ctx, userID, currentID
+func (_m *Service) ListUserSessions(ctx context.Context, userID uuid.UUID, currentID uuid.UUID) ([]*response.UserSession, error) {
+	ret := _m.Called(ctx, userID, currentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserSessions")
+	}
+
+	var r0 []*response.UserSession
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) ([]*response.UserSession, error)); ok {
+		return rf(ctx, userID, currentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) []*response.UserSession); ok {
+		r0 = rf(ctx, userID, currentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*response.UserSession)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID, currentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_ListUserSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserSessions'
+type Service_ListUserSessions_Call struct {
+	*mock.Call
+}
+
+// ListUserSessions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - currentID uuid.UUID
+func (_e *Service_Expecter) ListUserSessions(ctx interface{}, userID interface{}, currentID interface{}) *Service_ListUserSessions_Call {
+	return &Service_ListUserSessions_Call{Call: _e.mock.On("ListUserSessions", ctx, userID, currentID)}
+}
+
+func (_c *Service_ListUserSessions_Call) Run(run func(ctx context.Context, userID uuid.UUID, currentID uuid.UUID)) *Service_ListUserSessions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Service_ListUserSessions_Call) Return(_a0 []*response.UserSession, _a1 error) *Service_ListUserSessions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_ListUserSessions_Call) RunAndReturn(run func(context.Context, uuid.UUID, uuid.UUID) ([]*response.UserSession, error)) *Service_ListUserSessions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUserWithdrawals provides a mock function with given fields: ctx, id
+func (_m *Service) ListUserWithdrawals(ctx context.Context, id uuid.UUID) ([]*response.UserWithdrawal, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserWithdrawals")
+	}
+
+	var r0 []*response.UserWithdrawal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*response.UserWithdrawal, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*response.UserWithdrawal); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*response.UserWithdrawal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_ListUserWithdrawals_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserWithdrawals'
+type Service_ListUserWithdrawals_Call struct {
+	*mock.Call
+}
+
+// ListUserWithdrawals is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Service_Expecter) ListUserWithdrawals(ctx interface{}, id interface{}) *Service_ListUserWithdrawals_Call {
+	return &Service_ListUserWithdrawals_Call{Call: _e.mock.On("ListUserWithdrawals", ctx, id)}
+}
+
+func (_c *Service_ListUserWithdrawals_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Service_ListUserWithdrawals_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Service_ListUserWithdrawals_Call) Return(_a0 []*response.UserWithdrawal, _a1 error) *Service_ListUserWithdrawals_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_ListUserWithdrawals_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*response.UserWithdrawal, error)) *Service_ListUserWithdrawals_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Login provides a mock function with given fields: ctx, dto
+func (_m *Service) Login(ctx context.Context, dto *request.Login) (*response.Tokens, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Login")
+	}
+
+	var r0 *response.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *request.Login) (*response.Tokens, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *request.Login) *response.Tokens); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *request.Login) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_Login_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Login'
+type Service_Login_Call struct {
+	*mock.Call
+}
+
+// Login is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *request.Login
+func (_e *Service_Expecter) Login(ctx interface{}, dto interface{}) *Service_Login_Call {
+	return &Service_Login_Call{Call: _e.mock.On("Login", ctx, dto)}
+}
+
+func (_c *Service_Login_Call) Run(run func(ctx context.Context, dto *request.Login)) *Service_Login_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*request.Login))
+	})
+	return _c
+}
+
+func (_c *Service_Login_Call) Return(_a0 *response.Tokens, _a1 error) *Service_Login_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_Login_Call) RunAndReturn(run func(context.Context, *request.Login) (*response.Tokens, error)) *Service_Login_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Logout provides a mock function with given fields: ctx, userID, sessionID
+func (_m *Service) Logout(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Service_Logout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Logout'
+type Service_Logout_Call struct {
+	*mock.Call
+}
+
+// Logout is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - sessionID uuid.UUID
+func (_e *Service_Expecter) Logout(ctx interface{}, userID interface{}, sessionID interface{}) *Service_Logout_Call {
+	return &Service_Logout_Call{Call: _e.mock.On("Logout", ctx, userID, sessionID)}
+}
+
+func (_c *Service_Logout_Call) Run(run func(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID)) *Service_Logout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Service_Logout_Call) Return(_a0 error) *Service_Logout_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Service_Logout_Call) RunAndReturn(run func(context.Context, uuid.UUID, uuid.UUID) error) *Service_Logout_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RefreshToken provides a mock function with given fields: ctx, dto
+func (_m *Service) RefreshToken(ctx context.Context, dto *request.RefreshToken) (*response.Tokens, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshToken")
+	}
+
+	var r0 *response.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *request.RefreshToken) (*response.Tokens, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *request.RefreshToken) *response.Tokens); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *request.RefreshToken) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_RefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RefreshToken'
+type Service_RefreshToken_Call struct {
+	*mock.Call
+}
+
+// RefreshToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *request.RefreshToken
+func (_e *Service_Expecter) RefreshToken(ctx interface{}, dto interface{}) *Service_RefreshToken_Call {
+	return &Service_RefreshToken_Call{Call: _e.mock.On("RefreshToken", ctx, dto)}
+}
+
+func (_c *Service_RefreshToken_Call) Run(run func(ctx context.Context, dto *request.RefreshToken)) *Service_RefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*request.RefreshToken))
+	})
+	return _c
+}
+
+func (_c *Service_RefreshToken_Call) Return(_a0 *response.Tokens, _a1 error) *Service_RefreshToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_RefreshToken_Call) RunAndReturn(run func(context.Context, *request.RefreshToken) (*response.Tokens, error)) *Service_RefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RegisterUser provides a mock function with given fields: ctx, dto
+func (_m *Service) RegisterUser(ctx context.Context, dto *request.RegisterUser) (*response.Tokens, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterUser")
+	}
+
+	var r0 *response.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *request.RegisterUser) (*response.Tokens, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *request.RegisterUser) *response.Tokens); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *request.RegisterUser) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_RegisterUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterUser'
+type Service_RegisterUser_Call struct {
+	*mock.Call
+}
+
+// RegisterUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *request.RegisterUser
+func (_e *Service_Expecter) RegisterUser(ctx interface{}, dto interface{}) *Service_RegisterUser_Call {
+	return &Service_RegisterUser_Call{Call: _e.mock.On("RegisterUser", ctx, dto)}
+}
+
+func (_c *Service_RegisterUser_Call) Run(run func(ctx context.Context, dto *request.RegisterUser)) *Service_RegisterUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*request.RegisterUser))
+	})
+	return _c
+}
+
+func (_c *Service_RegisterUser_Call) Return(_a0 *response.Tokens, _a1 error) *Service_RegisterUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_RegisterUser_Call) RunAndReturn(run func(context.Context, *request.RegisterUser) (*response.Tokens, error)) *Service_RegisterUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResizeWorkerPool provides a mock function with given fields: ctx, dto
+func (_m *Service) ResizeWorkerPool(ctx context.Context, dto *request.ResizeWorkerPool) (*response.WorkerPoolStats, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResizeWorkerPool")
+	}
+
+	var r0 *response.WorkerPoolStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *request.ResizeWorkerPool) (*response.WorkerPoolStats, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *request.ResizeWorkerPool) *response.WorkerPoolStats); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.WorkerPoolStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *request.ResizeWorkerPool) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_ResizeWorkerPool_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResizeWorkerPool'
+type Service_ResizeWorkerPool_Call struct {
+	*mock.Call
+}
+
+// ResizeWorkerPool is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *request.ResizeWorkerPool
+func (_e *Service_Expecter) ResizeWorkerPool(ctx interface{}, dto interface{}) *Service_ResizeWorkerPool_Call {
+	return &Service_ResizeWorkerPool_Call{Call: _e.mock.On("ResizeWorkerPool", ctx, dto)}
+}
+
+func (_c *Service_ResizeWorkerPool_Call) Run(run func(ctx context.Context, dto *request.ResizeWorkerPool)) *Service_ResizeWorkerPool_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*request.ResizeWorkerPool))
+	})
+	return _c
+}
+
+func (_c *Service_ResizeWorkerPool_Call) Return(_a0 *response.WorkerPoolStats, _a1 error) *Service_ResizeWorkerPool_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_ResizeWorkerPool_Call) RunAndReturn(run func(context.Context, *request.ResizeWorkerPool) (*response.WorkerPoolStats, error)) *Service_ResizeWorkerPool_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetryAllOrderDeadLetters provides a mock function with given fields: ctx
+func (_m *Service) RetryAllOrderDeadLetters(ctx context.Context) (*response.RetriedOrders, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryAllOrderDeadLetters")
+	}
+
+	var r0 *response.RetriedOrders
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*response.RetriedOrders, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *response.RetriedOrders); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.RetriedOrders)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Service_RetryAllOrderDeadLetters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetryAllOrderDeadLetters'
+type Service_RetryAllOrderDeadLetters_Call struct {
+	*mock.Call
+}
+
+// RetryAllOrderDeadLetters is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Service_Expecter) RetryAllOrderDeadLetters(ctx interface{}) *Service_RetryAllOrderDeadLetters_Call {
+	return &Service_RetryAllOrderDeadLetters_Call{Call: _e.mock.On("RetryAllOrderDeadLetters", ctx)}
+}
+
+func (_c *Service_RetryAllOrderDeadLetters_Call) Run(run func(ctx context.Context)) *Service_RetryAllOrderDeadLetters_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Service_RetryAllOrderDeadLetters_Call) Return(_a0 *response.RetriedOrders, _a1 error) *Service_RetryAllOrderDeadLetters_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Service_RetryAllOrderDeadLetters_Call) RunAndReturn(run func(context.Context) (*response.RetriedOrders, error)) *Service_RetryAllOrderDeadLetters_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetryOrderDeadLetter provides a mock function with given fields: ctx, number
+func (_m *Service) RetryOrderDeadLetter(ctx context.Context, number string) error {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryOrderDeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, number)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Service_RetryOrderDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetryOrderDeadLetter'
+type Service_RetryOrderDeadLetter_Call struct {
+	*mock.Call
+}
+
+// RetryOrderDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - number string
+func (_e *Service_Expecter) RetryOrderDeadLetter(ctx interface{}, number interface{}) *Service_RetryOrderDeadLetter_Call {
+	return &Service_RetryOrderDeadLetter_Call{Call: _e.mock.On("RetryOrderDeadLetter", ctx, number)}
+}
+
+func (_c *Service_RetryOrderDeadLetter_Call) Run(run func(ctx context.Context, number string)) *Service_RetryOrderDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Service_RetryOrderDeadLetter_Call) Return(_a0 error) *Service_RetryOrderDeadLetter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Service_RetryOrderDeadLetter_Call) RunAndReturn(run func(context.Context, string) error) *Service_RetryOrderDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeUserSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *Service) RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Service_RevokeUserSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeUserSession'
+type Service_RevokeUserSession_Call struct {
+	*mock.Call
+}
+
+// RevokeUserSession is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - sessionID uuid.UUID
+func (_e *Service_Expecter) RevokeUserSession(ctx interface{}, userID interface{}, sessionID interface{}) *Service_RevokeUserSession_Call {
+	return &Service_RevokeUserSession_Call{Call: _e.mock.On("RevokeUserSession", ctx, userID, sessionID)}
+}
+
+func (_c *Service_RevokeUserSession_Call) Run(run func(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID)) *Service_RevokeUserSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Service_RevokeUserSession_Call) Return(_a0 error) *Service_RevokeUserSession_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Service_RevokeUserSession_Call) RunAndReturn(run func(context.Context, uuid.UUID, uuid.UUID) error) *Service_RevokeUserSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeUserSessions provides a mock function with given fields: ctx, userID
+func (_m *Service) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Service_RevokeUserSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeUserSessions'
+type Service_RevokeUserSessions_Call struct {
+	*mock.Call
+}
+
+// RevokeUserSessions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *Service_Expecter) RevokeUserSessions(ctx interface{}, userID interface{}) *Service_RevokeUserSessions_Call {
+	return &Service_RevokeUserSessions_Call{Call: _e.mock.On("RevokeUserSessions", ctx, userID)}
+}
+
+func (_c *Service_RevokeUserSessions_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Service_RevokeUserSessions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Service_RevokeUserSessions_Call) Return(_a0 error) *Service_RevokeUserSessions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Service_RevokeUserSessions_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *Service_RevokeUserSessions_Call {
 	_c.Call.Return(run)
 	return _c
 }
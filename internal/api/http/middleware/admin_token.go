@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/dtroode/gophermart/internal/logger"
+)
+
+// AdminToken lets through requests with "Bearer <token>" in Authorization header.
+type AdminToken struct {
+	token  []byte
+	logger *logger.Logger
+}
+
+func NewAdminToken(token string, l *logger.Logger) *AdminToken {
+	return &AdminToken{
+		token:  []byte(token),
+		logger: l,
+	}
+}
+
+func (m *AdminToken) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		token := []byte(strings.TrimPrefix(authHeader, "Bearer "))
+		if len(m.token) == 0 || subtle.ConstantTimeCompare(token, m.token) != 1 {
+			m.logger.Warn("wrong admin token", "uri", r.RequestURI)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dtroode/gophermart/internal/api/http/middleware"
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminToken_Handle(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := map[string]struct {
+		token              string
+		authHeader         string
+		expectedStatusCode int
+	}{
+		"no header": {
+			token:              "admin-token",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"no bearer prefix": {
+			token:              "admin-token",
+			authHeader:         "admin-token",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"wrong token": {
+			token:              "admin-token",
+			authHeader:         "Bearer another-token",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"empty token configured": {
+			authHeader:         "Bearer ",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"success": {
+			token:              "admin-token",
+			authHeader:         "Bearer admin-token",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.authHeader != "" {
+				r.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+
+			middleware.NewAdminToken(tt.token, dummyLogger).Handle(dummyHandler).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
@@ -1,26 +1,37 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/dtroode/gophermart/internal/application"
 	"github.com/dtroode/gophermart/internal/auth"
 	"github.com/dtroode/gophermart/internal/logger"
 	"github.com/google/uuid"
 )
 
 type TokenManager interface {
-	GetUserID(tokenString string) (uuid.UUID, error)
+	ParseToken(tokenString string) (*auth.Claims, error)
 }
 
+// SessionChecker returns application.ErrUnauthorized if the session the token is issued for is revoked.
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID, sessionID uuid.UUID) error
+}
+
+// Authenticate accepts access tokens with valid signature issued for sessions that are not revoked.
 type Authenticate struct {
 	tokenManager TokenManager
+	sessions     SessionChecker
 	logger       *logger.Logger
 }
 
-func NewAuthenticate(tokenManager TokenManager, l *logger.Logger) *Authenticate {
+func NewAuthenticate(tokenManager TokenManager, sessions SessionChecker, l *logger.Logger) *Authenticate {
 	return &Authenticate{
 		tokenManager: tokenManager,
+		sessions:     sessions,
 		logger:       l,
 	}
 }
@@ -42,20 +53,33 @@ func (m *Authenticate) Handle(next http.Handler) http.Handler {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		userID, err := m.tokenManager.GetUserID(tokenString)
+		claims, err := m.tokenManager.ParseToken(tokenString)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 
 			return
 		}
 
-		if userID == uuid.Nil {
+		if claims.UserID == uuid.Nil {
 			w.WriteHeader(http.StatusUnauthorized)
 
 			return
 		}
 
-		ctx := auth.SetUserIDToContext(r.Context(), userID)
+		if err := m.sessions.CheckSession(r.Context(), claims.UserID, claims.SessionID); err != nil {
+			if errors.Is(err, application.ErrUnauthorized) {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			m.logger.Error("failed to check session", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		ctx := auth.SetUserIDToContext(r.Context(), claims.UserID)
+		ctx = auth.SetSessionIDToContext(ctx, claims.SessionID)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
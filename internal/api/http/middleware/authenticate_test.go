@@ -10,10 +10,12 @@ import (
 
 	"github.com/dtroode/gophermart/internal/api/http/middleware"
 	"github.com/dtroode/gophermart/internal/api/http/middleware/mocks"
+	"github.com/dtroode/gophermart/internal/application"
 	"github.com/dtroode/gophermart/internal/auth"
 	"github.com/dtroode/gophermart/internal/logger"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,18 +25,23 @@ func TestAuthenticate_Handle(t *testing.T) {
 	}
 
 	userID := uuid.New()
+	sessionID := uuid.New()
 
 	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctxUserID, ok := auth.GetUserIDFromContext(r.Context())
 		require.True(t, ok)
+		ctxSessionID, ok := auth.GetSessionIDFromContext(r.Context())
+		require.True(t, ok)
 
 		assert.Equal(t, userID, ctxUserID)
+		assert.Equal(t, sessionID, ctxSessionID)
 		w.WriteHeader(http.StatusOK)
 	})
 
 	tests := map[string]struct {
 		req                *http.Request
 		tokenManagerMock   *mocks.TokenManager
+		sessionCheckerMock *mocks.SessionChecker
 		expectedStatusCode int
 	}{
 		"no auth header": {
@@ -57,8 +64,8 @@ func TestAuthenticate_Handle(t *testing.T) {
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				tokenManager := mocks.NewTokenManager(t)
-				tokenManager.On("GetUserID", "some.jwt.token").Once().
-					Return(uuid.Nil, errors.New("token manager error"))
+				tokenManager.On("ParseToken", "some.jwt.token").Once().
+					Return(nil, errors.New("token manager error"))
 				return tokenManager
 			}(),
 			expectedStatusCode: http.StatusUnauthorized,
@@ -71,12 +78,52 @@ func TestAuthenticate_Handle(t *testing.T) {
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				tokenManager := mocks.NewTokenManager(t)
-				tokenManager.On("GetUserID", "some.jwt.token").Once().
-					Return(uuid.Nil, nil)
+				tokenManager.On("ParseToken", "some.jwt.token").Once().
+					Return(&auth.Claims{}, nil)
 				return tokenManager
 			}(),
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		"session is revoked": {
+			req: func() *http.Request {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.Header.Set("Authorization", "Bearer some.jwt.token")
+				return r
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				tokenManager := mocks.NewTokenManager(t)
+				tokenManager.On("ParseToken", "some.jwt.token").Once().
+					Return(&auth.Claims{UserID: userID, SessionID: sessionID}, nil)
+				return tokenManager
+			}(),
+			sessionCheckerMock: func() *mocks.SessionChecker {
+				sessionChecker := mocks.NewSessionChecker(t)
+				sessionChecker.On("CheckSession", mock.Anything, userID, sessionID).Once().
+					Return(application.ErrUnauthorized)
+				return sessionChecker
+			}(),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"failed to check session": {
+			req: func() *http.Request {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.Header.Set("Authorization", "Bearer some.jwt.token")
+				return r
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				tokenManager := mocks.NewTokenManager(t)
+				tokenManager.On("ParseToken", "some.jwt.token").Once().
+					Return(&auth.Claims{UserID: userID, SessionID: sessionID}, nil)
+				return tokenManager
+			}(),
+			sessionCheckerMock: func() *mocks.SessionChecker {
+				sessionChecker := mocks.NewSessionChecker(t)
+				sessionChecker.On("CheckSession", mock.Anything, userID, sessionID).Once().
+					Return(errors.New("storage error"))
+				return sessionChecker
+			}(),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
 		"success": {
 			req: func() *http.Request {
 				r := httptest.NewRequest("GET", "/", nil)
@@ -85,10 +132,15 @@ func TestAuthenticate_Handle(t *testing.T) {
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				tokenManager := mocks.NewTokenManager(t)
-				tokenManager.On("GetUserID", "some.jwt.token").Once().
-					Return(userID, nil)
+				tokenManager.On("ParseToken", "some.jwt.token").Once().
+					Return(&auth.Claims{UserID: userID, SessionID: sessionID}, nil)
 				return tokenManager
 			}(),
+			sessionCheckerMock: func() *mocks.SessionChecker {
+				sessionChecker := mocks.NewSessionChecker(t)
+				sessionChecker.On("CheckSession", mock.Anything, userID, sessionID).Once().Return(nil)
+				return sessionChecker
+			}(),
 			expectedStatusCode: http.StatusOK,
 		},
 	}
@@ -99,7 +151,7 @@ func TestAuthenticate_Handle(t *testing.T) {
 
 			w := httptest.NewRecorder()
 
-			a := middleware.NewAuthenticate(tt.tokenManagerMock, dummyLogger)
+			a := middleware.NewAuthenticate(tt.tokenManagerMock, tt.sessionCheckerMock, dummyLogger)
 
 			a.Handle(dummyHandler).ServeHTTP(w, tt.req)
 
@@ -0,0 +1,86 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// SessionChecker is an autogenerated mock type for the SessionChecker type
+type SessionChecker struct {
+	mock.Mock
+}
+
+type SessionChecker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *SessionChecker) EXPECT() *SessionChecker_Expecter {
+	return &SessionChecker_Expecter{mock: &_m.Mock}
+}
+
+// CheckSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *SessionChecker) CheckSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SessionChecker_CheckSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckSession'
+type SessionChecker_CheckSession_Call struct {
+	*mock.Call
+}
+
+// CheckSession is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - sessionID uuid.UUID
+func (_e *SessionChecker_Expecter) CheckSession(ctx interface{}, userID interface{}, sessionID interface{}) *SessionChecker_CheckSession_Call {
+	return &SessionChecker_CheckSession_Call{Call: _e.mock.On("CheckSession", ctx, userID, sessionID)}
+}
+
+func (_c *SessionChecker_CheckSession_Call) Run(run func(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID)) *SessionChecker_CheckSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *SessionChecker_CheckSession_Call) Return(_a0 error) *SessionChecker_CheckSession_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SessionChecker_CheckSession_Call) RunAndReturn(run func(context.Context, uuid.UUID, uuid.UUID) error) *SessionChecker_CheckSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewSessionChecker creates a new instance of SessionChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSessionChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SessionChecker {
+	mock := &SessionChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
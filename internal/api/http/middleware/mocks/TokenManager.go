@@ -3,7 +3,8 @@
 package mocks
 
 import (
-	uuid "github.com/google/uuid"
+	auth "github.com/dtroode/gophermart/internal/auth"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -20,24 +21,24 @@ func (_m *TokenManager) EXPECT() *TokenManager_Expecter {
 	return &TokenManager_Expecter{mock: &_m.Mock}
 }
 
-// GetUserID provides a mock function with given fields: tokenString
-func (_m *TokenManager) GetUserID(tokenString string) (uuid.UUID, error) {
+// ParseToken provides a mock function with given fields: tokenString
+func (_m *TokenManager) ParseToken(tokenString string) (*auth.Claims, error) {
 	ret := _m.Called(tokenString)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserID")
+		panic("no return value specified for ParseToken")
 	}
 
-	var r0 uuid.UUID
+	var r0 *auth.Claims
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (uuid.UUID, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (*auth.Claims, error)); ok {
 		return rf(tokenString)
 	}
-	if rf, ok := ret.Get(0).(func(string) uuid.UUID); ok {
+	if rf, ok := ret.Get(0).(func(string) *auth.Claims); ok {
 		r0 = rf(tokenString)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(uuid.UUID)
+			r0 = ret.Get(0).(*auth.Claims)
 		}
 	}
 
@@ -50,30 +51,30 @@ func (_m *TokenManager) GetUserID(tokenString string) (uuid.UUID, error) {
 	return r0, r1
 }
 
-// TokenManager_GetUserID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserID'
-type TokenManager_GetUserID_Call struct {
+// TokenManager_ParseToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ParseToken'
+type TokenManager_ParseToken_Call struct {
 	*mock.Call
 }
 
-// GetUserID is a helper method to define mock.On call
+// ParseToken is a helper method to define mock.On call
 //   - tokenString string
-func (_e *TokenManager_Expecter) GetUserID(tokenString interface{}) *TokenManager_GetUserID_Call {
-	return &TokenManager_GetUserID_Call{Call: _e.mock.On("GetUserID", tokenString)}
+func (_e *TokenManager_Expecter) ParseToken(tokenString interface{}) *TokenManager_ParseToken_Call {
+	return &TokenManager_ParseToken_Call{Call: _e.mock.On("ParseToken", tokenString)}
 }
 
-func (_c *TokenManager_GetUserID_Call) Run(run func(tokenString string)) *TokenManager_GetUserID_Call {
+func (_c *TokenManager_ParseToken_Call) Run(run func(tokenString string)) *TokenManager_ParseToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *TokenManager_GetUserID_Call) Return(_a0 uuid.UUID, _a1 error) *TokenManager_GetUserID_Call {
+func (_c *TokenManager_ParseToken_Call) Return(_a0 *auth.Claims, _a1 error) *TokenManager_ParseToken_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *TokenManager_GetUserID_Call) RunAndReturn(run func(string) (uuid.UUID, error)) *TokenManager_GetUserID_Call {
+func (_c *TokenManager_ParseToken_Call) RunAndReturn(run func(string) (*auth.Claims, error)) *TokenManager_ParseToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
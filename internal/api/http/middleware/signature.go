@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+)
+
+// SignatureHeader carries hex encoded HMAC-SHA256 of the timestamp and the request body
+// joined with ".", optionally prefixed with "sha256=".
+const SignatureHeader = "X-Signature"
+
+// SignatureTimestampHeader carries unix time in seconds the request was signed at.
+const SignatureTimestampHeader = "X-Signature-Timestamp"
+
+// signatureTolerance limits how far the signing time may be from now, so captured
+// requests can't be replayed later.
+const signatureTolerance = 5 * time.Minute
+
+// maxSignedBodySize limits the body read into memory to check its signature.
+const maxSignedBodySize = 1 << 20
+
+type VerifySignature struct {
+	secret []byte
+	logger *logger.Logger
+}
+
+func NewVerifySignature(secret string, l *logger.Logger) *VerifySignature {
+	return &VerifySignature{
+		secret: []byte(secret),
+		logger: l,
+	}
+}
+
+func (m *VerifySignature) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(SignatureHeader), "sha256="))
+		if err != nil || len(signature) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		timestamp := r.Header.Get(SignatureTimestampHeader)
+		signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+		if age := time.Since(time.Unix(signedAt, 0)); age > signatureTolerance || age < -signatureTolerance {
+			m.logger.Warn("request signature is stale", "uri", r.RequestURI, "timestamp", timestamp)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		mac := hmac.New(sha256.New, m.secret)
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			m.logger.Warn("request signature mismatch", "uri", r.RequestURI)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,121 @@
+package middleware_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/api/http/middleware"
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature_Handle(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	secret := "webhook-secret"
+	body := `{"order": "1234", "status": "PROCESSED", "accrual": 50}`
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+
+	sign := func(secret, timestamp, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, body, string(b))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := map[string]struct {
+		signature          string
+		timestamp          string
+		expectedStatusCode int
+	}{
+		"no signature": {
+			timestamp:          now,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"signature is not hex": {
+			signature:          "not-hex",
+			timestamp:          now,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"signed with another secret": {
+			signature:          sign("another-secret", now, body),
+			timestamp:          now,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"no timestamp": {
+			signature:          sign(secret, "", body),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"timestamp is not a number": {
+			signature:          sign(secret, "yesterday", body),
+			timestamp:          "yesterday",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"timestamp is not signed": {
+			signature:          sign(secret, stale, body),
+			timestamp:          now,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"stale request is replayed": {
+			signature:          sign(secret, stale, body),
+			timestamp:          stale,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"timestamp is in the future": {
+			signature:          sign(secret, future, body),
+			timestamp:          future,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"success": {
+			signature:          sign(secret, now, body),
+			timestamp:          now,
+			expectedStatusCode: http.StatusOK,
+		},
+		"success with prefix": {
+			signature:          "sha256=" + sign(secret, now, body),
+			timestamp:          now,
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+			if tt.signature != "" {
+				r.Header.Set(middleware.SignatureHeader, tt.signature)
+			}
+			if tt.timestamp != "" {
+				r.Header.Set(middleware.SignatureTimestampHeader, tt.timestamp)
+			}
+			w := httptest.NewRecorder()
+
+			middleware.NewVerifySignature(secret, dummyLogger).Handle(dummyHandler).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+		})
+	}
+}
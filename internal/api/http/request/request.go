@@ -21,6 +21,13 @@ type Login struct {
 	Password string `json:"password"`
 }
 
+// RefreshToken represents token refresh request
+type RefreshToken struct {
+	// Refresh token issued at login or previous refresh
+	// Required: true
+	RefreshToken string `json:"refresh_token"`
+}
+
 // WithdrawBonuses represents bonus withdrawal request
 type WithdrawBonuses struct {
 	// Order number for withdrawal
@@ -30,3 +37,17 @@ type WithdrawBonuses struct {
 	// Required: true
 	Sum float32 `json:"sum"`
 }
+
+// AdjustOrderDiscrepancy represents order correction request
+type AdjustOrderDiscrepancy struct {
+	// Why the order is corrected, saved for audit
+	// Required: true
+	Reason string `json:"reason"`
+}
+
+// ResizeWorkerPool represents request changing the number of workers
+type ResizeWorkerPool struct {
+	// Number of workers
+	// Required: true
+	Size int `json:"size"`
+}
@@ -1,6 +1,8 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/dtroode/gophermart/internal/api/http/handler"
 	"github.com/dtroode/gophermart/internal/api/http/middleware"
 	"github.com/dtroode/gophermart/internal/application/service"
@@ -20,19 +22,78 @@ func NewRouter() *Router {
 	}
 }
 
-func (r *Router) RegisterRoutes(s *service.Service, token middleware.TokenManager, l *logger.Logger) {
+type options struct {
+	webhookSecret  string
+	accrualBreaker http.Handler
+	adminToken     string
+	jwks           http.Handler
+	realIP         bool
+}
+
+type Option func(*options)
+
+// WithAccrualEvents mounts accrual events endpoint checking request signature with secret.
+func WithAccrualEvents(secret string) Option {
+	return func(o *options) {
+		o.webhookSecret = secret
+	}
+}
+
+// WithAccrualBreaker mounts admin endpoint reporting accrual circuit breaker state.
+func WithAccrualBreaker(h http.Handler) Option {
+	return func(o *options) {
+		o.accrualBreaker = h
+	}
+}
+
+// WithAdmin mounts admin endpoints accessible with token.
+func WithAdmin(token string) Option {
+	return func(o *options) {
+		o.adminToken = token
+	}
+}
+
+// WithJWKS mounts endpoint publishing keys access tokens can be verified with.
+func WithJWKS(h http.Handler) Option {
+	return func(o *options) {
+		o.jwks = h
+	}
+}
+
+// WithRealIP takes client address from X-Forwarded-For and X-Real-IP headers.
+// Clients can set them to anything, so it is only for running behind a proxy that overwrites them.
+func WithRealIP() Option {
+	return func(o *options) {
+		o.realIP = true
+	}
+}
+
+func (r *Router) RegisterRoutes(s *service.Service, token middleware.TokenManager, l *logger.Logger, opts ...Option) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	loggerMiddleware := middleware.NewRequestLog(l).Handle
-	authenticate := middleware.NewAuthenticate(token, l).Handle
+	authenticate := middleware.NewAuthenticate(token, s, l).Handle
 	degzipper := middleware.Decompress
 	compressor := chiMiddleware.Compress(5)
 
 	h := handler.New(s, l)
 
+	if o.realIP {
+		r.Use(chiMiddleware.RealIP)
+	}
+
 	// Swagger UI endpoint
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"), // The url pointing to API definition
 	))
 
+	if o.jwks != nil {
+		r.With(loggerMiddleware).Method(http.MethodGet, "/.well-known/jwks.json", o.jwks)
+	}
+
 	r.Route("/api/user", func(r chi.Router) {
 		r.Use(loggerMiddleware)
 		r.Use(degzipper)
@@ -40,14 +101,50 @@ func (r *Router) RegisterRoutes(s *service.Service, token middleware.TokenManage
 
 		r.Post("/register", h.RegisterUser)
 		r.Post("/login", h.Login)
+		r.Post("/token/refresh", h.RefreshToken)
 
 		r.Group(func(r chi.Router) {
 			r.Use(authenticate)
+			r.Post("/logout", h.Logout)
+			r.Get("/sessions", h.ListUserSessions)
+			r.Delete("/sessions", h.RevokeUserSessions)
+			r.Delete("/sessions/{id}", h.RevokeUserSession)
 			r.Post("/orders", h.UploadOrder)
 			r.Get("/orders", h.ListUserOrders)
+			r.Get("/orders/{number}", h.GetUserOrder)
 			r.Get("/balance", h.GetUserBalance)
 			r.Post("/balance/withdraw", h.WithdrawUserBonuses)
 			r.Get("/withdrawals", h.ListUserWithdrawals)
 		})
 	})
+
+	if o.webhookSecret != "" {
+		verifySignature := middleware.NewVerifySignature(o.webhookSecret, l).Handle
+
+		r.With(loggerMiddleware, verifySignature).Post("/api/internal/accrual/events", h.AccrualEvent)
+	}
+
+	if o.adminToken != "" {
+		adminToken := middleware.NewAdminToken(o.adminToken, l).Handle
+
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Use(loggerMiddleware)
+			r.Use(adminToken)
+
+			r.Get("/orders/dead-letters", h.ListOrderDeadLetters)
+			r.Post("/orders/dead-letters/retry", h.RetryAllOrderDeadLetters)
+			r.Post("/orders/dead-letters/{number}/retry", h.RetryOrderDeadLetter)
+			r.Post("/orders/dead-letters/{number}/invalidate", h.InvalidateOrderDeadLetter)
+
+			r.Get("/orders/discrepancies", h.ListOrderDiscrepancies)
+			r.Post("/orders/discrepancies/{id}/adjust", h.AdjustOrderDiscrepancy)
+
+			r.Get("/workerpool", h.GetWorkerPoolStats)
+			r.Put("/workerpool", h.ResizeWorkerPool)
+
+			if o.accrualBreaker != nil {
+				r.Method(http.MethodGet, "/accrual/breaker", o.accrualBreaker)
+			}
+		})
+	}
 }
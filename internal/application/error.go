@@ -1,6 +1,11 @@
 package application
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var ErrNoData = errors.New("no data")
 var ErrNotFound = errors.New("not found")
@@ -9,7 +14,52 @@ var ErrConflict = errors.New("conflict")
 var ErrAlreadyExist = errors.New("already exist")
 var ErrUnprocessable = errors.New("unprocessable entity")
 var ErrNotEnoughBonuses = errors.New("not enough bonuses")
+var ErrIllegalTransition = errors.New("illegal order status transition")
+var ErrBusy = errors.New("service is busy")
+var ErrTooManyAttempts = errors.New("too many attempts")
+var ErrPolicyViolation = errors.New("credentials violate policy")
 
 var ErrAccrualOrderNotRegistered = errors.New("order is not registered")
 var ErrAccrualTooManyRequests = errors.New("too many requests")
 var ErrAccrualInternal = errors.New("internal service error")
+var ErrAccrualUnavailable = errors.New("accrual service is unavailable")
+var ErrAccrualUnknownProvider = errors.New("unknown accrual provider")
+var ErrAccrualBadResponse = errors.New("malformed accrual response")
+
+// ThrottledError is ErrTooManyAttempts telling when the next attempt is allowed.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, e.RetryAfter)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// PolicyError is ErrPolicyViolation naming every rule of credential policy the credentials break.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+// PolicyViolation tells which rule the field breaks, Message explains the rule to the user.
+type PolicyViolation struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Field+" "+v.Rule)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrPolicyViolation, strings.Join(rules, ", "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
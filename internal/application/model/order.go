@@ -17,6 +17,34 @@ const (
 	OrderStatusProcessed  OrderStatus = "PROCESSED"
 )
 
+// orderTransitions lists statuses an order may move to from each status.
+// Final statuses have no transitions.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusNew:        {OrderStatusProcessing, OrderStatusProcessed, OrderStatusInvalid},
+	OrderStatusProcessing: {OrderStatusProcessed, OrderStatusInvalid},
+}
+
+// CanTransitionTo reports whether an order may move from s to status
+func (s OrderStatus) CanTransitionTo(status OrderStatus) bool {
+	for _, to := range orderTransitions[s] {
+		if to == status {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviousStatuses returns statuses an order may move to s from
+func (s OrderStatus) PreviousStatuses() []OrderStatus {
+	var from []OrderStatus
+	for status := range orderTransitions {
+		if status.CanTransitionTo(s) {
+			from = append(from, status)
+		}
+	}
+	return from
+}
+
 // AccrualOrderStatus represents the status of an order in the accrual system
 type AccrualOrderStatus string
 
@@ -36,6 +64,22 @@ type Order struct {
 	Number    string
 	Accrual   int32
 	Status    OrderStatus
+
+	// Processing state of the order in the accrual system,
+	// Attempts counts failed checks since the last successful one
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     string
+	// DeadLettered is set when checks of the order are stopped until an administrator retries it
+	DeadLettered bool
+	// AccrualProvider is the accrual system the order was routed to,
+	// empty for the default one
+	AccrualProvider string
+}
+
+// IsFinal reports whether the order reached a status that is not checked anymore
+func (o *Order) IsFinal() bool {
+	return o.Status == OrderStatusInvalid || o.Status == OrderStatusProcessed
 }
 
 func NewOrder(
@@ -49,6 +93,90 @@ func NewOrder(
 	}
 }
 
+// OrderDeadLetter is an order that failed processing and waits for an administrator
+type OrderDeadLetter struct {
+	OrderID     uuid.UUID
+	OrderNumber string
+	Error       string
+	Attempts    int32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// OrderDiscrepancy is a finalized order that doesn't match accrual system anymore
+type OrderDiscrepancy struct {
+	ID          uuid.UUID
+	OrderID     uuid.UUID
+	OrderNumber string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// Order status and accrual at the moment of reconciliation
+	Status  OrderStatus
+	Accrual int32
+
+	// Order status and accrual reported by accrual system
+	ExpectedStatus  AccrualOrderStatus
+	ExpectedAccrual int32
+
+	// Correction applied to the order, AdjustedAt is zero until then
+	AdjustedAt time.Time
+	Adjustment int32
+	Reason     string
+}
+
+// IsAdjusted reports whether the order was already corrected
+func (d *OrderDiscrepancy) IsAdjusted() bool {
+	return !d.AdjustedAt.IsZero()
+}
+
+// CorrectedStatus returns the status the order gets when corrected.
+// Orders that are not final in accrual system can't be corrected.
+func (d *OrderDiscrepancy) CorrectedStatus() (OrderStatus, bool) {
+	switch d.ExpectedStatus {
+	case AccrualOrderStatusProcessed:
+		return OrderStatusProcessed, true
+	case AccrualOrderStatusInvalid:
+		return OrderStatusInvalid, true
+	default:
+		return "", false
+	}
+}
+
+// ReconciliationReport is the outcome of one reconciliation run
+type ReconciliationReport struct {
+	// Checked is the number of orders compared with accrual system,
+	// orders that failed to be compared are not counted
+	Checked       int
+	Discrepancies []*OrderDiscrepancy
+}
+
+// OrderEventType describes what happened to an order
+type OrderEventType string
+
+// List of possible order event types
+const (
+	OrderEventUploaded      OrderEventType = "UPLOADED"
+	OrderEventStatusChanged OrderEventType = "STATUS_CHANGED"
+	OrderEventChecked       OrderEventType = "CHECKED"
+	OrderEventFailed        OrderEventType = "FAILED"
+	OrderEventAdjusted      OrderEventType = "ADJUSTED"
+	OrderEventRetried       OrderEventType = "RETRIED"
+)
+
+// OrderEvent is an entry of order processing timeline
+type OrderEvent struct {
+	ID        uuid.UUID
+	OrderID   uuid.UUID
+	CreatedAt time.Time
+	Type      OrderEventType
+	Status    OrderStatus
+	Accrual   int32
+	Error     string
+	// PublicError is the message about the error safe to show the user
+	PublicError string
+}
+
 type AccrualOrder struct {
 	Number  string             `json:"order" binding:"required"`
 	Status  AccrualOrderStatus `json:"status" binding:"required"`
@@ -0,0 +1,69 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatus_CanTransitionTo(t *testing.T) {
+	tests := map[string]struct {
+		from     model.OrderStatus
+		to       model.OrderStatus
+		expected bool
+	}{
+		"new to processing":        {from: model.OrderStatusNew, to: model.OrderStatusProcessing, expected: true},
+		"new to processed":         {from: model.OrderStatusNew, to: model.OrderStatusProcessed, expected: true},
+		"new to invalid":           {from: model.OrderStatusNew, to: model.OrderStatusInvalid, expected: true},
+		"processing to processed":  {from: model.OrderStatusProcessing, to: model.OrderStatusProcessed, expected: true},
+		"processing to invalid":    {from: model.OrderStatusProcessing, to: model.OrderStatusInvalid, expected: true},
+		"processing to new":        {from: model.OrderStatusProcessing, to: model.OrderStatusNew},
+		"processing to processing": {from: model.OrderStatusProcessing, to: model.OrderStatusProcessing},
+		"processed to processed":   {from: model.OrderStatusProcessed, to: model.OrderStatusProcessed},
+		"processed to invalid":     {from: model.OrderStatusProcessed, to: model.OrderStatusInvalid},
+		"invalid to processed":     {from: model.OrderStatusInvalid, to: model.OrderStatusProcessed},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestOrderStatus_PreviousStatuses(t *testing.T) {
+	assert.ElementsMatch(t,
+		[]model.OrderStatus{model.OrderStatusNew, model.OrderStatusProcessing},
+		model.OrderStatusProcessed.PreviousStatuses(),
+	)
+	assert.ElementsMatch(t,
+		[]model.OrderStatus{model.OrderStatusNew},
+		model.OrderStatusProcessing.PreviousStatuses(),
+	)
+	assert.Empty(t, model.OrderStatusNew.PreviousStatuses())
+}
+
+func TestOrderDiscrepancy_CorrectedStatus(t *testing.T) {
+	tests := map[string]struct {
+		expectedStatus model.AccrualOrderStatus
+		status         model.OrderStatus
+		ok             bool
+	}{
+		"processed":  {expectedStatus: model.AccrualOrderStatusProcessed, status: model.OrderStatusProcessed, ok: true},
+		"invalid":    {expectedStatus: model.AccrualOrderStatusInvalid, status: model.OrderStatusInvalid, ok: true},
+		"processing": {expectedStatus: model.AccrualOrderStatusProcessing},
+		"registered": {expectedStatus: model.AccrualOrderStatusRegistered},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			d := &model.OrderDiscrepancy{ExpectedStatus: tt.expectedStatus}
+
+			status, ok := d.CorrectedStatus()
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a refresh token issued at login. Refreshing rotates the token:
+// the session is marked rotated and a new one of the same family is issued,
+// so a family stands for one signed in device.
+type Session struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	UserAgent string
+	// StartedAt is the time of login the family started with
+	StartedAt time.Time
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	// RotatedAt and RevokedAt are zero until the token is exchanged or revoked
+	RotatedAt time.Time
+	RevokedAt time.Time
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SigningKey is a key access tokens are signed with. It is published as soon as it is saved,
+// signs tokens from ActiveAt till RetireAt and verifies them till ExpiresAt,
+// when the last token it signed expires.
+type SigningKey struct {
+	ID        uuid.UUID
+	Algorithm string
+	// PrivateKey is PKCS #8 DER encoded and encrypted with AES-GCM,
+	// it is nonce followed by ciphertext, ID is additional data
+	PrivateKey []byte
+	CreatedAt  time.Time
+	ActiveAt   time.Time
+	RetireAt   time.Time
+	ExpiresAt  time.Time
+}
@@ -12,4 +12,7 @@ type User struct {
 	Password  string
 	CreatedAt time.Time
 	Balance   int32
+	// LoginKey is the login in the form logins are unique by, so logins differing in case are the same.
+	// It is empty for logins taken before that, which clash with another one.
+	LoginKey string
 }
@@ -3,13 +3,20 @@ package request
 import "github.com/google/uuid"
 
 type RegisterUser struct {
-	Login    string
-	Password string
+	Login     string
+	Password  string
+	UserAgent string
 }
 
 type Login struct {
-	Login    string
-	Password string
+	Login     string
+	Password  string
+	UserAgent string
+	ClientIP  string
+}
+
+type RefreshToken struct {
+	RefreshToken string
 }
 
 type UploadOrder struct {
@@ -22,3 +29,12 @@ type WithdrawBonuses struct {
 	OrderNumber string
 	Sum         float32
 }
+
+type AdjustOrderDiscrepancy struct {
+	ID     uuid.UUID
+	Reason string
+}
+
+type ResizeWorkerPool struct {
+	Size int
+}
@@ -1,6 +1,34 @@
 // Package response contains API response models
 package response
 
+// Tokens represents tokens of a session, access token is also sent in Authorization header
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// PolicyViolations represents rules of credential policy the credentials break
+type PolicyViolations struct {
+	Errors []*PolicyViolation `json:"errors"`
+}
+
+// PolicyViolation represents rule of credential policy the field breaks
+type PolicyViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// UserSession represents signed in device
+type UserSession struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	StartedAt  string `json:"started_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
 // UserBalance represents user's current balance information
 type UserBalance struct {
 	Current   float32 `json:"current"`
@@ -15,9 +43,70 @@ type UserOrder struct {
 	UploadedAt string  `json:"uploaded_at"`
 }
 
+// UserOrderDetails represents order information with its processing timeline
+type UserOrderDetails struct {
+	Number     string        `json:"number"`
+	Status     string        `json:"status"`
+	Accrual    float32       `json:"accrual,omitempty"`
+	UploadedAt string        `json:"uploaded_at"`
+	Timeline   []*OrderEvent `json:"timeline"`
+}
+
+// OrderEvent represents an entry of order processing timeline
+type OrderEvent struct {
+	Type      string  `json:"type"`
+	Status    string  `json:"status,omitempty"`
+	Accrual   float32 `json:"accrual,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
 // UserWithdrawal represents withdrawal transaction information
 type UserWithdrawal struct {
 	OrderNumber string  `json:"order"`
 	Sum         float32 `json:"sum"`
 	ProcessedAt string  `json:"processed_at"`
 }
+
+// OrderDeadLetter represents order that failed processing
+type OrderDeadLetter struct {
+	Number    string `json:"number"`
+	Error     string `json:"error"`
+	Attempts  int32  `json:"attempts"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// OrderDiscrepancy represents finalized order that doesn't match accrual system
+type OrderDiscrepancy struct {
+	ID              string  `json:"id"`
+	Number          string  `json:"number"`
+	Status          string  `json:"status"`
+	Accrual         float32 `json:"accrual"`
+	ExpectedStatus  string  `json:"expected_status"`
+	ExpectedAccrual float32 `json:"expected_accrual"`
+	Adjustment      float32 `json:"adjustment,omitempty"`
+	Reason          string  `json:"reason,omitempty"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+	AdjustedAt      string  `json:"adjusted_at,omitempty"`
+}
+
+// WorkerPoolStats represents load of the pool checking orders
+type WorkerPoolStats struct {
+	Workers    int    `json:"workers"`
+	Active     int64  `json:"active"`
+	Queued     int    `json:"queued"`
+	QueueSize  int    `json:"queue_size"`
+	Scheduled  int    `json:"scheduled"`
+	Completed  int64  `json:"completed"`
+	Failed     int64  `json:"failed"`
+	Rejected   int64  `json:"rejected"`
+	Joined     int64  `json:"joined"`
+	AvgRunTime string `json:"avg_run_time"`
+}
+
+// RetriedOrders represents number of orders scheduled for a check
+type RetriedOrders struct {
+	Retried int64 `json:"retried"`
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/request"
+	"github.com/dtroode/gophermart/internal/application/response"
+	"github.com/dtroode/gophermart/internal/workerpool"
+)
+
+func (s *Service) ListOrderDeadLetters(ctx context.Context) ([]*response.OrderDeadLetter, error) {
+	deadLetters, err := s.storage.ListOrderDeadLetters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order dead letters: %w", err)
+	}
+
+	if len(deadLetters) == 0 {
+		return nil, application.ErrNoData
+	}
+
+	resp := make([]*response.OrderDeadLetter, len(deadLetters))
+
+	for i, deadLetter := range deadLetters {
+		resp[i] = &response.OrderDeadLetter{
+			Number:    deadLetter.OrderNumber,
+			Error:     deadLetter.Error,
+			Attempts:  deadLetter.Attempts,
+			CreatedAt: deadLetter.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: deadLetter.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return resp, nil
+}
+
+// RetryOrderDeadLetter schedules the dead lettered order for a check by the next poll.
+func (s *Service) RetryOrderDeadLetter(ctx context.Context, number string) error {
+	if _, err := s.storage.RetryOrderDeadLetter(ctx, number); err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return application.ErrNotFound
+		}
+		return fmt.Errorf("failed to retry order dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// RetryAllOrderDeadLetters schedules all dead lettered orders for a check and returns their count.
+func (s *Service) RetryAllOrderDeadLetters(ctx context.Context) (*response.RetriedOrders, error) {
+	count, err := s.storage.RetryAllOrderDeadLetters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry order dead letters: %w", err)
+	}
+
+	return &response.RetriedOrders{Retried: count}, nil
+}
+
+// InvalidateOrderDeadLetter marks the dead lettered order invalid, so it is never checked again.
+func (s *Service) InvalidateOrderDeadLetter(ctx context.Context, number string) error {
+	if _, err := s.storage.InvalidateOrderDeadLetter(ctx, number); err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return application.ErrNotFound
+		}
+		return fmt.Errorf("failed to invalidate order dead letter: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ListOrderDiscrepancies(ctx context.Context) ([]*response.OrderDiscrepancy, error) {
+	discrepancies, err := s.storage.ListOrderDiscrepancies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order discrepancies: %w", err)
+	}
+
+	if len(discrepancies) == 0 {
+		return nil, application.ErrNoData
+	}
+
+	resp := make([]*response.OrderDiscrepancy, len(discrepancies))
+
+	for i, discrepancy := range discrepancies {
+		resp[i] = discrepancyResponse(discrepancy)
+	}
+
+	return resp, nil
+}
+
+// AdjustOrderDiscrepancy corrects the order to match accrual system
+// and credits or debits the difference to the user.
+func (s *Service) AdjustOrderDiscrepancy(ctx context.Context, params *request.AdjustOrderDiscrepancy) (*response.OrderDiscrepancy, error) {
+	if params.Reason == "" {
+		return nil, application.ErrUnprocessable
+	}
+
+	discrepancy, err := s.storage.GetOrderDiscrepancy(ctx, params.ID)
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return nil, application.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get order discrepancy: %w", err)
+	}
+
+	if discrepancy.IsAdjusted() {
+		return nil, application.ErrConflict
+	}
+
+	adjusted, err := s.adjustOrderDiscrepancy(ctx, discrepancy, params.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return discrepancyResponse(adjusted), nil
+}
+
+func discrepancyResponse(discrepancy *model.OrderDiscrepancy) *response.OrderDiscrepancy {
+	resp := &response.OrderDiscrepancy{
+		ID:              discrepancy.ID.String(),
+		Number:          discrepancy.OrderNumber,
+		Status:          string(discrepancy.Status),
+		Accrual:         float32(discrepancy.Accrual) / 100.0,
+		ExpectedStatus:  string(discrepancy.ExpectedStatus),
+		ExpectedAccrual: float32(discrepancy.ExpectedAccrual) / 100.0,
+		Adjustment:      float32(discrepancy.Adjustment) / 100.0,
+		Reason:          discrepancy.Reason,
+		CreatedAt:       discrepancy.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       discrepancy.UpdatedAt.Format(time.RFC3339),
+	}
+	if discrepancy.IsAdjusted() {
+		resp.AdjustedAt = discrepancy.AdjustedAt.Format(time.RFC3339)
+	}
+
+	return resp
+}
+
+// GetWorkerPoolStats reports load of the pool checking orders.
+func (s *Service) GetWorkerPoolStats(ctx context.Context) *response.WorkerPoolStats {
+	return workerPoolStatsResponse(s.pool.Stats())
+}
+
+// ResizeWorkerPool changes the number of workers checking orders.
+func (s *Service) ResizeWorkerPool(ctx context.Context, params *request.ResizeWorkerPool) (*response.WorkerPoolStats, error) {
+	if err := s.pool.Resize(params.Size); err != nil {
+		if errors.Is(err, workerpool.ErrInvalidSize) {
+			return nil, application.ErrUnprocessable
+		}
+		return nil, fmt.Errorf("failed to resize worker pool: %w", err)
+	}
+
+	return workerPoolStatsResponse(s.pool.Stats()), nil
+}
+
+func workerPoolStatsResponse(stats workerpool.Stats) *response.WorkerPoolStats {
+	return &response.WorkerPoolStats{
+		Workers:    stats.Workers,
+		Active:     stats.Active,
+		Queued:     stats.Queued,
+		QueueSize:  stats.QueueSize,
+		Scheduled:  stats.Scheduled,
+		Completed:  stats.Completed,
+		Failed:     stats.Failed,
+		Rejected:   stats.Rejected,
+		Joined:     stats.Joined,
+		AvgRunTime: stats.AvgRunTime.String(),
+	}
+}
@@ -0,0 +1,505 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/request"
+	"github.com/dtroode/gophermart/internal/application/response"
+	"github.com/dtroode/gophermart/internal/application/service"
+	mocks "github.com/dtroode/gophermart/internal/application/service/mocks"
+	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/dtroode/gophermart/internal/workerpool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_ListOrderDeadLetters(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "ListOrderDeadLetters")
+	now := time.Now()
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		expectedResp []*response.OrderDeadLetter
+		expectedErr  error
+	}{
+		"failed to list dead letters": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListOrderDeadLetters", ctx).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to list order dead letters: %w", errors.New("storage error")),
+		},
+		"no dead letters": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListOrderDeadLetters", ctx).Once().Return([]*model.OrderDeadLetter{}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrNoData,
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListOrderDeadLetters", ctx).Once().Return([]*model.OrderDeadLetter{
+					{OrderNumber: "1234", Error: "internal service error", Attempts: 3, CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+				}, nil)
+				return mock
+			}(),
+			expectedResp: []*response.OrderDeadLetter{
+				{
+					Number:    "1234",
+					Error:     "internal service error",
+					Attempts:  3,
+					CreatedAt: now.Add(-time.Hour).Format(time.RFC3339),
+					UpdatedAt: now.Format(time.RFC3339),
+				},
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			resp, err := s.ListOrderDeadLetters(ctx)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_RetryOrderDeadLetter(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "RetryOrderDeadLetter")
+
+	tests := map[string]struct {
+		storageMock *mocks.Storage
+		expectedErr error
+	}{
+		"not found": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RetryOrderDeadLetter", ctx, "1234").Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"storage error": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RetryOrderDeadLetter", ctx, "1234").Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to retry order dead letter: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RetryOrderDeadLetter", ctx, "1234").Once().Return(&model.Order{Number: "1234"}, nil)
+				return mock
+			}(),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			err := s.RetryOrderDeadLetter(ctx, "1234")
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestService_RetryAllOrderDeadLetters(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "RetryAllOrderDeadLetters")
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		expectedResp *response.RetriedOrders
+		expectedErr  error
+	}{
+		"storage error": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RetryAllOrderDeadLetters", ctx).Once().Return(int64(0), errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to retry order dead letters: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RetryAllOrderDeadLetters", ctx).Once().Return(int64(5), nil)
+				return mock
+			}(),
+			expectedResp: &response.RetriedOrders{Retried: 5},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			resp, err := s.RetryAllOrderDeadLetters(ctx)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_InvalidateOrderDeadLetter(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "InvalidateOrderDeadLetter")
+
+	tests := map[string]struct {
+		storageMock *mocks.Storage
+		expectedErr error
+	}{
+		"not found": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("InvalidateOrderDeadLetter", ctx, "1234").Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"storage error": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("InvalidateOrderDeadLetter", ctx, "1234").Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to invalidate order dead letter: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("InvalidateOrderDeadLetter", ctx, "1234").Once().Return(&model.Order{
+					Number: "1234",
+					Status: model.OrderStatusInvalid,
+				}, nil)
+				return mock
+			}(),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			err := s.InvalidateOrderDeadLetter(ctx, "1234")
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestService_ListOrderDiscrepancies(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "ListOrderDiscrepancies")
+	now := time.Now()
+	id := uuid.New()
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		expectedResp []*response.OrderDiscrepancy
+		expectedErr  error
+	}{
+		"failed to list discrepancies": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListOrderDiscrepancies", ctx).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to list order discrepancies: %w", errors.New("storage error")),
+		},
+		"no discrepancies": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListOrderDiscrepancies", ctx).Once().Return([]*model.OrderDiscrepancy{}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrNoData,
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListOrderDiscrepancies", ctx).Once().Return([]*model.OrderDiscrepancy{
+					{
+						ID:              id,
+						OrderNumber:     "1234",
+						Status:          model.OrderStatusProcessed,
+						Accrual:         5000,
+						ExpectedStatus:  model.AccrualOrderStatusProcessed,
+						ExpectedAccrual: 7550,
+						CreatedAt:       now.Add(-time.Hour),
+						UpdatedAt:       now,
+						AdjustedAt:      now,
+						Adjustment:      2550,
+						Reason:          "accrual corrected",
+					},
+					{
+						ID:             id,
+						OrderNumber:    "5678",
+						Status:         model.OrderStatusInvalid,
+						ExpectedStatus: model.AccrualOrderStatusProcessing,
+						CreatedAt:      now,
+						UpdatedAt:      now,
+					},
+				}, nil)
+				return mock
+			}(),
+			expectedResp: []*response.OrderDiscrepancy{
+				{
+					ID:              id.String(),
+					Number:          "1234",
+					Status:          "PROCESSED",
+					Accrual:         50,
+					ExpectedStatus:  "PROCESSED",
+					ExpectedAccrual: 75.5,
+					Adjustment:      25.5,
+					Reason:          "accrual corrected",
+					CreatedAt:       now.Add(-time.Hour).Format(time.RFC3339),
+					UpdatedAt:       now.Format(time.RFC3339),
+					AdjustedAt:      now.Format(time.RFC3339),
+				},
+				{
+					ID:             id.String(),
+					Number:         "5678",
+					Status:         "INVALID",
+					ExpectedStatus: "PROCESSING",
+					CreatedAt:      now.Format(time.RFC3339),
+					UpdatedAt:      now.Format(time.RFC3339),
+				},
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			resp, err := s.ListOrderDiscrepancies(ctx)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_AdjustOrderDiscrepancy(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "AdjustOrderDiscrepancy")
+	now := time.Now()
+	id := uuid.New()
+
+	params := &request.AdjustOrderDiscrepancy{ID: id, Reason: "accrual corrected"}
+	open := &model.OrderDiscrepancy{
+		ID:              id,
+		OrderNumber:     "1234",
+		Status:          model.OrderStatusProcessed,
+		Accrual:         5000,
+		ExpectedStatus:  model.AccrualOrderStatusProcessed,
+		ExpectedAccrual: 7550,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	adjust := &storage.AdjustOrderDiscrepancy{ID: id, Status: model.OrderStatusProcessed, Reason: "accrual corrected"}
+
+	tests := map[string]struct {
+		params       *request.AdjustOrderDiscrepancy
+		storageMock  *mocks.Storage
+		expectedResp *response.OrderDiscrepancy
+		expectedErr  error
+	}{
+		"empty reason": {
+			params:      &request.AdjustOrderDiscrepancy{ID: id},
+			expectedErr: application.ErrUnprocessable,
+		},
+		"not found": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"failed to get discrepancy": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to get order discrepancy: %w", errors.New("storage error")),
+		},
+		"already adjusted": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(&model.OrderDiscrepancy{ID: id, AdjustedAt: now}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrConflict,
+		},
+		"order is not final in accrual system": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(&model.OrderDiscrepancy{
+					ID:             id,
+					ExpectedStatus: model.AccrualOrderStatusProcessing,
+				}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrUnprocessable,
+		},
+		"adjusted concurrently": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(open, nil)
+				mock.On("AdjustOrderDiscrepancy", ctx, adjust).Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrConflict,
+		},
+		"not enough bonuses": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(open, nil)
+				mock.On("AdjustOrderDiscrepancy", ctx, adjust).Once().Return(nil, application.ErrNotEnoughBonuses)
+				return mock
+			}(),
+			expectedErr: application.ErrNotEnoughBonuses,
+		},
+		"failed to adjust": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(open, nil)
+				mock.On("AdjustOrderDiscrepancy", ctx, adjust).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to adjust order discrepancy: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderDiscrepancy", ctx, id).Once().Return(open, nil)
+				mock.On("AdjustOrderDiscrepancy", ctx, adjust).Once().Return(&model.OrderDiscrepancy{
+					ID:              id,
+					OrderNumber:     "1234",
+					Status:          model.OrderStatusProcessed,
+					Accrual:         5000,
+					ExpectedStatus:  model.AccrualOrderStatusProcessed,
+					ExpectedAccrual: 7550,
+					CreatedAt:       now,
+					UpdatedAt:       now,
+					AdjustedAt:      now,
+					Adjustment:      2550,
+					Reason:          "accrual corrected",
+				}, nil)
+				return mock
+			}(),
+			expectedResp: &response.OrderDiscrepancy{
+				ID:              id.String(),
+				Number:          "1234",
+				Status:          "PROCESSED",
+				Accrual:         50,
+				ExpectedStatus:  "PROCESSED",
+				ExpectedAccrual: 75.5,
+				Adjustment:      25.5,
+				Reason:          "accrual corrected",
+				CreatedAt:       now.Format(time.RFC3339),
+				UpdatedAt:       now.Format(time.RFC3339),
+				AdjustedAt:      now.Format(time.RFC3339),
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			p := tt.params
+			if p == nil {
+				p = params
+			}
+
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			resp, err := s.AdjustOrderDiscrepancy(ctx, p)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_ResizeWorkerPool(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "ResizeWorkerPool")
+
+	tests := map[string]struct {
+		size         int
+		poolMock     *mocks.WorkerPool
+		expectedResp *response.WorkerPoolStats
+		expectedErr  error
+	}{
+		"size is not positive": {
+			size: 0,
+			poolMock: func() *mocks.WorkerPool {
+				mock := mocks.NewWorkerPool(t)
+				mock.On("Resize", 0).Once().Return(workerpool.ErrInvalidSize)
+				return mock
+			}(),
+			expectedErr: application.ErrUnprocessable,
+		},
+		"pool is stopped": {
+			size: 10,
+			poolMock: func() *mocks.WorkerPool {
+				mock := mocks.NewWorkerPool(t)
+				mock.On("Resize", 10).Once().Return(workerpool.ErrStopped)
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to resize worker pool: %w", workerpool.ErrStopped),
+		},
+		"success": {
+			size: 10,
+			poolMock: func() *mocks.WorkerPool {
+				mock := mocks.NewWorkerPool(t)
+				mock.On("Resize", 10).Once().Return(nil)
+				mock.On("Stats").Once().Return(workerpool.Stats{
+					Workers:    10,
+					Active:     2,
+					Queued:     3,
+					QueueSize:  25,
+					Completed:  40,
+					Failed:     1,
+					AvgRunTime: 150 * time.Millisecond,
+				})
+				return mock
+			}(),
+			expectedResp: &response.WorkerPoolStats{
+				Workers:    10,
+				Active:     2,
+				Queued:     3,
+				QueueSize:  25,
+				Completed:  40,
+				Failed:     1,
+				AvgRunTime: "150ms",
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(nil, nil, nil, nil, tt.poolMock)
+
+			resp, err := s.ResizeWorkerPool(ctx, &request.ResizeWorkerPool{Size: tt.size})
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
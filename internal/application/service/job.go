@@ -9,11 +9,23 @@ import (
 	"github.com/dtroode/gophermart/internal/application"
 	"github.com/dtroode/gophermart/internal/application/model"
 	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/dtroode/gophermart/internal/workerpool"
 	"github.com/google/uuid"
 )
 
-func (s *Service) isRetryableError(err error) bool {
-	return errors.Is(err, application.ErrAccrualTooManyRequests) || errors.Is(err, application.ErrAccrualOrderNotRegistered)
+// maxOrderRetryBackoff caps the delay between checks of an order that keeps failing.
+const maxOrderRetryBackoff = 10 * time.Minute
+
+// isPermanentError reports errors of checks that can't succeed however many times they are retried.
+// Other errors, like timeouts and failures of accrual system or storage, may pass,
+// so such checks are retried with backoff until attempts are exhausted.
+func isPermanentError(err error) bool {
+	return errors.Is(err, application.ErrAccrualBadResponse) ||
+		errors.Is(err, application.ErrAccrualUnknownProvider)
+}
+
+func (s *Service) attemptsExhausted(order *model.Order) bool {
+	return s.maxOrderAttempts > 0 && int(order.Attempts) >= s.maxOrderAttempts
 }
 
 func (s *Service) updateOrderStatus(ctx context.Context, id uuid.UUID, status model.OrderStatus) (*model.Order, error) {
@@ -29,6 +41,55 @@ func (s *Service) updateOrderStatus(ctx context.Context, id uuid.UUID, status mo
 	return order, nil
 }
 
+// retryBackoff returns the delay before the next check of the order after a failed one,
+// it doubles with every attempt made so far.
+func (s *Service) retryBackoff(order *model.Order) time.Duration {
+	d := s.orderRetryInterval << min(max(order.Attempts, 0), 30)
+	if d <= 0 || d > maxOrderRetryBackoff {
+		return maxOrderRetryBackoff
+	}
+
+	return d
+}
+
+// recordOrderAttempt saves the outcome of the check and schedules the next one.
+// Only failed checks in a row are counted, so backoff and dead letters
+// don't depend on how long the order has been processed by accrual system.
+func (s *Service) recordOrderAttempt(ctx context.Context, order *model.Order, attemptErr error) (*model.Order, error) {
+	params := &storage.RecordOrderAttempt{
+		ID:            order.ID,
+		NextAttemptAt: time.Now().Add(s.checkInterval()),
+	}
+	if attemptErr != nil {
+		params.Attempts = order.Attempts + 1
+		params.NextAttemptAt = time.Now().Add(s.retryBackoff(order))
+		params.LastError = attemptErr.Error()
+		params.PublicError = publicOrderError(attemptErr)
+	}
+	order, err := s.storage.RecordOrderAttempt(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record order attempt: %w", err)
+	}
+
+	return order, nil
+}
+
+// deadLetterOrder stops checks of the order until an administrator retries it.
+func (s *Service) deadLetterOrder(ctx context.Context, id uuid.UUID, reason error) error {
+	params := &storage.DeadLetterOrder{
+		ID:          id,
+		Error:       reason.Error(),
+		PublicError: publicOrderError(reason),
+	}
+	_, err := s.storage.DeadLetterOrder(ctx, params)
+	// the order was finalized meanwhile by a push or another instance, there is nothing to park
+	if err != nil && !errors.Is(err, application.ErrNotFound) {
+		return fmt.Errorf("failed to dead letter order: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Service) finalizeInvalidOrder(ctx context.Context, id uuid.UUID) (*model.Order, error) {
 	params := &storage.SetOrderStatus{
 		ID:     id,
@@ -42,11 +103,11 @@ func (s *Service) finalizeInvalidOrder(ctx context.Context, id uuid.UUID) (*mode
 	return updatedOrder, nil
 }
 
-func (s *Service) finalizeProcessedOrder(ctx context.Context, orderID uuid.UUID, accrual float32) (any, error) {
+func (s *Service) finalizeProcessedOrder(ctx context.Context, orderID uuid.UUID, accrual float32) (*model.Order, error) {
 	params := &storage.SetOrderStatusAndAccrual{
 		ID:      orderID,
 		Status:  model.OrderStatusProcessed,
-		Accrual: int32(accrual * 100.0),
+		Accrual: accrualCents(accrual),
 	}
 	order, err := s.storage.SetOrderStatusAndAccrual(ctx, params)
 	if err != nil {
@@ -55,44 +116,155 @@ func (s *Service) finalizeProcessedOrder(ctx context.Context, orderID uuid.UUID,
 	return order, nil
 }
 
-func (s *Service) checkOrderJob(orderID uuid.UUID, orderNumber string, duration time.Duration) func(ctx context.Context) (any, error) {
-	return func(ctx context.Context) (any, error) {
-		c := time.Tick(duration)
-		currentStatus := model.AccrualOrderStatusRegistered
-		for {
-			select {
-			case <-c:
-				order, err := s.accrualAdapter.GetOrder(ctx, orderNumber)
-				if err != nil {
-					if s.isRetryableError(err) {
-						continue
-					}
-
-					return nil, fmt.Errorf("failed to check order: %w", err)
-				}
-
-				switch order.Status {
-				case model.AccrualOrderStatusProcessing:
-					if currentStatus != order.Status {
-						if _, err := s.updateOrderStatus(ctx, orderID, model.OrderStatusProcessing); err != nil {
-							return nil, err
-						}
-						currentStatus = order.Status
-					}
-					continue
-				case model.AccrualOrderStatusInvalid:
-					return s.finalizeInvalidOrder(ctx, orderID)
-				case model.AccrualOrderStatusProcessed:
-					return s.finalizeProcessedOrder(ctx, orderID, order.Accrual)
-				default:
-					continue
-				}
-			case <-ctx.Done():
-				if err := ctx.Err(); err != nil {
-					return nil, fmt.Errorf("ctx is closed: %w", err)
-				}
-				return nil, fmt.Errorf("failed to get reason of context close")
+// checkOrder asks accrual system about the order once. Orders that are not final yet
+// are scheduled for the next check, which is picked up by PollOrders.
+// Failed checks are retried with backoff, orders that failed with permanent error
+// or ran out of attempts are moved to dead letters.
+func (s *Service) checkOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	accrualOrder, err := s.accrualAdapter.GetOrder(ctx, order.AccrualProvider, order.Number)
+	if err != nil {
+		// the job context may be already done, the outcome should be saved anyway
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderStoreTimeout)
+		defer cancel()
+
+		// the check was interrupted by shutdown, not failed, so the order is handed back
+		// to the next poll without spending an attempt
+		if errors.Is(err, context.Canceled) {
+			if err := s.storage.ReleaseOrder(storeCtx, order.ID); err != nil {
+				return nil, fmt.Errorf("failed to release order: %w", err)
 			}
+			return order, nil
+		}
+
+		updatedOrder, recordErr := s.recordOrderAttempt(storeCtx, order, err)
+		if recordErr != nil {
+			return nil, recordErr
+		}
+		if !isPermanentError(err) && !s.attemptsExhausted(updatedOrder) {
+			return updatedOrder, nil
+		}
+
+		checkErr := fmt.Errorf("failed to check order: %w", err)
+		if err := s.deadLetterOrder(storeCtx, order.ID, err); err != nil {
+			return nil, errors.Join(checkErr, err)
+		}
+
+		return nil, checkErr
+	}
+
+	// the answer is saved even if the job is interrupted meanwhile
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderStoreTimeout)
+	defer cancel()
+
+	return s.applyAccrualOrder(storeCtx, order, accrualOrder, func(ctx context.Context, o *model.Order) (*model.Order, error) {
+		return s.recordOrderAttempt(ctx, o, nil)
+	})
+}
+
+// applyAccrualOrder moves the order according to its status in accrual system,
+// orders that are not final yet are passed to schedule for the next check.
+// If the order was already moved by a concurrent check or accrual event,
+// the update is skipped and the order is returned as is.
+func (s *Service) applyAccrualOrder(
+	ctx context.Context,
+	order *model.Order,
+	accrualOrder *model.AccrualOrder,
+	schedule func(ctx context.Context, order *model.Order) (*model.Order, error),
+) (*model.Order, error) {
+	updatedOrder, err := s.transitionOrder(ctx, order, accrualOrder)
+	if errors.Is(err, application.ErrIllegalTransition) {
+		return order, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updatedOrder.IsFinal() {
+		return updatedOrder, nil
+	}
+
+	return schedule(ctx, updatedOrder)
+}
+
+func (s *Service) transitionOrder(ctx context.Context, order *model.Order, accrualOrder *model.AccrualOrder) (*model.Order, error) {
+	switch accrualOrder.Status {
+	case model.AccrualOrderStatusInvalid:
+		return s.finalizeInvalidOrder(ctx, order.ID)
+	case model.AccrualOrderStatusProcessed:
+		return s.finalizeProcessedOrder(ctx, order.ID, accrualOrder.Accrual)
+	case model.AccrualOrderStatusProcessing:
+		if order.Status != model.OrderStatusProcessing {
+			return s.updateOrderStatus(ctx, order.ID, model.OrderStatusProcessing)
 		}
 	}
+
+	return order, nil
+}
+
+// postponeOrderCheck moves the fallback check of the order pushed by accrual system.
+// Pushes are not checks, so attempts and the last error are left as they are.
+// Orders dead lettered meanwhile are returned as is.
+func (s *Service) postponeOrderCheck(ctx context.Context, order *model.Order) (*model.Order, error) {
+	params := &storage.PostponeOrderCheck{
+		ID:            order.ID,
+		NextAttemptAt: time.Now().Add(s.checkInterval()),
+	}
+	updatedOrder, err := s.storage.PostponeOrderCheck(ctx, params)
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return order, nil
+		}
+		return nil, fmt.Errorf("failed to postpone order check: %w", err)
+	}
+
+	return updatedOrder, nil
+}
+
+// accrualCents converts accrual reported by accrual system to the amount stored in orders.
+func accrualCents(accrual float32) int32 {
+	return int32(accrual * 100.0)
+}
+
+// Kinds of jobs run for an order.
+const (
+	checkOrderJobKind     = "check"
+	reconcileOrderJobKind = "reconcile"
+)
+
+// orderJobContext keys the job by user, so orders of one user take turns
+// with orders of the others in the pool, and by the order, so the same job
+// submitted again while it is pending joins it instead of racing with it.
+func orderJobContext(order *model.Order, kind string) context.Context {
+	ctx := workerpool.WithKey(context.Background(), order.UserID.String())
+	return workerpool.WithIdempotencyKey(ctx, kind+"/"+order.Number)
+}
+
+func (s *Service) checkOrderJob(order *model.Order) func(ctx context.Context) (*model.Order, error) {
+	return func(ctx context.Context) (*model.Order, error) {
+		defer func() {
+			if v := recover(); v != nil {
+				s.recordOrderPanic(ctx, order, v)
+				// the pool recovers the panic and logs its stack
+				panic(v)
+			}
+		}()
+
+		return s.checkOrder(ctx, order)
+	}
+}
+
+// recordOrderPanic counts the check that panicked as failed, so an order that keeps
+// panicking is moved to dead letters when its attempts are exhausted
+// instead of being claimed again after every lease.
+func (s *Service) recordOrderPanic(ctx context.Context, order *model.Order, v any) {
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), orderStoreTimeout)
+	defer cancel()
+
+	checkErr := fmt.Errorf("check panicked: %v", v)
+	updatedOrder, err := s.recordOrderAttempt(storeCtx, order, checkErr)
+	if err == nil && s.attemptsExhausted(updatedOrder) {
+		err = s.deadLetterOrder(storeCtx, order.ID, checkErr)
+	}
+	if err != nil {
+		s.logger.Error("failed to record panicked order check", "order", order.Number, "error", err)
+	}
 }
@@ -14,22 +14,34 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_checkOrderJob(t *testing.T) {
 	orderID := uuid.New()
 	orderNumber := "1234"
 
+	attempt := func(lastError string) any {
+		return mock.MatchedBy(func(dto *storage.RecordOrderAttempt) bool {
+			// failed checks are counted, successful one resets the count
+			return dto.ID == orderID && dto.LastError == lastError && (dto.Attempts == 0) == (lastError == "") &&
+				(dto.PublicError == "") == (lastError == "") &&
+				dto.NextAttemptAt.After(time.Now())
+		})
+	}
+
 	tests := map[string]struct {
+		order        *model.Order
+		opts         []Option
 		accrualMock  *mocks.AccrualAdapter
 		storageMock  *mocks.Storage
-		expectedResp any
+		expectedResp *model.Order
 		expectedErr  error
 	}{
 		"order status invalid": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).Once().
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
 					Return(&model.AccrualOrder{
 						Number: orderNumber,
 						Status: model.AccrualOrderStatusInvalid,
@@ -57,7 +69,7 @@ func TestService_checkOrderJob(t *testing.T) {
 		"order status processed": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).Once().
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
 					Return(&model.AccrualOrder{
 						Status:  model.AccrualOrderStatusProcessed,
 						Accrual: 50,
@@ -86,19 +98,14 @@ func TestService_checkOrderJob(t *testing.T) {
 				Status:  model.OrderStatusProcessed,
 			},
 		},
-		"order status processing and than processed": {
+		"new order status processing": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
 					Return(&model.AccrualOrder{
 						Status: model.AccrualOrderStatusProcessing,
 						Number: orderNumber,
-					}, nil).Once()
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status:  model.AccrualOrderStatusProcessed,
-					Accrual: 50,
-					Number:  orderNumber,
-				}, nil).Once()
+					}, nil)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
@@ -111,220 +118,157 @@ func TestService_checkOrderJob(t *testing.T) {
 					Number: orderNumber,
 					Status: model.OrderStatusProcessing,
 				}, nil)
-				storageMock.On("SetOrderStatusAndAccrual", mock.Anything, &storage.SetOrderStatusAndAccrual{
-					ID:      orderID,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 5000,
-				}).Once().Return(&model.Order{
-					ID:      orderID,
-					Number:  orderNumber,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 50,
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt("")).Once().Return(&model.Order{
+					ID:     orderID,
+					Number: orderNumber,
+					Status: model.OrderStatusProcessing,
 				}, nil)
 				return storageMock
 			}(),
 			expectedResp: &model.Order{
-				ID:      orderID,
-				Number:  orderNumber,
-				Accrual: 50,
-				Status:  model.OrderStatusProcessed,
+				ID:     orderID,
+				Number: orderNumber,
+				Status: model.OrderStatusProcessing,
 			},
 		},
-		"order status processing and than invalid": {
+		"processing order status processing": {
+			order: &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusProcessing},
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
 					Return(&model.AccrualOrder{
 						Status: model.AccrualOrderStatusProcessing,
 						Number: orderNumber,
-					}, nil).Once()
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status: model.AccrualOrderStatusInvalid,
-					Number: orderNumber,
-				}, nil).Once()
+					}, nil)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
 				storageMock := mocks.NewStorage(t)
-				storageMock.On("SetOrderStatus", mock.Anything, &storage.SetOrderStatus{
-					ID:     orderID,
-					Status: model.OrderStatusProcessing,
-				}).Once().Return(&model.Order{
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt("")).Once().Return(&model.Order{
 					ID:     orderID,
 					Number: orderNumber,
 					Status: model.OrderStatusProcessing,
 				}, nil)
-				storageMock.On("SetOrderStatus", mock.Anything, &storage.SetOrderStatus{
-					ID:     orderID,
-					Status: model.OrderStatusInvalid,
-				}).Once().Return(&model.Order{
-					ID:     orderID,
-					Number: orderNumber,
-					Status: model.OrderStatusInvalid,
-				}, nil)
 				return storageMock
 			}(),
 			expectedResp: &model.Order{
 				ID:     orderID,
 				Number: orderNumber,
-				Status: model.OrderStatusInvalid,
+				Status: model.OrderStatusProcessing,
 			},
 		},
-		"order status registered and than processed": {
+		"order status registered": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
 					Return(&model.AccrualOrder{
 						Status: model.AccrualOrderStatusRegistered,
 						Number: orderNumber,
-					}, nil).Once()
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status:  model.AccrualOrderStatusProcessed,
-					Accrual: 50,
-					Number:  orderNumber,
-				}, nil).Once()
+					}, nil)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
 				storageMock := mocks.NewStorage(t)
-				storageMock.On("SetOrderStatusAndAccrual", mock.Anything, &storage.SetOrderStatusAndAccrual{
-					ID:      orderID,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 5000,
-				}).Once().Return(&model.Order{
-					ID:      orderID,
-					Number:  orderNumber,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 50,
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt("")).Once().Return(&model.Order{
+					ID:     orderID,
+					Number: orderNumber,
+					Status: model.OrderStatusNew,
 				}, nil)
 				return storageMock
 			}(),
 			expectedResp: &model.Order{
-				ID:      orderID,
-				Number:  orderNumber,
-				Accrual: 50,
-				Status:  model.OrderStatusProcessed,
+				ID:     orderID,
+				Number: orderNumber,
+				Status: model.OrderStatusNew,
 			},
 		},
-		"order status registered and than invalid": {
+		"err too many requests": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
-					Return(&model.AccrualOrder{
-						Status: model.AccrualOrderStatusRegistered,
-						Number: orderNumber,
-					}, nil).Once()
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status: model.AccrualOrderStatusInvalid,
-					Number: orderNumber,
-				}, nil).Once()
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualTooManyRequests)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
 				storageMock := mocks.NewStorage(t)
-				storageMock.On("SetOrderStatus", mock.Anything, &storage.SetOrderStatus{
-					ID:     orderID,
-					Status: model.OrderStatusInvalid,
-				}).Once().Return(&model.Order{
-					ID:     orderID,
-					Number: orderNumber,
-					Status: model.OrderStatusInvalid,
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualTooManyRequests.Error())).Once().Return(&model.Order{
+					ID:        orderID,
+					Number:    orderNumber,
+					Status:    model.OrderStatusNew,
+					Attempts:  1,
+					LastError: application.ErrAccrualTooManyRequests.Error(),
 				}, nil)
 				return storageMock
 			}(),
 			expectedResp: &model.Order{
-				ID:     orderID,
-				Number: orderNumber,
-				Status: model.OrderStatusInvalid,
+				ID:        orderID,
+				Number:    orderNumber,
+				Status:    model.OrderStatusNew,
+				Attempts:  1,
+				LastError: application.ErrAccrualTooManyRequests.Error(),
 			},
 		},
-		"order status processing 5 times and than processed": {
+		"err order not registered": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
-					Return(&model.AccrualOrder{
-						Status: model.AccrualOrderStatusProcessing,
-						Number: orderNumber,
-					}, nil).Times(5)
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status:  model.AccrualOrderStatusProcessed,
-					Accrual: 50,
-					Number:  orderNumber,
-				}, nil).Once()
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualOrderNotRegistered)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
 				storageMock := mocks.NewStorage(t)
-				storageMock.On("SetOrderStatus", mock.Anything, &storage.SetOrderStatus{
-					ID:     orderID,
-					Status: model.OrderStatusProcessing,
-				}).Once().Return(&model.Order{
-					ID:     orderID,
-					Number: orderNumber,
-					Status: model.OrderStatusProcessing,
-				}, nil)
-				storageMock.On("SetOrderStatusAndAccrual", mock.Anything, &storage.SetOrderStatusAndAccrual{
-					ID:      orderID,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 5000,
-				}).Once().Return(&model.Order{
-					ID:      orderID,
-					Number:  orderNumber,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 50,
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualOrderNotRegistered.Error())).Once().Return(&model.Order{
+					ID:        orderID,
+					Number:    orderNumber,
+					Status:    model.OrderStatusNew,
+					Attempts:  1,
+					LastError: application.ErrAccrualOrderNotRegistered.Error(),
 				}, nil)
 				return storageMock
 			}(),
 			expectedResp: &model.Order{
-				ID:      orderID,
-				Number:  orderNumber,
-				Accrual: 50,
-				Status:  model.OrderStatusProcessed,
+				ID:        orderID,
+				Number:    orderNumber,
+				Status:    model.OrderStatusNew,
+				Attempts:  1,
+				LastError: application.ErrAccrualOrderNotRegistered.Error(),
 			},
 		},
-		"err too many requests and than processed": {
+		"err accrual unavailable": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
-					Return(nil, application.ErrAccrualTooManyRequests).Once()
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status:  model.AccrualOrderStatusProcessed,
-					Accrual: 50,
-					Number:  orderNumber,
-				}, nil).Once()
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualUnavailable)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
 				storageMock := mocks.NewStorage(t)
-				storageMock.On("SetOrderStatusAndAccrual", mock.Anything, &storage.SetOrderStatusAndAccrual{
-					ID:      orderID,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 5000,
-				}).Once().Return(&model.Order{
-					ID:      orderID,
-					Number:  orderNumber,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 50,
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualUnavailable.Error())).Once().Return(&model.Order{
+					ID:        orderID,
+					Number:    orderNumber,
+					Status:    model.OrderStatusNew,
+					Attempts:  1,
+					LastError: application.ErrAccrualUnavailable.Error(),
 				}, nil)
 				return storageMock
 			}(),
 			expectedResp: &model.Order{
-				ID:      orderID,
-				Number:  orderNumber,
-				Accrual: 50,
-				Status:  model.OrderStatusProcessed,
+				ID:        orderID,
+				Number:    orderNumber,
+				Status:    model.OrderStatusNew,
+				Attempts:  1,
+				LastError: application.ErrAccrualUnavailable.Error(),
 			},
 		},
-		"err order not registerd and than processed": {
+		"order already finalized by another check": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
-					Return(nil, application.ErrAccrualOrderNotRegistered).Once()
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
-					Status:  model.AccrualOrderStatusProcessed,
-					Accrual: 50,
-					Number:  orderNumber,
-				}, nil).Once()
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(&model.AccrualOrder{
+						Status:  model.AccrualOrderStatusProcessed,
+						Accrual: 50,
+						Number:  orderNumber,
+					}, nil)
 				return accrual
 			}(),
 			storageMock: func() *mocks.Storage {
@@ -333,25 +277,35 @@ func TestService_checkOrderJob(t *testing.T) {
 					ID:      orderID,
 					Status:  model.OrderStatusProcessed,
 					Accrual: 5000,
-				}).Once().Return(&model.Order{
-					ID:      orderID,
-					Number:  orderNumber,
-					Status:  model.OrderStatusProcessed,
-					Accrual: 50,
-				}, nil)
+				}).Once().Return(nil, application.ErrIllegalTransition)
 				return storageMock
 			}(),
-			expectedResp: &model.Order{
-				ID:      orderID,
-				Number:  orderNumber,
-				Accrual: 50,
-				Status:  model.OrderStatusProcessed,
-			},
+			expectedResp: &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew},
+		},
+		"order finalized while processing": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(&model.AccrualOrder{
+						Status: model.AccrualOrderStatusProcessing,
+						Number: orderNumber,
+					}, nil)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("SetOrderStatus", mock.Anything, &storage.SetOrderStatus{
+					ID:     orderID,
+					Status: model.OrderStatusProcessing,
+				}).Once().Return(nil, application.ErrIllegalTransition)
+				return storageMock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew},
 		},
 		"failed to set order status": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Return(&model.AccrualOrder{
 					Status: model.AccrualOrderStatusProcessing,
 					Number: orderNumber,
 				}, nil).Once()
@@ -370,7 +324,7 @@ func TestService_checkOrderJob(t *testing.T) {
 		"failed to set order status and accrual": {
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).Return(&model.AccrualOrder{
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Return(&model.AccrualOrder{
 					Status:  model.AccrualOrderStatusProcessed,
 					Accrual: 50,
 					Number:  orderNumber,
@@ -388,14 +342,202 @@ func TestService_checkOrderJob(t *testing.T) {
 			}(),
 			expectedErr: fmt.Errorf("failed to update order in storage: %w", errors.New("storage error")),
 		},
-		"unexpected error": {
+		"failed to record order attempt": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Return(&model.AccrualOrder{
+					Status: model.AccrualOrderStatusRegistered,
+					Number: orderNumber,
+				}, nil).Once()
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt("")).Once().Return(nil, errors.New("storage error"))
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to record order attempt: %w", errors.New("storage error")),
+		},
+		"order of partner provider": {
+			order: &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew, AccrualProvider: "partner"},
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "partner", orderNumber).Once().
+					Return(&model.AccrualOrder{
+						Number: orderNumber,
+						Status: model.AccrualOrderStatusInvalid,
+					}, nil)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("SetOrderStatus", mock.Anything, &storage.SetOrderStatus{
+					ID:     orderID,
+					Status: model.OrderStatusInvalid,
+				}).Once().Return(&model.Order{ID: orderID, Status: model.OrderStatusInvalid}, nil)
+				return storageMock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Status: model.OrderStatusInvalid},
+		},
+		"order of unknown provider": {
+			order: &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew, AccrualProvider: "gone"},
 			accrualMock: func() *mocks.AccrualAdapter {
 				accrual := mocks.NewAccrualAdapter(t)
-				accrual.On("GetOrder", mock.Anything, orderNumber).
+				accrual.On("GetOrder", mock.Anything, "gone", orderNumber).Once().
+					Return(nil, application.ErrAccrualUnknownProvider)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualUnknownProvider.Error())).Once().Return(&model.Order{ID: orderID}, nil)
+				storageMock.On("DeadLetterOrder", mock.Anything, &storage.DeadLetterOrder{
+					ID:          orderID,
+					Error:       application.ErrAccrualUnknownProvider.Error(),
+					PublicError: "failed to check order in accrual system",
+				}).Once().Return(&model.OrderDeadLetter{OrderID: orderID}, nil)
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to check order: %w", application.ErrAccrualUnknownProvider),
+		},
+		"unexpected error is retried": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
 					Return(nil, errors.New("accrual error"))
 				return accrual
 			}(),
-			expectedErr: fmt.Errorf("failed to check order: %w", errors.New("accrual error")),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt("accrual error")).Once().Return(&model.Order{ID: orderID, Attempts: 1}, nil)
+				return storageMock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Attempts: 1},
+		},
+		"timed out check is retried": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, context.DeadlineExceeded)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(context.DeadlineExceeded.Error())).Once().Return(&model.Order{ID: orderID, Attempts: 1}, nil)
+				return storageMock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Attempts: 1},
+		},
+		"malformed response": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualBadResponse)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualBadResponse.Error())).Once().Return(&model.Order{ID: orderID, Attempts: 1}, nil)
+				storageMock.On("DeadLetterOrder", mock.Anything, &storage.DeadLetterOrder{
+					ID:          orderID,
+					Error:       application.ErrAccrualBadResponse.Error(),
+					PublicError: "failed to check order in accrual system",
+				}).Once().Return(&model.OrderDeadLetter{OrderID: orderID}, nil)
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to check order: %w", application.ErrAccrualBadResponse),
+		},
+		"attempts exhausted": {
+			opts: []Option{WithMaxOrderAttempts(3)},
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualUnavailable)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualUnavailable.Error())).Once().Return(&model.Order{
+					ID:       orderID,
+					Attempts: 3,
+				}, nil)
+				storageMock.On("DeadLetterOrder", mock.Anything, &storage.DeadLetterOrder{
+					ID:          orderID,
+					Error:       application.ErrAccrualUnavailable.Error(),
+					PublicError: "accrual system is temporarily unavailable, order will be checked later",
+				}).Once().Return(&model.OrderDeadLetter{OrderID: orderID}, nil)
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to check order: %w", application.ErrAccrualUnavailable),
+		},
+		"interrupted check releases order": {
+			opts: []Option{WithMaxOrderAttempts(3)},
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, context.Canceled)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("ReleaseOrder", mock.Anything, orderID).Once().Return(nil)
+				return storageMock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew},
+		},
+		"failed to release order": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, context.Canceled)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("ReleaseOrder", mock.Anything, orderID).Once().Return(errors.New("storage error"))
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to release order: %w", errors.New("storage error")),
+		},
+		"order finalized before dead letter": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualBadResponse)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualBadResponse.Error())).Once().Return(&model.Order{ID: orderID, Attempts: 1}, nil)
+				storageMock.On("DeadLetterOrder", mock.Anything, &storage.DeadLetterOrder{
+					ID:          orderID,
+					Error:       application.ErrAccrualBadResponse.Error(),
+					PublicError: "failed to check order in accrual system",
+				}).Once().Return(nil, application.ErrNotFound)
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to check order: %w", application.ErrAccrualBadResponse),
+		},
+		"failed to dead letter order": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrual := mocks.NewAccrualAdapter(t)
+				accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+					Return(nil, application.ErrAccrualBadResponse)
+				return accrual
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt(application.ErrAccrualBadResponse.Error())).Once().Return(&model.Order{ID: orderID}, nil)
+				storageMock.On("DeadLetterOrder", mock.Anything, &storage.DeadLetterOrder{
+					ID:          orderID,
+					Error:       application.ErrAccrualBadResponse.Error(),
+					PublicError: "failed to check order in accrual system",
+				}).Once().Return(nil, errors.New("storage error"))
+				return storageMock
+			}(),
+			expectedErr: errors.Join(
+				fmt.Errorf("failed to check order: %w", application.ErrAccrualBadResponse),
+				fmt.Errorf("failed to dead letter order: %w", errors.New("storage error")),
+			),
 		},
 	}
 
@@ -403,12 +545,177 @@ func TestService_checkOrderJob(t *testing.T) {
 		t.Run(tn, func(t *testing.T) {
 			t.Parallel()
 
-			s := NewService(tt.storageMock, nil, nil, tt.accrualMock, nil)
+			order := tt.order
+			if order == nil {
+				order = &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew}
+			}
+
+			s := NewService(tt.storageMock, nil, nil, tt.accrualMock, nil, tt.opts...)
 
-			res, err := s.checkOrderJob(orderID, orderNumber, 1*time.Millisecond)(context.Background())
+			res, err := s.checkOrderJob(order)(context.Background())
 
 			assert.Equal(t, tt.expectedErr, err)
 			assert.Equal(t, tt.expectedResp, res)
 		})
 	}
 }
+
+func TestService_checkOrderJob_failedAfterPolls(t *testing.T) {
+	orderID := uuid.New()
+	orderNumber := "1234"
+	retryInterval := time.Minute
+
+	accrual := mocks.NewAccrualAdapter(t)
+	accrual.On("GetOrder", mock.Anything, "", orderNumber).Times(100).
+		Return(&model.AccrualOrder{Number: orderNumber, Status: model.AccrualOrderStatusProcessing}, nil)
+	accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+		Return(nil, application.ErrAccrualUnavailable)
+
+	// storage counts attempts the way the service tells it to
+	storageMock := mocks.NewStorage(t)
+	storageMock.On("RecordOrderAttempt", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, dto *storage.RecordOrderAttempt) (*model.Order, error) {
+			return &model.Order{
+				ID:            orderID,
+				Number:        orderNumber,
+				Status:        model.OrderStatusProcessing,
+				Attempts:      dto.Attempts,
+				NextAttemptAt: dto.NextAttemptAt,
+				LastError:     dto.LastError,
+			}, nil
+		})
+
+	s := NewService(storageMock, nil, nil, accrual, nil, WithMaxOrderAttempts(3), WithOrderRetryInterval(retryInterval))
+
+	order := &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusProcessing}
+	for range 101 {
+		var err error
+		order, err = s.checkOrderJob(order)(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), order.Attempts)
+	assert.Equal(t, application.ErrAccrualUnavailable.Error(), order.LastError)
+	assert.WithinDuration(t, time.Now().Add(retryInterval), order.NextAttemptAt, time.Second)
+	storageMock.AssertNotCalled(t, "DeadLetterOrder", mock.Anything, mock.Anything)
+}
+
+func TestService_checkOrderJob_panic(t *testing.T) {
+	orderID := uuid.New()
+	orderNumber := "1234"
+
+	attempt := mock.MatchedBy(func(dto *storage.RecordOrderAttempt) bool {
+		return dto.ID == orderID && dto.LastError == "check panicked: accrual bug"
+	})
+
+	tests := map[string]struct {
+		storageMock func(storageMock *mocks.Storage)
+	}{
+		"attempt is counted": {
+			storageMock: func(storageMock *mocks.Storage) {
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt).Once().
+					Return(&model.Order{ID: orderID, Attempts: 1}, nil)
+			},
+		},
+		"attempts exhausted": {
+			storageMock: func(storageMock *mocks.Storage) {
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt).Once().
+					Return(&model.Order{ID: orderID, Attempts: 3}, nil)
+				storageMock.On("DeadLetterOrder", mock.Anything, &storage.DeadLetterOrder{
+					ID:          orderID,
+					Error:       "check panicked: accrual bug",
+					PublicError: "failed to check order in accrual system",
+				}).Once().Return(&model.OrderDeadLetter{OrderID: orderID}, nil)
+			},
+		},
+		"failed to record attempt": {
+			storageMock: func(storageMock *mocks.Storage) {
+				storageMock.On("RecordOrderAttempt", mock.Anything, attempt).Once().
+					Return(nil, errors.New("storage error"))
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			accrual := mocks.NewAccrualAdapter(t)
+			accrual.On("GetOrder", mock.Anything, "", orderNumber).Once().
+				Run(func(mock.Arguments) { panic("accrual bug") })
+
+			storageMock := mocks.NewStorage(t)
+			tt.storageMock(storageMock)
+
+			s := NewService(storageMock, nil, nil, accrual, nil, WithMaxOrderAttempts(3))
+			order := &model.Order{ID: orderID, Number: orderNumber, Status: model.OrderStatusNew}
+
+			assert.PanicsWithValue(t, "accrual bug", func() {
+				_, _ = s.checkOrderJob(order)(context.Background())
+			})
+		})
+	}
+}
+
+func TestService_retryBackoff(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, nil, WithOrderRetryInterval(time.Second))
+
+	tests := map[string]struct {
+		attempts      int32
+		expectedDelay time.Duration
+	}{
+		"first attempt": {
+			attempts:      0,
+			expectedDelay: time.Second,
+		},
+		"doubles with attempts": {
+			attempts:      3,
+			expectedDelay: 8 * time.Second,
+		},
+		"capped": {
+			attempts:      20,
+			expectedDelay: maxOrderRetryBackoff,
+		},
+		"doesn't overflow": {
+			attempts:      100,
+			expectedDelay: maxOrderRetryBackoff,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tt.expectedDelay, s.retryBackoff(&model.Order{Attempts: tt.attempts}))
+		})
+	}
+}
+
+func TestPublicOrderError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected string
+	}{
+		"no error": {},
+		"order is not registered": {
+			err:      fmt.Errorf("failed to get order: %w", application.ErrAccrualOrderNotRegistered),
+			expected: "order is not registered in accrual system yet",
+		},
+		"too many requests": {
+			err:      fmt.Errorf("failed to get order: %w", application.ErrAccrualTooManyRequests),
+			expected: "accrual system is temporarily unavailable, order will be checked later",
+		},
+		"accrual system is unavailable": {
+			err:      application.ErrAccrualUnavailable,
+			expected: "accrual system is temporarily unavailable, order will be checked later",
+		},
+		"error mentioning a sentinel": {
+			err:      errors.New("dial tcp: order is not registered"),
+			expected: "failed to check order in accrual system",
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tt.expected, publicOrderError(tt.err))
+		})
+	}
+}
@@ -22,9 +22,9 @@ func (_m *AccrualAdapter) EXPECT() *AccrualAdapter_Expecter {
 	return &AccrualAdapter_Expecter{mock: &_m.Mock}
 }
 
-// GetOrder provides a mock function with given fields: ctx, orderNumber
-func (_m *AccrualAdapter) GetOrder(ctx context.Context, orderNumber string) (*model.AccrualOrder, error) {
-	ret := _m.Called(ctx, orderNumber)
+// GetOrder provides a mock function with given fields: ctx, provider, orderNumber
+func (_m *AccrualAdapter) GetOrder(ctx context.Context, provider string, orderNumber string) (*model.AccrualOrder, error) {
+	ret := _m.Called(ctx, provider, orderNumber)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetOrder")
@@ -32,19 +32,19 @@ func (_m *AccrualAdapter) GetOrder(ctx context.Context, orderNumber string) (*mo
 
 	var r0 *model.AccrualOrder
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.AccrualOrder, error)); ok {
-		return rf(ctx, orderNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.AccrualOrder, error)); ok {
+		return rf(ctx, provider, orderNumber)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.AccrualOrder); ok {
-		r0 = rf(ctx, orderNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.AccrualOrder); ok {
+		r0 = rf(ctx, provider, orderNumber)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.AccrualOrder)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, orderNumber)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, provider, orderNumber)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -59,14 +59,15 @@ type AccrualAdapter_GetOrder_Call struct {
 
 // GetOrder is a helper method to define mock.On call
 //   - ctx context.Context
+//   - provider string
 //   - orderNumber string
-func (_e *AccrualAdapter_Expecter) GetOrder(ctx interface{}, orderNumber interface{}) *AccrualAdapter_GetOrder_Call {
-	return &AccrualAdapter_GetOrder_Call{Call: _e.mock.On("GetOrder", ctx, orderNumber)}
+func (_e *AccrualAdapter_Expecter) GetOrder(ctx interface{}, provider interface{}, orderNumber interface{}) *AccrualAdapter_GetOrder_Call {
+	return &AccrualAdapter_GetOrder_Call{Call: _e.mock.On("GetOrder", ctx, provider, orderNumber)}
 }
 
-func (_c *AccrualAdapter_GetOrder_Call) Run(run func(ctx context.Context, orderNumber string)) *AccrualAdapter_GetOrder_Call {
+func (_c *AccrualAdapter_GetOrder_Call) Run(run func(ctx context.Context, provider string, orderNumber string)) *AccrualAdapter_GetOrder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
@@ -76,7 +77,53 @@ func (_c *AccrualAdapter_GetOrder_Call) Return(_a0 *model.AccrualOrder, _a1 erro
 	return _c
 }
 
-func (_c *AccrualAdapter_GetOrder_Call) RunAndReturn(run func(context.Context, string) (*model.AccrualOrder, error)) *AccrualAdapter_GetOrder_Call {
+func (_c *AccrualAdapter_GetOrder_Call) RunAndReturn(run func(context.Context, string, string) (*model.AccrualOrder, error)) *AccrualAdapter_GetOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Route provides a mock function with given fields: orderNumber
+func (_m *AccrualAdapter) Route(orderNumber string) string {
+	ret := _m.Called(orderNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Route")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(orderNumber)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// AccrualAdapter_Route_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Route'
+type AccrualAdapter_Route_Call struct {
+	*mock.Call
+}
+
+// Route is a helper method to define mock.On call
+//   - orderNumber string
+func (_e *AccrualAdapter_Expecter) Route(orderNumber interface{}) *AccrualAdapter_Route_Call {
+	return &AccrualAdapter_Route_Call{Call: _e.mock.On("Route", orderNumber)}
+}
+
+func (_c *AccrualAdapter_Route_Call) Run(run func(orderNumber string)) *AccrualAdapter_Route_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *AccrualAdapter_Route_Call) Return(_a0 string) *AccrualAdapter_Route_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AccrualAdapter_Route_Call) RunAndReturn(run func(string) string) *AccrualAdapter_Route_Call {
 	_c.Call.Return(run)
 	return _c
 }
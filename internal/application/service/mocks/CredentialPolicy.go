@@ -0,0 +1,135 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// CredentialPolicy is an autogenerated mock type for the CredentialPolicy type
+type CredentialPolicy struct {
+	mock.Mock
+}
+
+type CredentialPolicy_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *CredentialPolicy) EXPECT() *CredentialPolicy_Expecter {
+	return &CredentialPolicy_Expecter{mock: &_m.Mock}
+}
+
+// Check provides a mock function with given fields: login, password
+func (_m *CredentialPolicy) Check(login string, password string) error {
+	ret := _m.Called(login, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(login, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialPolicy_Check_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Check'
+type CredentialPolicy_Check_Call struct {
+	*mock.Call
+}
+
+// Check is a helper method to define mock.On call
+//   - login string
+//   - password string
+func (_e *CredentialPolicy_Expecter) Check(login interface{}, password interface{}) *CredentialPolicy_Check_Call {
+	return &CredentialPolicy_Check_Call{Call: _e.mock.On("Check", login, password)}
+}
+
+func (_c *CredentialPolicy_Check_Call) Run(run func(login string, password string)) *CredentialPolicy_Check_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CredentialPolicy_Check_Call) Return(_a0 error) *CredentialPolicy_Check_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CredentialPolicy_Check_Call) RunAndReturn(run func(string, string) error) *CredentialPolicy_Check_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NormalizeLogin provides a mock function with given fields: login
+func (_m *CredentialPolicy) NormalizeLogin(login string) (string, string) {
+	ret := _m.Called(login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NormalizeLogin")
+	}
+
+	var r0 string
+	var r1 string
+	if rf, ok := ret.Get(0).(func(string) (string, string)); ok {
+		return rf(login)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(login)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(login)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	return r0, r1
+}
+
+// CredentialPolicy_NormalizeLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NormalizeLogin'
+type CredentialPolicy_NormalizeLogin_Call struct {
+	*mock.Call
+}
+
+// NormalizeLogin is a helper method to define mock.On call
+//   - login string
+func (_e *CredentialPolicy_Expecter) NormalizeLogin(login interface{}) *CredentialPolicy_NormalizeLogin_Call {
+	return &CredentialPolicy_NormalizeLogin_Call{Call: _e.mock.On("NormalizeLogin", login)}
+}
+
+func (_c *CredentialPolicy_NormalizeLogin_Call) Run(run func(login string)) *CredentialPolicy_NormalizeLogin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *CredentialPolicy_NormalizeLogin_Call) Return(normalized string, key string) *CredentialPolicy_NormalizeLogin_Call {
+	_c.Call.Return(normalized, key)
+	return _c
+}
+
+func (_c *CredentialPolicy_NormalizeLogin_Call) RunAndReturn(run func(string) (string, string)) *CredentialPolicy_NormalizeLogin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewCredentialPolicy creates a new instance of CredentialPolicy. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCredentialPolicy(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CredentialPolicy {
+	mock := &CredentialPolicy{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -78,6 +78,110 @@ func (_c *Hasher_Hash_Call) RunAndReturn(run func(context.Context, []byte) (stri
 	return _c
 }
 
+// NeedsRehash provides a mock function with given fields: hash
+func (_m *Hasher) NeedsRehash(hash string) bool {
+	ret := _m.Called(hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NeedsRehash")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(hash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Hasher_NeedsRehash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NeedsRehash'
+type Hasher_NeedsRehash_Call struct {
+	*mock.Call
+}
+
+// NeedsRehash is a helper method to define mock.On call
+//   - hash string
+func (_e *Hasher_Expecter) NeedsRehash(hash interface{}) *Hasher_NeedsRehash_Call {
+	return &Hasher_NeedsRehash_Call{Call: _e.mock.On("NeedsRehash", hash)}
+}
+
+func (_c *Hasher_NeedsRehash_Call) Run(run func(hash string)) *Hasher_NeedsRehash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Hasher_NeedsRehash_Call) Return(_a0 bool) *Hasher_NeedsRehash_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Hasher_NeedsRehash_Call) RunAndReturn(run func(string) bool) *Hasher_NeedsRehash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Verify provides a mock function with given fields: ctx, password, hash
+func (_m *Hasher) Verify(ctx context.Context, password []byte, hash string) (bool, error) {
+	ret := _m.Called(ctx, password, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Verify")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) (bool, error)); ok {
+		return rf(ctx, password, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) bool); ok {
+		r0 = rf(ctx, password, hash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, string) error); ok {
+		r1 = rf(ctx, password, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Hasher_Verify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Verify'
+type Hasher_Verify_Call struct {
+	*mock.Call
+}
+
+// Verify is a helper method to define mock.On call
+//   - ctx context.Context
+//   - password []byte
+//   - hash string
+func (_e *Hasher_Expecter) Verify(ctx interface{}, password interface{}, hash interface{}) *Hasher_Verify_Call {
+	return &Hasher_Verify_Call{Call: _e.mock.On("Verify", ctx, password, hash)}
+}
+
+func (_c *Hasher_Verify_Call) Run(run func(ctx context.Context, password []byte, hash string)) *Hasher_Verify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]byte), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Hasher_Verify_Call) Return(_a0 bool, _a1 error) *Hasher_Verify_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Hasher_Verify_Call) RunAndReturn(run func(context.Context, []byte, string) (bool, error)) *Hasher_Verify_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewHasher creates a new instance of Hasher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewHasher(t interface {
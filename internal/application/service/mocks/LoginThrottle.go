@@ -0,0 +1,181 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// LoginThrottle is an autogenerated mock type for the LoginThrottle type
+type LoginThrottle struct {
+	mock.Mock
+}
+
+type LoginThrottle_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *LoginThrottle) EXPECT() *LoginThrottle_Expecter {
+	return &LoginThrottle_Expecter{mock: &_m.Mock}
+}
+
+// Allow provides a mock function with given fields: key
+func (_m *LoginThrottle) Allow(key string) time.Duration {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Allow")
+	}
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(string) time.Duration); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
+
+// LoginThrottle_Allow_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Allow'
+type LoginThrottle_Allow_Call struct {
+	*mock.Call
+}
+
+// Allow is a helper method to define mock.On call
+//   - key string
+func (_e *LoginThrottle_Expecter) Allow(key interface{}) *LoginThrottle_Allow_Call {
+	return &LoginThrottle_Allow_Call{Call: _e.mock.On("Allow", key)}
+}
+
+func (_c *LoginThrottle_Allow_Call) Run(run func(key string)) *LoginThrottle_Allow_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LoginThrottle_Allow_Call) Return(_a0 time.Duration) *LoginThrottle_Allow_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LoginThrottle_Allow_Call) RunAndReturn(run func(string) time.Duration) *LoginThrottle_Allow_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Failure provides a mock function with given fields: key
+func (_m *LoginThrottle) Failure(key string) {
+	_m.Called(key)
+}
+
+// LoginThrottle_Failure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Failure'
+type LoginThrottle_Failure_Call struct {
+	*mock.Call
+}
+
+// Failure is a helper method to define mock.On call
+//   - key string
+func (_e *LoginThrottle_Expecter) Failure(key interface{}) *LoginThrottle_Failure_Call {
+	return &LoginThrottle_Failure_Call{Call: _e.mock.On("Failure", key)}
+}
+
+func (_c *LoginThrottle_Failure_Call) Run(run func(key string)) *LoginThrottle_Failure_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LoginThrottle_Failure_Call) Return() *LoginThrottle_Failure_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *LoginThrottle_Failure_Call) RunAndReturn(run func(string)) *LoginThrottle_Failure_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Release provides a mock function with given fields: key
+func (_m *LoginThrottle) Release(key string) {
+	_m.Called(key)
+}
+
+// LoginThrottle_Release_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Release'
+type LoginThrottle_Release_Call struct {
+	*mock.Call
+}
+
+// Release is a helper method to define mock.On call
+//   - key string
+func (_e *LoginThrottle_Expecter) Release(key interface{}) *LoginThrottle_Release_Call {
+	return &LoginThrottle_Release_Call{Call: _e.mock.On("Release", key)}
+}
+
+func (_c *LoginThrottle_Release_Call) Run(run func(key string)) *LoginThrottle_Release_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LoginThrottle_Release_Call) Return() *LoginThrottle_Release_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *LoginThrottle_Release_Call) RunAndReturn(run func(string)) *LoginThrottle_Release_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Success provides a mock function with given fields: key
+func (_m *LoginThrottle) Success(key string) {
+	_m.Called(key)
+}
+
+// LoginThrottle_Success_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Success'
+type LoginThrottle_Success_Call struct {
+	*mock.Call
+}
+
+// Success is a helper method to define mock.On call
+//   - key string
+func (_e *LoginThrottle_Expecter) Success(key interface{}) *LoginThrottle_Success_Call {
+	return &LoginThrottle_Success_Call{Call: _e.mock.On("Success", key)}
+}
+
+func (_c *LoginThrottle_Success_Call) Run(run func(key string)) *LoginThrottle_Success_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *LoginThrottle_Success_Call) Return() *LoginThrottle_Success_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *LoginThrottle_Success_Call) RunAndReturn(run func(string)) *LoginThrottle_Success_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewLoginThrottle creates a new instance of LoginThrottle. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLoginThrottle(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LoginThrottle {
+	mock := &LoginThrottle{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -26,12 +26,1499 @@ func (_m *Storage) EXPECT() *Storage_Expecter {
 	return &Storage_Expecter{mock: &_m.Mock}
 }
 
+// AdjustOrderDiscrepancy provides a mock function with given fields: ctx, dto
+func (_m *Storage) AdjustOrderDiscrepancy(ctx context.Context, dto *storage.AdjustOrderDiscrepancy) (*model.OrderDiscrepancy, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdjustOrderDiscrepancy")
+	}
+
+	var r0 *model.OrderDiscrepancy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.AdjustOrderDiscrepancy) (*model.OrderDiscrepancy, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.AdjustOrderDiscrepancy) *model.OrderDiscrepancy); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.OrderDiscrepancy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.AdjustOrderDiscrepancy) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_AdjustOrderDiscrepancy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdjustOrderDiscrepancy'
+type Storage_AdjustOrderDiscrepancy_Call struct {
+	*mock.Call
+}
+
+// AdjustOrderDiscrepancy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.AdjustOrderDiscrepancy
+func (_e *Storage_Expecter) AdjustOrderDiscrepancy(ctx interface{}, dto interface{}) *Storage_AdjustOrderDiscrepancy_Call {
+	return &Storage_AdjustOrderDiscrepancy_Call{Call: _e.mock.On("AdjustOrderDiscrepancy", ctx, dto)}
+}
+
+func (_c *Storage_AdjustOrderDiscrepancy_Call) Run(run func(ctx context.Context, dto *storage.AdjustOrderDiscrepancy)) *Storage_AdjustOrderDiscrepancy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.AdjustOrderDiscrepancy))
+	})
+	return _c
+}
+
+func (_c *Storage_AdjustOrderDiscrepancy_Call) Return(_a0 *model.OrderDiscrepancy, _a1 error) *Storage_AdjustOrderDiscrepancy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_AdjustOrderDiscrepancy_Call) RunAndReturn(run func(context.Context, *storage.AdjustOrderDiscrepancy) (*model.OrderDiscrepancy, error)) *Storage_AdjustOrderDiscrepancy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimDueOrders provides a mock function with given fields: ctx, dto
+func (_m *Storage) ClaimDueOrders(ctx context.Context, dto *storage.ClaimDueOrders) ([]*model.Order, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDueOrders")
+	}
+
+	var r0 []*model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.ClaimDueOrders) ([]*model.Order, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.ClaimDueOrders) []*model.Order); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.ClaimDueOrders) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_ClaimDueOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDueOrders'
+type Storage_ClaimDueOrders_Call struct {
+	*mock.Call
+}
+
+// ClaimDueOrders is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.ClaimDueOrders
+func (_e *Storage_Expecter) ClaimDueOrders(ctx interface{}, dto interface{}) *Storage_ClaimDueOrders_Call {
+	return &Storage_ClaimDueOrders_Call{Call: _e.mock.On("ClaimDueOrders", ctx, dto)}
+}
+
+func (_c *Storage_ClaimDueOrders_Call) Run(run func(ctx context.Context, dto *storage.ClaimDueOrders)) *Storage_ClaimDueOrders_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.ClaimDueOrders))
+	})
+	return _c
+}
+
+func (_c *Storage_ClaimDueOrders_Call) Return(_a0 []*model.Order, _a1 error) *Storage_ClaimDueOrders_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_ClaimDueOrders_Call) RunAndReturn(run func(context.Context, *storage.ClaimDueOrders) ([]*model.Order, error)) *Storage_ClaimDueOrders_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimOrdersForReconciliation provides a mock function with given fields: ctx, dto
+func (_m *Storage) ClaimOrdersForReconciliation(ctx context.Context, dto *storage.ClaimOrdersForReconciliation) ([]*model.Order, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimOrdersForReconciliation")
+	}
+
+	var r0 []*model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.ClaimOrdersForReconciliation) ([]*model.Order, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.ClaimOrdersForReconciliation) []*model.Order); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.ClaimOrdersForReconciliation) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_ClaimOrdersForReconciliation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimOrdersForReconciliation'
+type Storage_ClaimOrdersForReconciliation_Call struct {
+	*mock.Call
+}
+
+// ClaimOrdersForReconciliation is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.ClaimOrdersForReconciliation
+func (_e *Storage_Expecter) ClaimOrdersForReconciliation(ctx interface{}, dto interface{}) *Storage_ClaimOrdersForReconciliation_Call {
+	return &Storage_ClaimOrdersForReconciliation_Call{Call: _e.mock.On("ClaimOrdersForReconciliation", ctx, dto)}
+}
+
+func (_c *Storage_ClaimOrdersForReconciliation_Call) Run(run func(ctx context.Context, dto *storage.ClaimOrdersForReconciliation)) *Storage_ClaimOrdersForReconciliation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.ClaimOrdersForReconciliation))
+	})
+	return _c
+}
+
+func (_c *Storage_ClaimOrdersForReconciliation_Call) Return(_a0 []*model.Order, _a1 error) *Storage_ClaimOrdersForReconciliation_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_ClaimOrdersForReconciliation_Call) RunAndReturn(run func(context.Context, *storage.ClaimOrdersForReconciliation) ([]*model.Order, error)) *Storage_ClaimOrdersForReconciliation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeadLetterOrder provides a mock function with given fields: ctx, dto
+func (_m *Storage) DeadLetterOrder(ctx context.Context, dto *storage.DeadLetterOrder) (*model.OrderDeadLetter, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeadLetterOrder")
+	}
+
+	var r0 *model.OrderDeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.DeadLetterOrder) (*model.OrderDeadLetter, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.DeadLetterOrder) *model.OrderDeadLetter); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.OrderDeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.DeadLetterOrder) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_DeadLetterOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeadLetterOrder'
+type Storage_DeadLetterOrder_Call struct {
+	*mock.Call
+}
+
+// DeadLetterOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.DeadLetterOrder
+func (_e *Storage_Expecter) DeadLetterOrder(ctx interface{}, dto interface{}) *Storage_DeadLetterOrder_Call {
+	return &Storage_DeadLetterOrder_Call{Call: _e.mock.On("DeadLetterOrder", ctx, dto)}
+}
+
+func (_c *Storage_DeadLetterOrder_Call) Run(run func(ctx context.Context, dto *storage.DeadLetterOrder)) *Storage_DeadLetterOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.DeadLetterOrder))
+	})
+	return _c
+}
+
+func (_c *Storage_DeadLetterOrder_Call) Return(_a0 *model.OrderDeadLetter, _a1 error) *Storage_DeadLetterOrder_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_DeadLetterOrder_Call) RunAndReturn(run func(context.Context, *storage.DeadLetterOrder) (*model.OrderDeadLetter, error)) *Storage_DeadLetterOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteExpiredSigningKeys provides a mock function with given fields: ctx
+func (_m *Storage) DeleteExpiredSigningKeys(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredSigningKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Storage_DeleteExpiredSigningKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteExpiredSigningKeys'
+type Storage_DeleteExpiredSigningKeys_Call struct {
+	*mock.Call
+}
+
+// DeleteExpiredSigningKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Storage_Expecter) DeleteExpiredSigningKeys(ctx interface{}) *Storage_DeleteExpiredSigningKeys_Call {
+	return &Storage_DeleteExpiredSigningKeys_Call{Call: _e.mock.On("DeleteExpiredSigningKeys", ctx)}
+}
+
+func (_c *Storage_DeleteExpiredSigningKeys_Call) Run(run func(ctx context.Context)) *Storage_DeleteExpiredSigningKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Storage_DeleteExpiredSigningKeys_Call) Return(_a0 error) *Storage_DeleteExpiredSigningKeys_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_DeleteExpiredSigningKeys_Call) RunAndReturn(run func(context.Context) error) *Storage_DeleteExpiredSigningKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetOrderByNumber provides a mock function with given fields: ctx, number
 func (_m *Storage) GetOrderByNumber(ctx context.Context, number string) (*model.Order, error) {
 	ret := _m.Called(ctx, number)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOrderByNumber")
+		panic("no return value specified for GetOrderByNumber")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Order, error)); ok {
+		return rf(ctx, number)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Order); ok {
+		r0 = rf(ctx, number)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetOrderByNumber_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderByNumber'
+type Storage_GetOrderByNumber_Call struct {
+	*mock.Call
+}
+
+// GetOrderByNumber is a helper method to define mock.On call
+//   - ctx context.Context
+//   - number string
+func (_e *Storage_Expecter) GetOrderByNumber(ctx interface{}, number interface{}) *Storage_GetOrderByNumber_Call {
+	return &Storage_GetOrderByNumber_Call{Call: _e.mock.On("GetOrderByNumber", ctx, number)}
+}
+
+func (_c *Storage_GetOrderByNumber_Call) Run(run func(ctx context.Context, number string)) *Storage_GetOrderByNumber_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_GetOrderByNumber_Call) Return(_a0 *model.Order, _a1 error) *Storage_GetOrderByNumber_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetOrderByNumber_Call) RunAndReturn(run func(context.Context, string) (*model.Order, error)) *Storage_GetOrderByNumber_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOrderDiscrepancy provides a mock function with given fields: ctx, id
+func (_m *Storage) GetOrderDiscrepancy(ctx context.Context, id uuid.UUID) (*model.OrderDiscrepancy, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderDiscrepancy")
+	}
+
+	var r0 *model.OrderDiscrepancy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*model.OrderDiscrepancy, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *model.OrderDiscrepancy); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.OrderDiscrepancy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetOrderDiscrepancy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderDiscrepancy'
+type Storage_GetOrderDiscrepancy_Call struct {
+	*mock.Call
+}
+
+// GetOrderDiscrepancy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Storage_Expecter) GetOrderDiscrepancy(ctx interface{}, id interface{}) *Storage_GetOrderDiscrepancy_Call {
+	return &Storage_GetOrderDiscrepancy_Call{Call: _e.mock.On("GetOrderDiscrepancy", ctx, id)}
+}
+
+func (_c *Storage_GetOrderDiscrepancy_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Storage_GetOrderDiscrepancy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_GetOrderDiscrepancy_Call) Return(_a0 *model.OrderDiscrepancy, _a1 error) *Storage_GetOrderDiscrepancy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetOrderDiscrepancy_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*model.OrderDiscrepancy, error)) *Storage_GetOrderDiscrepancy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOrderEvents provides a mock function with given fields: ctx, orderID
+func (_m *Storage) GetOrderEvents(ctx context.Context, orderID uuid.UUID) ([]*model.OrderEvent, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderEvents")
+	}
+
+	var r0 []*model.OrderEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.OrderEvent, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.OrderEvent); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.OrderEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetOrderEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderEvents'
+type Storage_GetOrderEvents_Call struct {
+	*mock.Call
+}
+
+// GetOrderEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - orderID uuid.UUID
+func (_e *Storage_Expecter) GetOrderEvents(ctx interface{}, orderID interface{}) *Storage_GetOrderEvents_Call {
+	return &Storage_GetOrderEvents_Call{Call: _e.mock.On("GetOrderEvents", ctx, orderID)}
+}
+
+func (_c *Storage_GetOrderEvents_Call) Run(run func(ctx context.Context, orderID uuid.UUID)) *Storage_GetOrderEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_GetOrderEvents_Call) Return(_a0 []*model.OrderEvent, _a1 error) *Storage_GetOrderEvents_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetOrderEvents_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*model.OrderEvent, error)) *Storage_GetOrderEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSessionByTokenHash provides a mock function with given fields: ctx, tokenHash
+func (_m *Storage) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSessionByTokenHash")
+	}
+
+	var r0 *model.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Session, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Session); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetSessionByTokenHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSessionByTokenHash'
+type Storage_GetSessionByTokenHash_Call struct {
+	*mock.Call
+}
+
+// GetSessionByTokenHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+func (_e *Storage_Expecter) GetSessionByTokenHash(ctx interface{}, tokenHash interface{}) *Storage_GetSessionByTokenHash_Call {
+	return &Storage_GetSessionByTokenHash_Call{Call: _e.mock.On("GetSessionByTokenHash", ctx, tokenHash)}
+}
+
+func (_c *Storage_GetSessionByTokenHash_Call) Run(run func(ctx context.Context, tokenHash string)) *Storage_GetSessionByTokenHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_GetSessionByTokenHash_Call) Return(_a0 *model.Session, _a1 error) *Storage_GetSessionByTokenHash_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetSessionByTokenHash_Call) RunAndReturn(run func(context.Context, string) (*model.Session, error)) *Storage_GetSessionByTokenHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUser provides a mock function with given fields: ctx, id
+func (_m *Storage) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*model.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *model.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
+type Storage_GetUser_Call struct {
+	*mock.Call
+}
+
+// GetUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Storage_Expecter) GetUser(ctx interface{}, id interface{}) *Storage_GetUser_Call {
+	return &Storage_GetUser_Call{Call: _e.mock.On("GetUser", ctx, id)}
+}
+
+func (_c *Storage_GetUser_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Storage_GetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_GetUser_Call) Return(_a0 *model.User, _a1 error) *Storage_GetUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetUser_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*model.User, error)) *Storage_GetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByLogin provides a mock function with given fields: ctx, dto
+func (_m *Storage) GetUserByLogin(ctx context.Context, dto *storage.GetUserByLogin) (*model.User, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByLogin")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.GetUserByLogin) (*model.User, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.GetUserByLogin) *model.User); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.GetUserByLogin) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetUserByLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByLogin'
+type Storage_GetUserByLogin_Call struct {
+	*mock.Call
+}
+
+// GetUserByLogin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.GetUserByLogin
+func (_e *Storage_Expecter) GetUserByLogin(ctx interface{}, dto interface{}) *Storage_GetUserByLogin_Call {
+	return &Storage_GetUserByLogin_Call{Call: _e.mock.On("GetUserByLogin", ctx, dto)}
+}
+
+func (_c *Storage_GetUserByLogin_Call) Run(run func(ctx context.Context, dto *storage.GetUserByLogin)) *Storage_GetUserByLogin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.GetUserByLogin))
+	})
+	return _c
+}
+
+func (_c *Storage_GetUserByLogin_Call) Return(_a0 *model.User, _a1 error) *Storage_GetUserByLogin_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetUserByLogin_Call) RunAndReturn(run func(context.Context, *storage.GetUserByLogin) (*model.User, error)) *Storage_GetUserByLogin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserOrdersNewestFirst provides a mock function with given fields: ctx, userID
+func (_m *Storage) GetUserOrdersNewestFirst(ctx context.Context, userID uuid.UUID) ([]*model.Order, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserOrdersNewestFirst")
+	}
+
+	var r0 []*model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.Order, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.Order); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetUserOrdersNewestFirst_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserOrdersNewestFirst'
+type Storage_GetUserOrdersNewestFirst_Call struct {
+	*mock.Call
+}
+
+// GetUserOrdersNewestFirst is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *Storage_Expecter) GetUserOrdersNewestFirst(ctx interface{}, userID interface{}) *Storage_GetUserOrdersNewestFirst_Call {
+	return &Storage_GetUserOrdersNewestFirst_Call{Call: _e.mock.On("GetUserOrdersNewestFirst", ctx, userID)}
+}
+
+func (_c *Storage_GetUserOrdersNewestFirst_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_GetUserOrdersNewestFirst_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_GetUserOrdersNewestFirst_Call) Return(_a0 []*model.Order, _a1 error) *Storage_GetUserOrdersNewestFirst_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetUserOrdersNewestFirst_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*model.Order, error)) *Storage_GetUserOrdersNewestFirst_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserWithdrawalSum provides a mock function with given fields: ctx, userID
+func (_m *Storage) GetUserWithdrawalSum(ctx context.Context, userID uuid.UUID) (int32, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserWithdrawalSum")
+	}
+
+	var r0 int32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (int32, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) int32); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetUserWithdrawalSum_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserWithdrawalSum'
+type Storage_GetUserWithdrawalSum_Call struct {
+	*mock.Call
+}
+
+// GetUserWithdrawalSum is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *Storage_Expecter) GetUserWithdrawalSum(ctx interface{}, userID interface{}) *Storage_GetUserWithdrawalSum_Call {
+	return &Storage_GetUserWithdrawalSum_Call{Call: _e.mock.On("GetUserWithdrawalSum", ctx, userID)}
+}
+
+func (_c *Storage_GetUserWithdrawalSum_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_GetUserWithdrawalSum_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_GetUserWithdrawalSum_Call) Return(_a0 int32, _a1 error) *Storage_GetUserWithdrawalSum_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetUserWithdrawalSum_Call) RunAndReturn(run func(context.Context, uuid.UUID) (int32, error)) *Storage_GetUserWithdrawalSum_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserWithdrawals provides a mock function with given fields: ctx, userID
+func (_m *Storage) GetUserWithdrawals(ctx context.Context, userID uuid.UUID) ([]*model.WithdrawalOrder, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserWithdrawals")
+	}
+
+	var r0 []*model.WithdrawalOrder
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.WithdrawalOrder, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.WithdrawalOrder); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.WithdrawalOrder)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_GetUserWithdrawals_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserWithdrawals'
+type Storage_GetUserWithdrawals_Call struct {
+	*mock.Call
+}
+
+// GetUserWithdrawals is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *Storage_Expecter) GetUserWithdrawals(ctx interface{}, userID interface{}) *Storage_GetUserWithdrawals_Call {
+	return &Storage_GetUserWithdrawals_Call{Call: _e.mock.On("GetUserWithdrawals", ctx, userID)}
+}
+
+func (_c *Storage_GetUserWithdrawals_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_GetUserWithdrawals_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_GetUserWithdrawals_Call) Return(_a0 []*model.WithdrawalOrder, _a1 error) *Storage_GetUserWithdrawals_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_GetUserWithdrawals_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*model.WithdrawalOrder, error)) *Storage_GetUserWithdrawals_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IncrementUserBalance provides a mock function with given fields: ctx, dto
+func (_m *Storage) IncrementUserBalance(ctx context.Context, dto *storage.IncrementUserBalance) (*model.User, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementUserBalance")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.IncrementUserBalance) (*model.User, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.IncrementUserBalance) *model.User); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.IncrementUserBalance) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_IncrementUserBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementUserBalance'
+type Storage_IncrementUserBalance_Call struct {
+	*mock.Call
+}
+
+// IncrementUserBalance is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.IncrementUserBalance
+func (_e *Storage_Expecter) IncrementUserBalance(ctx interface{}, dto interface{}) *Storage_IncrementUserBalance_Call {
+	return &Storage_IncrementUserBalance_Call{Call: _e.mock.On("IncrementUserBalance", ctx, dto)}
+}
+
+func (_c *Storage_IncrementUserBalance_Call) Run(run func(ctx context.Context, dto *storage.IncrementUserBalance)) *Storage_IncrementUserBalance_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.IncrementUserBalance))
+	})
+	return _c
+}
+
+func (_c *Storage_IncrementUserBalance_Call) Return(_a0 *model.User, _a1 error) *Storage_IncrementUserBalance_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_IncrementUserBalance_Call) RunAndReturn(run func(context.Context, *storage.IncrementUserBalance) (*model.User, error)) *Storage_IncrementUserBalance_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InvalidateOrderDeadLetter provides a mock function with given fields: ctx, number
+func (_m *Storage) InvalidateOrderDeadLetter(ctx context.Context, number string) (*model.Order, error) {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InvalidateOrderDeadLetter")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.Order, error)); ok {
+		return rf(ctx, number)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Order); ok {
+		r0 = rf(ctx, number)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_InvalidateOrderDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InvalidateOrderDeadLetter'
+type Storage_InvalidateOrderDeadLetter_Call struct {
+	*mock.Call
+}
+
+// InvalidateOrderDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - number string
+func (_e *Storage_Expecter) InvalidateOrderDeadLetter(ctx interface{}, number interface{}) *Storage_InvalidateOrderDeadLetter_Call {
+	return &Storage_InvalidateOrderDeadLetter_Call{Call: _e.mock.On("InvalidateOrderDeadLetter", ctx, number)}
+}
+
+func (_c *Storage_InvalidateOrderDeadLetter_Call) Run(run func(ctx context.Context, number string)) *Storage_InvalidateOrderDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Storage_InvalidateOrderDeadLetter_Call) Return(_a0 *model.Order, _a1 error) *Storage_InvalidateOrderDeadLetter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_InvalidateOrderDeadLetter_Call) RunAndReturn(run func(context.Context, string) (*model.Order, error)) *Storage_InvalidateOrderDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsSessionFamilyActive provides a mock function with given fields: ctx, dto
+func (_m *Storage) IsSessionFamilyActive(ctx context.Context, dto *storage.IsSessionFamilyActive) (bool, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsSessionFamilyActive")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.IsSessionFamilyActive) (bool, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.IsSessionFamilyActive) bool); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.IsSessionFamilyActive) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_IsSessionFamilyActive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsSessionFamilyActive'
+type Storage_IsSessionFamilyActive_Call struct {
+	*mock.Call
+}
+
+// IsSessionFamilyActive is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.IsSessionFamilyActive
+func (_e *Storage_Expecter) IsSessionFamilyActive(ctx interface{}, dto interface{}) *Storage_IsSessionFamilyActive_Call {
+	return &Storage_IsSessionFamilyActive_Call{Call: _e.mock.On("IsSessionFamilyActive", ctx, dto)}
+}
+
+func (_c *Storage_IsSessionFamilyActive_Call) Run(run func(ctx context.Context, dto *storage.IsSessionFamilyActive)) *Storage_IsSessionFamilyActive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.IsSessionFamilyActive))
+	})
+	return _c
+}
+
+func (_c *Storage_IsSessionFamilyActive_Call) Return(_a0 bool, _a1 error) *Storage_IsSessionFamilyActive_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_IsSessionFamilyActive_Call) RunAndReturn(run func(context.Context, *storage.IsSessionFamilyActive) (bool, error)) *Storage_IsSessionFamilyActive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListOrderDeadLetters provides a mock function with given fields: ctx
+func (_m *Storage) ListOrderDeadLetters(ctx context.Context) ([]*model.OrderDeadLetter, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOrderDeadLetters")
+	}
+
+	var r0 []*model.OrderDeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*model.OrderDeadLetter, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*model.OrderDeadLetter); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.OrderDeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_ListOrderDeadLetters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListOrderDeadLetters'
+type Storage_ListOrderDeadLetters_Call struct {
+	*mock.Call
+}
+
+// ListOrderDeadLetters is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Storage_Expecter) ListOrderDeadLetters(ctx interface{}) *Storage_ListOrderDeadLetters_Call {
+	return &Storage_ListOrderDeadLetters_Call{Call: _e.mock.On("ListOrderDeadLetters", ctx)}
+}
+
+func (_c *Storage_ListOrderDeadLetters_Call) Run(run func(ctx context.Context)) *Storage_ListOrderDeadLetters_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Storage_ListOrderDeadLetters_Call) Return(_a0 []*model.OrderDeadLetter, _a1 error) *Storage_ListOrderDeadLetters_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_ListOrderDeadLetters_Call) RunAndReturn(run func(context.Context) ([]*model.OrderDeadLetter, error)) *Storage_ListOrderDeadLetters_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListOrderDiscrepancies provides a mock function with given fields: ctx
+func (_m *Storage) ListOrderDiscrepancies(ctx context.Context) ([]*model.OrderDiscrepancy, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOrderDiscrepancies")
+	}
+
+	var r0 []*model.OrderDiscrepancy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*model.OrderDiscrepancy, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*model.OrderDiscrepancy); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.OrderDiscrepancy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_ListOrderDiscrepancies_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListOrderDiscrepancies'
+type Storage_ListOrderDiscrepancies_Call struct {
+	*mock.Call
+}
+
+// ListOrderDiscrepancies is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Storage_Expecter) ListOrderDiscrepancies(ctx interface{}) *Storage_ListOrderDiscrepancies_Call {
+	return &Storage_ListOrderDiscrepancies_Call{Call: _e.mock.On("ListOrderDiscrepancies", ctx)}
+}
+
+func (_c *Storage_ListOrderDiscrepancies_Call) Run(run func(ctx context.Context)) *Storage_ListOrderDiscrepancies_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Storage_ListOrderDiscrepancies_Call) Return(_a0 []*model.OrderDiscrepancy, _a1 error) *Storage_ListOrderDiscrepancies_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_ListOrderDiscrepancies_Call) RunAndReturn(run func(context.Context) ([]*model.OrderDiscrepancy, error)) *Storage_ListOrderDiscrepancies_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSigningKeys provides a mock function with given fields: ctx
+func (_m *Storage) ListSigningKeys(ctx context.Context) ([]*model.SigningKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSigningKeys")
+	}
+
+	var r0 []*model.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*model.SigningKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*model.SigningKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.SigningKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_ListSigningKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSigningKeys'
+type Storage_ListSigningKeys_Call struct {
+	*mock.Call
+}
+
+// ListSigningKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Storage_Expecter) ListSigningKeys(ctx interface{}) *Storage_ListSigningKeys_Call {
+	return &Storage_ListSigningKeys_Call{Call: _e.mock.On("ListSigningKeys", ctx)}
+}
+
+func (_c *Storage_ListSigningKeys_Call) Run(run func(ctx context.Context)) *Storage_ListSigningKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Storage_ListSigningKeys_Call) Return(_a0 []*model.SigningKey, _a1 error) *Storage_ListSigningKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_ListSigningKeys_Call) RunAndReturn(run func(context.Context) ([]*model.SigningKey, error)) *Storage_ListSigningKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUserSessions provides a mock function with given fields: ctx, userID
+func (_m *Storage) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserSessions")
+	}
+
+	var r0 []*model.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.Session, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.Session); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_ListUserSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserSessions'
+type Storage_ListUserSessions_Call struct {
+	*mock.Call
+}
+
+// ListUserSessions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *Storage_Expecter) ListUserSessions(ctx interface{}, userID interface{}) *Storage_ListUserSessions_Call {
+	return &Storage_ListUserSessions_Call{Call: _e.mock.On("ListUserSessions", ctx, userID)}
+}
+
+func (_c *Storage_ListUserSessions_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_ListUserSessions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_ListUserSessions_Call) Return(_a0 []*model.Session, _a1 error) *Storage_ListUserSessions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_ListUserSessions_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*model.Session, error)) *Storage_ListUserSessions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkOrderReconciled provides a mock function with given fields: ctx, id
+func (_m *Storage) MarkOrderReconciled(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkOrderReconciled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Storage_MarkOrderReconciled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkOrderReconciled'
+type Storage_MarkOrderReconciled_Call struct {
+	*mock.Call
+}
+
+// MarkOrderReconciled is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Storage_Expecter) MarkOrderReconciled(ctx interface{}, id interface{}) *Storage_MarkOrderReconciled_Call {
+	return &Storage_MarkOrderReconciled_Call{Call: _e.mock.On("MarkOrderReconciled", ctx, id)}
+}
+
+func (_c *Storage_MarkOrderReconciled_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Storage_MarkOrderReconciled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_MarkOrderReconciled_Call) Return(_a0 error) *Storage_MarkOrderReconciled_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_MarkOrderReconciled_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *Storage_MarkOrderReconciled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PostponeOrderCheck provides a mock function with given fields: ctx, dto
+func (_m *Storage) PostponeOrderCheck(ctx context.Context, dto *storage.PostponeOrderCheck) (*model.Order, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostponeOrderCheck")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.PostponeOrderCheck) (*model.Order, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.PostponeOrderCheck) *model.Order); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.PostponeOrderCheck) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_PostponeOrderCheck_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PostponeOrderCheck'
+type Storage_PostponeOrderCheck_Call struct {
+	*mock.Call
+}
+
+// PostponeOrderCheck is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.PostponeOrderCheck
+func (_e *Storage_Expecter) PostponeOrderCheck(ctx interface{}, dto interface{}) *Storage_PostponeOrderCheck_Call {
+	return &Storage_PostponeOrderCheck_Call{Call: _e.mock.On("PostponeOrderCheck", ctx, dto)}
+}
+
+func (_c *Storage_PostponeOrderCheck_Call) Run(run func(ctx context.Context, dto *storage.PostponeOrderCheck)) *Storage_PostponeOrderCheck_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.PostponeOrderCheck))
+	})
+	return _c
+}
+
+func (_c *Storage_PostponeOrderCheck_Call) Return(_a0 *model.Order, _a1 error) *Storage_PostponeOrderCheck_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_PostponeOrderCheck_Call) RunAndReturn(run func(context.Context, *storage.PostponeOrderCheck) (*model.Order, error)) *Storage_PostponeOrderCheck_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordOrderAttempt provides a mock function with given fields: ctx, dto
+func (_m *Storage) RecordOrderAttempt(ctx context.Context, dto *storage.RecordOrderAttempt) (*model.Order, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordOrderAttempt")
+	}
+
+	var r0 *model.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.RecordOrderAttempt) (*model.Order, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.RecordOrderAttempt) *model.Order); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.RecordOrderAttempt) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_RecordOrderAttempt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordOrderAttempt'
+type Storage_RecordOrderAttempt_Call struct {
+	*mock.Call
+}
+
+// RecordOrderAttempt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.RecordOrderAttempt
+func (_e *Storage_Expecter) RecordOrderAttempt(ctx interface{}, dto interface{}) *Storage_RecordOrderAttempt_Call {
+	return &Storage_RecordOrderAttempt_Call{Call: _e.mock.On("RecordOrderAttempt", ctx, dto)}
+}
+
+func (_c *Storage_RecordOrderAttempt_Call) Run(run func(ctx context.Context, dto *storage.RecordOrderAttempt)) *Storage_RecordOrderAttempt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.RecordOrderAttempt))
+	})
+	return _c
+}
+
+func (_c *Storage_RecordOrderAttempt_Call) Return(_a0 *model.Order, _a1 error) *Storage_RecordOrderAttempt_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_RecordOrderAttempt_Call) RunAndReturn(run func(context.Context, *storage.RecordOrderAttempt) (*model.Order, error)) *Storage_RecordOrderAttempt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseOrder provides a mock function with given fields: ctx, id
+func (_m *Storage) ReleaseOrder(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Storage_ReleaseOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseOrder'
+type Storage_ReleaseOrder_Call struct {
+	*mock.Call
+}
+
+// ReleaseOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Storage_Expecter) ReleaseOrder(ctx interface{}, id interface{}) *Storage_ReleaseOrder_Call {
+	return &Storage_ReleaseOrder_Call{Call: _e.mock.On("ReleaseOrder", ctx, id)}
+}
+
+func (_c *Storage_ReleaseOrder_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Storage_ReleaseOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Storage_ReleaseOrder_Call) Return(_a0 error) *Storage_ReleaseOrder_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_ReleaseOrder_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *Storage_ReleaseOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetryAllOrderDeadLetters provides a mock function with given fields: ctx
+func (_m *Storage) RetryAllOrderDeadLetters(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryAllOrderDeadLetters")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Storage_RetryAllOrderDeadLetters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetryAllOrderDeadLetters'
+type Storage_RetryAllOrderDeadLetters_Call struct {
+	*mock.Call
+}
+
+// RetryAllOrderDeadLetters is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Storage_Expecter) RetryAllOrderDeadLetters(ctx interface{}) *Storage_RetryAllOrderDeadLetters_Call {
+	return &Storage_RetryAllOrderDeadLetters_Call{Call: _e.mock.On("RetryAllOrderDeadLetters", ctx)}
+}
+
+func (_c *Storage_RetryAllOrderDeadLetters_Call) Run(run func(ctx context.Context)) *Storage_RetryAllOrderDeadLetters_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Storage_RetryAllOrderDeadLetters_Call) Return(_a0 int64, _a1 error) *Storage_RetryAllOrderDeadLetters_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Storage_RetryAllOrderDeadLetters_Call) RunAndReturn(run func(context.Context) (int64, error)) *Storage_RetryAllOrderDeadLetters_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetryOrderDeadLetter provides a mock function with given fields: ctx, number
+func (_m *Storage) RetryOrderDeadLetter(ctx context.Context, number string) (*model.Order, error) {
+	ret := _m.Called(ctx, number)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryOrderDeadLetter")
 	}
 
 	var r0 *model.Order
@@ -56,176 +1543,152 @@ func (_m *Storage) GetOrderByNumber(ctx context.Context, number string) (*model.
 	return r0, r1
 }
 
-// Storage_GetOrderByNumber_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderByNumber'
-type Storage_GetOrderByNumber_Call struct {
+// Storage_RetryOrderDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetryOrderDeadLetter'
+type Storage_RetryOrderDeadLetter_Call struct {
 	*mock.Call
 }
 
-// GetOrderByNumber is a helper method to define mock.On call
+// RetryOrderDeadLetter is a helper method to define mock.On call
 //   - ctx context.Context
 //   - number string
-func (_e *Storage_Expecter) GetOrderByNumber(ctx interface{}, number interface{}) *Storage_GetOrderByNumber_Call {
-	return &Storage_GetOrderByNumber_Call{Call: _e.mock.On("GetOrderByNumber", ctx, number)}
+func (_e *Storage_Expecter) RetryOrderDeadLetter(ctx interface{}, number interface{}) *Storage_RetryOrderDeadLetter_Call {
+	return &Storage_RetryOrderDeadLetter_Call{Call: _e.mock.On("RetryOrderDeadLetter", ctx, number)}
 }
 
-func (_c *Storage_GetOrderByNumber_Call) Run(run func(ctx context.Context, number string)) *Storage_GetOrderByNumber_Call {
+func (_c *Storage_RetryOrderDeadLetter_Call) Run(run func(ctx context.Context, number string)) *Storage_RetryOrderDeadLetter_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *Storage_GetOrderByNumber_Call) Return(_a0 *model.Order, _a1 error) *Storage_GetOrderByNumber_Call {
+func (_c *Storage_RetryOrderDeadLetter_Call) Return(_a0 *model.Order, _a1 error) *Storage_RetryOrderDeadLetter_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_GetOrderByNumber_Call) RunAndReturn(run func(context.Context, string) (*model.Order, error)) *Storage_GetOrderByNumber_Call {
+func (_c *Storage_RetryOrderDeadLetter_Call) RunAndReturn(run func(context.Context, string) (*model.Order, error)) *Storage_RetryOrderDeadLetter_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUser provides a mock function with given fields: ctx, id
-func (_m *Storage) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
-	ret := _m.Called(ctx, id)
+// RevokeSession provides a mock function with given fields: ctx, dto
+func (_m *Storage) RevokeSession(ctx context.Context, dto *storage.RevokeSession) error {
+	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUser")
-	}
-
-	var r0 *model.User
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*model.User, error)); ok {
-		return rf(ctx, id)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *model.User); ok {
-		r0 = rf(ctx, id)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.User)
-		}
+		panic("no return value specified for RevokeSession")
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, id)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.RevokeSession) error); ok {
+		r0 = rf(ctx, dto)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// Storage_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
-type Storage_GetUser_Call struct {
+// Storage_RevokeSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeSession'
+type Storage_RevokeSession_Call struct {
 	*mock.Call
 }
 
-// GetUser is a helper method to define mock.On call
+// RevokeSession is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id uuid.UUID
-func (_e *Storage_Expecter) GetUser(ctx interface{}, id interface{}) *Storage_GetUser_Call {
-	return &Storage_GetUser_Call{Call: _e.mock.On("GetUser", ctx, id)}
+//   - dto *storage.RevokeSession
+func (_e *Storage_Expecter) RevokeSession(ctx interface{}, dto interface{}) *Storage_RevokeSession_Call {
+	return &Storage_RevokeSession_Call{Call: _e.mock.On("RevokeSession", ctx, dto)}
 }
 
-func (_c *Storage_GetUser_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Storage_GetUser_Call {
+func (_c *Storage_RevokeSession_Call) Run(run func(ctx context.Context, dto *storage.RevokeSession)) *Storage_RevokeSession_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(*storage.RevokeSession))
 	})
 	return _c
 }
 
-func (_c *Storage_GetUser_Call) Return(_a0 *model.User, _a1 error) *Storage_GetUser_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *Storage_RevokeSession_Call) Return(_a0 error) *Storage_RevokeSession_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Storage_GetUser_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*model.User, error)) *Storage_GetUser_Call {
+func (_c *Storage_RevokeSession_Call) RunAndReturn(run func(context.Context, *storage.RevokeSession) error) *Storage_RevokeSession_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserByLogin provides a mock function with given fields: ctx, login
-func (_m *Storage) GetUserByLogin(ctx context.Context, login string) (*model.User, error) {
-	ret := _m.Called(ctx, login)
+// RevokeUserSessions provides a mock function with given fields: ctx, userID
+func (_m *Storage) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByLogin")
-	}
-
-	var r0 *model.User
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
-		return rf(ctx, login)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
-		r0 = rf(ctx, login)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.User)
-		}
+		panic("no return value specified for RevokeUserSessions")
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, login)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// Storage_GetUserByLogin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByLogin'
-type Storage_GetUserByLogin_Call struct {
+// Storage_RevokeUserSessions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeUserSessions'
+type Storage_RevokeUserSessions_Call struct {
 	*mock.Call
 }
 
-// GetUserByLogin is a helper method to define mock.On call
+// RevokeUserSessions is a helper method to define mock.On call
 //   - ctx context.Context
-//   - login string
-func (_e *Storage_Expecter) GetUserByLogin(ctx interface{}, login interface{}) *Storage_GetUserByLogin_Call {
-	return &Storage_GetUserByLogin_Call{Call: _e.mock.On("GetUserByLogin", ctx, login)}
+//   - userID uuid.UUID
+func (_e *Storage_Expecter) RevokeUserSessions(ctx interface{}, userID interface{}) *Storage_RevokeUserSessions_Call {
+	return &Storage_RevokeUserSessions_Call{Call: _e.mock.On("RevokeUserSessions", ctx, userID)}
 }
 
-func (_c *Storage_GetUserByLogin_Call) Run(run func(ctx context.Context, login string)) *Storage_GetUserByLogin_Call {
+func (_c *Storage_RevokeUserSessions_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_RevokeUserSessions_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(uuid.UUID))
 	})
 	return _c
 }
 
-func (_c *Storage_GetUserByLogin_Call) Return(_a0 *model.User, _a1 error) *Storage_GetUserByLogin_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *Storage_RevokeUserSessions_Call) Return(_a0 error) *Storage_RevokeUserSessions_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Storage_GetUserByLogin_Call) RunAndReturn(run func(context.Context, string) (*model.User, error)) *Storage_GetUserByLogin_Call {
+func (_c *Storage_RevokeUserSessions_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *Storage_RevokeUserSessions_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserOrdersNewestFirst provides a mock function with given fields: ctx, userID
-func (_m *Storage) GetUserOrdersNewestFirst(ctx context.Context, userID uuid.UUID) ([]*model.Order, error) {
-	ret := _m.Called(ctx, userID)
+// RotateSession provides a mock function with given fields: ctx, dto
+func (_m *Storage) RotateSession(ctx context.Context, dto *storage.RotateSession) (*model.Session, error) {
+	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserOrdersNewestFirst")
+		panic("no return value specified for RotateSession")
 	}
 
-	var r0 []*model.Order
+	var r0 *model.Session
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.Order, error)); ok {
-		return rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.RotateSession) (*model.Session, error)); ok {
+		return rf(ctx, dto)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.Order); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.RotateSession) *model.Session); ok {
+		r0 = rf(ctx, dto)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*model.Order)
+			r0 = ret.Get(0).(*model.Session)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, *storage.RotateSession) error); ok {
+		r1 = rf(ctx, dto)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -233,56 +1696,58 @@ func (_m *Storage) GetUserOrdersNewestFirst(ctx context.Context, userID uuid.UUI
 	return r0, r1
 }
 
-// Storage_GetUserOrdersNewestFirst_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserOrdersNewestFirst'
-type Storage_GetUserOrdersNewestFirst_Call struct {
+// Storage_RotateSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RotateSession'
+type Storage_RotateSession_Call struct {
 	*mock.Call
 }
 
-// GetUserOrdersNewestFirst is a helper method to define mock.On call
+// RotateSession is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID uuid.UUID
-func (_e *Storage_Expecter) GetUserOrdersNewestFirst(ctx interface{}, userID interface{}) *Storage_GetUserOrdersNewestFirst_Call {
-	return &Storage_GetUserOrdersNewestFirst_Call{Call: _e.mock.On("GetUserOrdersNewestFirst", ctx, userID)}
+//   - dto *storage.RotateSession
+func (_e *Storage_Expecter) RotateSession(ctx interface{}, dto interface{}) *Storage_RotateSession_Call {
+	return &Storage_RotateSession_Call{Call: _e.mock.On("RotateSession", ctx, dto)}
 }
 
-func (_c *Storage_GetUserOrdersNewestFirst_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_GetUserOrdersNewestFirst_Call {
+func (_c *Storage_RotateSession_Call) Run(run func(ctx context.Context, dto *storage.RotateSession)) *Storage_RotateSession_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(*storage.RotateSession))
 	})
 	return _c
 }
 
-func (_c *Storage_GetUserOrdersNewestFirst_Call) Return(_a0 []*model.Order, _a1 error) *Storage_GetUserOrdersNewestFirst_Call {
+func (_c *Storage_RotateSession_Call) Return(_a0 *model.Session, _a1 error) *Storage_RotateSession_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_GetUserOrdersNewestFirst_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*model.Order, error)) *Storage_GetUserOrdersNewestFirst_Call {
+func (_c *Storage_RotateSession_Call) RunAndReturn(run func(context.Context, *storage.RotateSession) (*model.Session, error)) *Storage_RotateSession_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserWithdrawalSum provides a mock function with given fields: ctx, userID
-func (_m *Storage) GetUserWithdrawalSum(ctx context.Context, userID uuid.UUID) (int32, error) {
-	ret := _m.Called(ctx, userID)
+// SaveOrder provides a mock function with given fields: ctx, order
+func (_m *Storage) SaveOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	ret := _m.Called(ctx, order)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserWithdrawalSum")
+		panic("no return value specified for SaveOrder")
 	}
 
-	var r0 int32
+	var r0 *model.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (int32, error)); ok {
-		return rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Order) (*model.Order, error)); ok {
+		return rf(ctx, order)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) int32); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Order) *model.Order); ok {
+		r0 = rf(ctx, order)
 	} else {
-		r0 = ret.Get(0).(int32)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Order) error); ok {
+		r1 = rf(ctx, order)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -290,58 +1755,58 @@ func (_m *Storage) GetUserWithdrawalSum(ctx context.Context, userID uuid.UUID) (
 	return r0, r1
 }
 
-// Storage_GetUserWithdrawalSum_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserWithdrawalSum'
-type Storage_GetUserWithdrawalSum_Call struct {
+// Storage_SaveOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveOrder'
+type Storage_SaveOrder_Call struct {
 	*mock.Call
 }
 
-// GetUserWithdrawalSum is a helper method to define mock.On call
+// SaveOrder is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID uuid.UUID
-func (_e *Storage_Expecter) GetUserWithdrawalSum(ctx interface{}, userID interface{}) *Storage_GetUserWithdrawalSum_Call {
-	return &Storage_GetUserWithdrawalSum_Call{Call: _e.mock.On("GetUserWithdrawalSum", ctx, userID)}
+//   - order *model.Order
+func (_e *Storage_Expecter) SaveOrder(ctx interface{}, order interface{}) *Storage_SaveOrder_Call {
+	return &Storage_SaveOrder_Call{Call: _e.mock.On("SaveOrder", ctx, order)}
 }
 
-func (_c *Storage_GetUserWithdrawalSum_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_GetUserWithdrawalSum_Call {
+func (_c *Storage_SaveOrder_Call) Run(run func(ctx context.Context, order *model.Order)) *Storage_SaveOrder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(*model.Order))
 	})
 	return _c
 }
 
-func (_c *Storage_GetUserWithdrawalSum_Call) Return(_a0 int32, _a1 error) *Storage_GetUserWithdrawalSum_Call {
+func (_c *Storage_SaveOrder_Call) Return(_a0 *model.Order, _a1 error) *Storage_SaveOrder_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_GetUserWithdrawalSum_Call) RunAndReturn(run func(context.Context, uuid.UUID) (int32, error)) *Storage_GetUserWithdrawalSum_Call {
+func (_c *Storage_SaveOrder_Call) RunAndReturn(run func(context.Context, *model.Order) (*model.Order, error)) *Storage_SaveOrder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserWithdrawals provides a mock function with given fields: ctx, userID
-func (_m *Storage) GetUserWithdrawals(ctx context.Context, userID uuid.UUID) ([]*model.WithdrawalOrder, error) {
-	ret := _m.Called(ctx, userID)
+// SaveOrderDiscrepancy provides a mock function with given fields: ctx, discrepancy
+func (_m *Storage) SaveOrderDiscrepancy(ctx context.Context, discrepancy *model.OrderDiscrepancy) (*model.OrderDiscrepancy, error) {
+	ret := _m.Called(ctx, discrepancy)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserWithdrawals")
+		panic("no return value specified for SaveOrderDiscrepancy")
 	}
 
-	var r0 []*model.WithdrawalOrder
+	var r0 *model.OrderDiscrepancy
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]*model.WithdrawalOrder, error)); ok {
-		return rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.OrderDiscrepancy) (*model.OrderDiscrepancy, error)); ok {
+		return rf(ctx, discrepancy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []*model.WithdrawalOrder); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.OrderDiscrepancy) *model.OrderDiscrepancy); ok {
+		r0 = rf(ctx, discrepancy)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*model.WithdrawalOrder)
+			r0 = ret.Get(0).(*model.OrderDiscrepancy)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.OrderDiscrepancy) error); ok {
+		r1 = rf(ctx, discrepancy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -349,58 +1814,58 @@ func (_m *Storage) GetUserWithdrawals(ctx context.Context, userID uuid.UUID) ([]
 	return r0, r1
 }
 
-// Storage_GetUserWithdrawals_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserWithdrawals'
-type Storage_GetUserWithdrawals_Call struct {
+// Storage_SaveOrderDiscrepancy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveOrderDiscrepancy'
+type Storage_SaveOrderDiscrepancy_Call struct {
 	*mock.Call
 }
 
-// GetUserWithdrawals is a helper method to define mock.On call
+// SaveOrderDiscrepancy is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID uuid.UUID
-func (_e *Storage_Expecter) GetUserWithdrawals(ctx interface{}, userID interface{}) *Storage_GetUserWithdrawals_Call {
-	return &Storage_GetUserWithdrawals_Call{Call: _e.mock.On("GetUserWithdrawals", ctx, userID)}
+//   - discrepancy *model.OrderDiscrepancy
+func (_e *Storage_Expecter) SaveOrderDiscrepancy(ctx interface{}, discrepancy interface{}) *Storage_SaveOrderDiscrepancy_Call {
+	return &Storage_SaveOrderDiscrepancy_Call{Call: _e.mock.On("SaveOrderDiscrepancy", ctx, discrepancy)}
 }
 
-func (_c *Storage_GetUserWithdrawals_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *Storage_GetUserWithdrawals_Call {
+func (_c *Storage_SaveOrderDiscrepancy_Call) Run(run func(ctx context.Context, discrepancy *model.OrderDiscrepancy)) *Storage_SaveOrderDiscrepancy_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(*model.OrderDiscrepancy))
 	})
 	return _c
 }
 
-func (_c *Storage_GetUserWithdrawals_Call) Return(_a0 []*model.WithdrawalOrder, _a1 error) *Storage_GetUserWithdrawals_Call {
+func (_c *Storage_SaveOrderDiscrepancy_Call) Return(_a0 *model.OrderDiscrepancy, _a1 error) *Storage_SaveOrderDiscrepancy_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_GetUserWithdrawals_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]*model.WithdrawalOrder, error)) *Storage_GetUserWithdrawals_Call {
+func (_c *Storage_SaveOrderDiscrepancy_Call) RunAndReturn(run func(context.Context, *model.OrderDiscrepancy) (*model.OrderDiscrepancy, error)) *Storage_SaveOrderDiscrepancy_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// IncrementUserBalance provides a mock function with given fields: ctx, dto
-func (_m *Storage) IncrementUserBalance(ctx context.Context, dto *storage.IncrementUserBalance) (*model.User, error) {
-	ret := _m.Called(ctx, dto)
+// SaveSession provides a mock function with given fields: ctx, session
+func (_m *Storage) SaveSession(ctx context.Context, session *model.Session) (*model.Session, error) {
+	ret := _m.Called(ctx, session)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IncrementUserBalance")
+		panic("no return value specified for SaveSession")
 	}
 
-	var r0 *model.User
+	var r0 *model.Session
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *storage.IncrementUserBalance) (*model.User, error)); ok {
-		return rf(ctx, dto)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Session) (*model.Session, error)); ok {
+		return rf(ctx, session)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *storage.IncrementUserBalance) *model.User); ok {
-		r0 = rf(ctx, dto)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Session) *model.Session); ok {
+		r0 = rf(ctx, session)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.User)
+			r0 = ret.Get(0).(*model.Session)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *storage.IncrementUserBalance) error); ok {
-		r1 = rf(ctx, dto)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Session) error); ok {
+		r1 = rf(ctx, session)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -408,58 +1873,58 @@ func (_m *Storage) IncrementUserBalance(ctx context.Context, dto *storage.Increm
 	return r0, r1
 }
 
-// Storage_IncrementUserBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementUserBalance'
-type Storage_IncrementUserBalance_Call struct {
+// Storage_SaveSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSession'
+type Storage_SaveSession_Call struct {
 	*mock.Call
 }
 
-// IncrementUserBalance is a helper method to define mock.On call
+// SaveSession is a helper method to define mock.On call
 //   - ctx context.Context
-//   - dto *storage.IncrementUserBalance
-func (_e *Storage_Expecter) IncrementUserBalance(ctx interface{}, dto interface{}) *Storage_IncrementUserBalance_Call {
-	return &Storage_IncrementUserBalance_Call{Call: _e.mock.On("IncrementUserBalance", ctx, dto)}
+//   - session *model.Session
+func (_e *Storage_Expecter) SaveSession(ctx interface{}, session interface{}) *Storage_SaveSession_Call {
+	return &Storage_SaveSession_Call{Call: _e.mock.On("SaveSession", ctx, session)}
 }
 
-func (_c *Storage_IncrementUserBalance_Call) Run(run func(ctx context.Context, dto *storage.IncrementUserBalance)) *Storage_IncrementUserBalance_Call {
+func (_c *Storage_SaveSession_Call) Run(run func(ctx context.Context, session *model.Session)) *Storage_SaveSession_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*storage.IncrementUserBalance))
+		run(args[0].(context.Context), args[1].(*model.Session))
 	})
 	return _c
 }
 
-func (_c *Storage_IncrementUserBalance_Call) Return(_a0 *model.User, _a1 error) *Storage_IncrementUserBalance_Call {
+func (_c *Storage_SaveSession_Call) Return(_a0 *model.Session, _a1 error) *Storage_SaveSession_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_IncrementUserBalance_Call) RunAndReturn(run func(context.Context, *storage.IncrementUserBalance) (*model.User, error)) *Storage_IncrementUserBalance_Call {
+func (_c *Storage_SaveSession_Call) RunAndReturn(run func(context.Context, *model.Session) (*model.Session, error)) *Storage_SaveSession_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SaveOrder provides a mock function with given fields: ctx, order
-func (_m *Storage) SaveOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
-	ret := _m.Called(ctx, order)
+// SaveSigningKey provides a mock function with given fields: ctx, key
+func (_m *Storage) SaveSigningKey(ctx context.Context, key *model.SigningKey) (*model.SigningKey, error) {
+	ret := _m.Called(ctx, key)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveOrder")
+		panic("no return value specified for SaveSigningKey")
 	}
 
-	var r0 *model.Order
+	var r0 *model.SigningKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *model.Order) (*model.Order, error)); ok {
-		return rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SigningKey) (*model.SigningKey, error)); ok {
+		return rf(ctx, key)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *model.Order) *model.Order); ok {
-		r0 = rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.SigningKey) *model.SigningKey); ok {
+		r0 = rf(ctx, key)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Order)
+			r0 = ret.Get(0).(*model.SigningKey)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *model.Order) error); ok {
-		r1 = rf(ctx, order)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.SigningKey) error); ok {
+		r1 = rf(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -467,31 +1932,31 @@ func (_m *Storage) SaveOrder(ctx context.Context, order *model.Order) (*model.Or
 	return r0, r1
 }
 
-// Storage_SaveOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveOrder'
-type Storage_SaveOrder_Call struct {
+// Storage_SaveSigningKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSigningKey'
+type Storage_SaveSigningKey_Call struct {
 	*mock.Call
 }
 
-// SaveOrder is a helper method to define mock.On call
+// SaveSigningKey is a helper method to define mock.On call
 //   - ctx context.Context
-//   - order *model.Order
-func (_e *Storage_Expecter) SaveOrder(ctx interface{}, order interface{}) *Storage_SaveOrder_Call {
-	return &Storage_SaveOrder_Call{Call: _e.mock.On("SaveOrder", ctx, order)}
+//   - key *model.SigningKey
+func (_e *Storage_Expecter) SaveSigningKey(ctx interface{}, key interface{}) *Storage_SaveSigningKey_Call {
+	return &Storage_SaveSigningKey_Call{Call: _e.mock.On("SaveSigningKey", ctx, key)}
 }
 
-func (_c *Storage_SaveOrder_Call) Run(run func(ctx context.Context, order *model.Order)) *Storage_SaveOrder_Call {
+func (_c *Storage_SaveSigningKey_Call) Run(run func(ctx context.Context, key *model.SigningKey)) *Storage_SaveSigningKey_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(*model.Order))
+		run(args[0].(context.Context), args[1].(*model.SigningKey))
 	})
 	return _c
 }
 
-func (_c *Storage_SaveOrder_Call) Return(_a0 *model.Order, _a1 error) *Storage_SaveOrder_Call {
+func (_c *Storage_SaveSigningKey_Call) Return(_a0 *model.SigningKey, _a1 error) *Storage_SaveSigningKey_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Storage_SaveOrder_Call) RunAndReturn(run func(context.Context, *model.Order) (*model.Order, error)) *Storage_SaveOrder_Call {
+func (_c *Storage_SaveSigningKey_Call) RunAndReturn(run func(context.Context, *model.SigningKey) (*model.SigningKey, error)) *Storage_SaveSigningKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -673,6 +2138,53 @@ func (_c *Storage_SetOrderStatusAndAccrual_Call) RunAndReturn(run func(context.C
 	return _c
 }
 
+// SetUserPassword provides a mock function with given fields: ctx, dto
+func (_m *Storage) SetUserPassword(ctx context.Context, dto *storage.SetUserPassword) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *storage.SetUserPassword) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Storage_SetUserPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserPassword'
+type Storage_SetUserPassword_Call struct {
+	*mock.Call
+}
+
+// SetUserPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dto *storage.SetUserPassword
+func (_e *Storage_Expecter) SetUserPassword(ctx interface{}, dto interface{}) *Storage_SetUserPassword_Call {
+	return &Storage_SetUserPassword_Call{Call: _e.mock.On("SetUserPassword", ctx, dto)}
+}
+
+func (_c *Storage_SetUserPassword_Call) Run(run func(ctx context.Context, dto *storage.SetUserPassword)) *Storage_SetUserPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*storage.SetUserPassword))
+	})
+	return _c
+}
+
+func (_c *Storage_SetUserPassword_Call) Return(_a0 error) *Storage_SetUserPassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Storage_SetUserPassword_Call) RunAndReturn(run func(context.Context, *storage.SetUserPassword) error) *Storage_SetUserPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // WithdrawUserBonuses provides a mock function with given fields: ctx, dto
 func (_m *Storage) WithdrawUserBonuses(ctx context.Context, dto *storage.WithdrawUserBonuses) (*model.User, error) {
 	ret := _m.Called(ctx, dto)
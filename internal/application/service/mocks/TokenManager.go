@@ -3,8 +3,11 @@
 package mocks
 
 import (
+	model "github.com/dtroode/gophermart/internal/application/model"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -21,9 +24,64 @@ func (_m *TokenManager) EXPECT() *TokenManager_Expecter {
 	return &TokenManager_Expecter{mock: &_m.Mock}
 }
 
-// CreateToken provides a mock function with given fields: userID
-func (_m *TokenManager) CreateToken(userID uuid.UUID) (string, error) {
-	ret := _m.Called(userID)
+// CreateRefreshToken provides a mock function with no fields
+func (_m *TokenManager) CreateRefreshToken() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRefreshToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenManager_CreateRefreshToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRefreshToken'
+type TokenManager_CreateRefreshToken_Call struct {
+	*mock.Call
+}
+
+// CreateRefreshToken is a helper method to define mock.On call
+func (_e *TokenManager_Expecter) CreateRefreshToken() *TokenManager_CreateRefreshToken_Call {
+	return &TokenManager_CreateRefreshToken_Call{Call: _e.mock.On("CreateRefreshToken")}
+}
+
+func (_c *TokenManager_CreateRefreshToken_Call) Run(run func()) *TokenManager_CreateRefreshToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *TokenManager_CreateRefreshToken_Call) Return(_a0 string, _a1 error) *TokenManager_CreateRefreshToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenManager_CreateRefreshToken_Call) RunAndReturn(run func() (string, error)) *TokenManager_CreateRefreshToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateToken provides a mock function with given fields: userID, sessionID
+func (_m *TokenManager) CreateToken(userID uuid.UUID, sessionID uuid.UUID) (string, error) {
+	ret := _m.Called(userID, sessionID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateToken")
@@ -31,17 +89,17 @@ func (_m *TokenManager) CreateToken(userID uuid.UUID) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) (string, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) (string, error)); ok {
+		return rf(userID, sessionID)
 	}
-	if rf, ok := ret.Get(0).(func(uuid.UUID) string); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) string); ok {
+		r0 = rf(userID, sessionID)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(userID, sessionID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -56,13 +114,14 @@ type TokenManager_CreateToken_Call struct {
 
 // CreateToken is a helper method to define mock.On call
 //   - userID uuid.UUID
-func (_e *TokenManager_Expecter) CreateToken(userID interface{}) *TokenManager_CreateToken_Call {
-	return &TokenManager_CreateToken_Call{Call: _e.mock.On("CreateToken", userID)}
+//   - sessionID uuid.UUID
+func (_e *TokenManager_Expecter) CreateToken(userID interface{}, sessionID interface{}) *TokenManager_CreateToken_Call {
+	return &TokenManager_CreateToken_Call{Call: _e.mock.On("CreateToken", userID, sessionID)}
 }
 
-func (_c *TokenManager_CreateToken_Call) Run(run func(userID uuid.UUID)) *TokenManager_CreateToken_Call {
+func (_c *TokenManager_CreateToken_Call) Run(run func(userID uuid.UUID, sessionID uuid.UUID)) *TokenManager_CreateToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID))
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
 	})
 	return _c
 }
@@ -72,7 +131,112 @@ func (_c *TokenManager_CreateToken_Call) Return(_a0 string, _a1 error) *TokenMan
 	return _c
 }
 
-func (_c *TokenManager_CreateToken_Call) RunAndReturn(run func(uuid.UUID) (string, error)) *TokenManager_CreateToken_Call {
+func (_c *TokenManager_CreateToken_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) (string, error)) *TokenManager_CreateToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GenerateSigningKey provides a mock function with given fields: activeAt, retireAt
+func (_m *TokenManager) GenerateSigningKey(activeAt time.Time, retireAt time.Time) (*model.SigningKey, error) {
+	ret := _m.Called(activeAt, retireAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateSigningKey")
+	}
+
+	var r0 *model.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time) (*model.SigningKey, error)); ok {
+		return rf(activeAt, retireAt)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time) *model.SigningKey); ok {
+		r0 = rf(activeAt, retireAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SigningKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time) error); ok {
+		r1 = rf(activeAt, retireAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenManager_GenerateSigningKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GenerateSigningKey'
+type TokenManager_GenerateSigningKey_Call struct {
+	*mock.Call
+}
+
+// GenerateSigningKey is a helper method to define mock.On call
+//   - activeAt time.Time
+//   - retireAt time.Time
+func (_e *TokenManager_Expecter) GenerateSigningKey(activeAt interface{}, retireAt interface{}) *TokenManager_GenerateSigningKey_Call {
+	return &TokenManager_GenerateSigningKey_Call{Call: _e.mock.On("GenerateSigningKey", activeAt, retireAt)}
+}
+
+func (_c *TokenManager_GenerateSigningKey_Call) Run(run func(activeAt time.Time, retireAt time.Time)) *TokenManager_GenerateSigningKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *TokenManager_GenerateSigningKey_Call) Return(_a0 *model.SigningKey, _a1 error) *TokenManager_GenerateSigningKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenManager_GenerateSigningKey_Call) RunAndReturn(run func(time.Time, time.Time) (*model.SigningKey, error)) *TokenManager_GenerateSigningKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetSigningKeys provides a mock function with given fields: keys
+func (_m *TokenManager) SetSigningKeys(keys []*model.SigningKey) error {
+	ret := _m.Called(keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSigningKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]*model.SigningKey) error); ok {
+		r0 = rf(keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TokenManager_SetSigningKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetSigningKeys'
+type TokenManager_SetSigningKeys_Call struct {
+	*mock.Call
+}
+
+// SetSigningKeys is a helper method to define mock.On call
+//   - keys []*model.SigningKey
+func (_e *TokenManager_Expecter) SetSigningKeys(keys interface{}) *TokenManager_SetSigningKeys_Call {
+	return &TokenManager_SetSigningKeys_Call{Call: _e.mock.On("SetSigningKeys", keys)}
+}
+
+func (_c *TokenManager_SetSigningKeys_Call) Run(run func(keys []*model.SigningKey)) *TokenManager_SetSigningKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]*model.SigningKey))
+	})
+	return _c
+}
+
+func (_c *TokenManager_SetSigningKeys_Call) Return(_a0 error) *TokenManager_SetSigningKeys_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TokenManager_SetSigningKeys_Call) RunAndReturn(run func([]*model.SigningKey) error) *TokenManager_SetSigningKeys_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -25,53 +25,178 @@ func (_m *WorkerPool) EXPECT() *WorkerPool_Expecter {
 	return &WorkerPool_Expecter{mock: &_m.Mock}
 }
 
-// Submit provides a mock function with given fields: ctx, timeout, fn, expectResult
-func (_m *WorkerPool) Submit(ctx context.Context, timeout time.Duration, fn func(context.Context) (any, error), expectResult bool) chan *workerpool.Result {
-	ret := _m.Called(ctx, timeout, fn, expectResult)
+// Resize provides a mock function with given fields: size
+func (_m *WorkerPool) Resize(size int) error {
+	ret := _m.Called(size)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Submit")
+		panic("no return value specified for Resize")
 	}
 
-	var r0 chan *workerpool.Result
-	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, func(context.Context) (any, error), bool) chan *workerpool.Result); ok {
-		r0 = rf(ctx, timeout, fn, expectResult)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(size)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *workerpool.Result)
-		}
+		r0 = ret.Error(0)
 	}
 
 	return r0
 }
 
-// WorkerPool_Submit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Submit'
-type WorkerPool_Submit_Call struct {
+// WorkerPool_Resize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resize'
+type WorkerPool_Resize_Call struct {
 	*mock.Call
 }
 
-// Submit is a helper method to define mock.On call
+// Resize is a helper method to define mock.On call
+//   - size int
+func (_e *WorkerPool_Expecter) Resize(size interface{}) *WorkerPool_Resize_Call {
+	return &WorkerPool_Resize_Call{Call: _e.mock.On("Resize", size)}
+}
+
+func (_c *WorkerPool_Resize_Call) Run(run func(size int)) *WorkerPool_Resize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *WorkerPool_Resize_Call) Return(_a0 error) *WorkerPool_Resize_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WorkerPool_Resize_Call) RunAndReturn(run func(int) error) *WorkerPool_Resize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stats provides a mock function with no fields
+func (_m *WorkerPool) Stats() workerpool.Stats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 workerpool.Stats
+	if rf, ok := ret.Get(0).(func() workerpool.Stats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(workerpool.Stats)
+	}
+
+	return r0
+}
+
+// WorkerPool_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type WorkerPool_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+func (_e *WorkerPool_Expecter) Stats() *WorkerPool_Stats_Call {
+	return &WorkerPool_Stats_Call{Call: _e.mock.On("Stats")}
+}
+
+func (_c *WorkerPool_Stats_Call) Run(run func()) *WorkerPool_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *WorkerPool_Stats_Call) Return(_a0 workerpool.Stats) *WorkerPool_Stats_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WorkerPool_Stats_Call) RunAndReturn(run func() workerpool.Stats) *WorkerPool_Stats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SubmitFunc provides a mock function with given fields: ctx, timeout, fn, done
+func (_m *WorkerPool) SubmitFunc(ctx context.Context, timeout time.Duration, fn func(context.Context) (any, error), done func(*workerpool.Result)) {
+	_m.Called(ctx, timeout, fn, done)
+}
+
+// WorkerPool_SubmitFunc_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SubmitFunc'
+type WorkerPool_SubmitFunc_Call struct {
+	*mock.Call
+}
+
+// SubmitFunc is a helper method to define mock.On call
+//   - ctx context.Context
+//   - timeout time.Duration
+//   - fn func(context.Context)(any , error)
+//   - done func(*workerpool.Result)
+func (_e *WorkerPool_Expecter) SubmitFunc(ctx interface{}, timeout interface{}, fn interface{}, done interface{}) *WorkerPool_SubmitFunc_Call {
+	return &WorkerPool_SubmitFunc_Call{Call: _e.mock.On("SubmitFunc", ctx, timeout, fn, done)}
+}
+
+func (_c *WorkerPool_SubmitFunc_Call) Run(run func(ctx context.Context, timeout time.Duration, fn func(context.Context) (any, error), done func(*workerpool.Result))) *WorkerPool_SubmitFunc_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Duration), args[2].(func(context.Context) (any, error)), args[3].(func(*workerpool.Result)))
+	})
+	return _c
+}
+
+func (_c *WorkerPool_SubmitFunc_Call) Return() *WorkerPool_SubmitFunc_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *WorkerPool_SubmitFunc_Call) RunAndReturn(run func(context.Context, time.Duration, func(context.Context) (any, error), func(*workerpool.Result))) *WorkerPool_SubmitFunc_Call {
+	_c.Run(run)
+	return _c
+}
+
+// TrySubmitFunc provides a mock function with given fields: ctx, timeout, fn, done
+func (_m *WorkerPool) TrySubmitFunc(ctx context.Context, timeout time.Duration, fn func(context.Context) (any, error), done func(*workerpool.Result)) error {
+	ret := _m.Called(ctx, timeout, fn, done)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TrySubmitFunc")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, func(context.Context) (any, error), func(*workerpool.Result)) error); ok {
+		r0 = rf(ctx, timeout, fn, done)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WorkerPool_TrySubmitFunc_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TrySubmitFunc'
+type WorkerPool_TrySubmitFunc_Call struct {
+	*mock.Call
+}
+
+// TrySubmitFunc is a helper method to define mock.On call
 //   - ctx context.Context
 //   - timeout time.Duration
 //   - fn func(context.Context)(any , error)
-//   - expectResult bool
-func (_e *WorkerPool_Expecter) Submit(ctx interface{}, timeout interface{}, fn interface{}, expectResult interface{}) *WorkerPool_Submit_Call {
-	return &WorkerPool_Submit_Call{Call: _e.mock.On("Submit", ctx, timeout, fn, expectResult)}
+//   - done func(*workerpool.Result)
+func (_e *WorkerPool_Expecter) TrySubmitFunc(ctx interface{}, timeout interface{}, fn interface{}, done interface{}) *WorkerPool_TrySubmitFunc_Call {
+	return &WorkerPool_TrySubmitFunc_Call{Call: _e.mock.On("TrySubmitFunc", ctx, timeout, fn, done)}
 }
 
-func (_c *WorkerPool_Submit_Call) Run(run func(ctx context.Context, timeout time.Duration, fn func(context.Context) (any, error), expectResult bool)) *WorkerPool_Submit_Call {
+func (_c *WorkerPool_TrySubmitFunc_Call) Run(run func(ctx context.Context, timeout time.Duration, fn func(context.Context) (any, error), done func(*workerpool.Result))) *WorkerPool_TrySubmitFunc_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(time.Duration), args[2].(func(context.Context) (any, error)), args[3].(bool))
+		run(args[0].(context.Context), args[1].(time.Duration), args[2].(func(context.Context) (any, error)), args[3].(func(*workerpool.Result)))
 	})
 	return _c
 }
 
-func (_c *WorkerPool_Submit_Call) Return(_a0 chan *workerpool.Result) *WorkerPool_Submit_Call {
+func (_c *WorkerPool_TrySubmitFunc_Call) Return(_a0 error) *WorkerPool_TrySubmitFunc_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *WorkerPool_Submit_Call) RunAndReturn(run func(context.Context, time.Duration, func(context.Context) (any, error), bool) chan *workerpool.Result) *WorkerPool_Submit_Call {
+func (_c *WorkerPool_TrySubmitFunc_Call) RunAndReturn(run func(context.Context, time.Duration, func(context.Context) (any, error), func(*workerpool.Result)) error) *WorkerPool_TrySubmitFunc_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/dtroode/gophermart/internal/workerpool"
+)
+
+// autoAdjustReason is saved with corrections made without an administrator.
+const autoAdjustReason = "automatic reconciliation"
+
+// ReconcileOrders asks accrual system again about a batch of recently finalized orders
+// and records the ones that don't match it anymore. Each order is reconciled at most
+// once per reconcile period, so repeated calls move on to the next orders.
+// Orders are leased while they are compared, the ones that failed to be compared
+// are claimed again after the lease.
+func (s *Service) ReconcileOrders(ctx context.Context) (*model.ReconciliationReport, error) {
+	// orders claimed after cancellation would wait for the lease without being compared
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	orders, err := s.storage.ClaimOrdersForReconciliation(ctx, &storage.ClaimOrdersForReconciliation{
+		BatchSize:        int32(s.reconcileBatchSize),
+		FinalizedAfter:   now.Add(-s.reconcileWindow),
+		ReconciledBefore: now.Add(-s.reconcilePeriod),
+		LeaseUntil:       now.Add(s.orderLease),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim orders for reconciliation: %w", err)
+	}
+
+	futures := make([]*workerpool.Future[*model.OrderDiscrepancy], len(orders))
+	for i, order := range orders {
+		futures[i] = workerpool.Submit(s.pool, orderJobContext(order, reconcileOrderJobKind), orderCheckTimeout, s.reconcileOrderJob(order))
+	}
+
+	report := &model.ReconciliationReport{}
+
+	var errs []error
+	for i, future := range futures {
+		discrepancy, err := future.Await(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", orders[i].Number, err))
+			continue
+		}
+		report.Checked++
+		if discrepancy != nil {
+			report.Discrepancies = append(report.Discrepancies, discrepancy)
+		}
+	}
+	if len(errs) > 0 {
+		return report, fmt.Errorf("failed to reconcile orders: %w", errors.Join(errs...))
+	}
+
+	return report, nil
+}
+
+// reconcileOrder compares the order with accrual system and returns discrepancy
+// if they don't match, or nil otherwise.
+func (s *Service) reconcileOrder(ctx context.Context, order *model.Order) (*model.OrderDiscrepancy, error) {
+	accrualOrder, err := s.accrualAdapter.GetOrder(ctx, order.AccrualProvider, order.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order from accrual system: %w", err)
+	}
+
+	var expectedAccrual int32
+	if accrualOrder.Status == model.AccrualOrderStatusProcessed {
+		expectedAccrual = accrualCents(accrualOrder.Accrual)
+	}
+	if string(order.Status) == string(accrualOrder.Status) && order.Accrual == expectedAccrual {
+		return nil, s.markOrderReconciled(ctx, order)
+	}
+
+	discrepancy, err := s.storage.SaveOrderDiscrepancy(ctx, &model.OrderDiscrepancy{
+		OrderID:         order.ID,
+		OrderNumber:     order.Number,
+		Status:          order.Status,
+		Accrual:         order.Accrual,
+		ExpectedStatus:  accrualOrder.Status,
+		ExpectedAccrual: expectedAccrual,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save order discrepancy: %w", err)
+	}
+	if err := s.markOrderReconciled(ctx, order); err != nil {
+		return nil, err
+	}
+
+	if !s.autoAdjust {
+		return discrepancy, nil
+	}
+	if _, ok := discrepancy.CorrectedStatus(); !ok {
+		// the order is still processed by accrual system, it's up to an administrator
+		return discrepancy, nil
+	}
+
+	return s.adjustOrderDiscrepancy(ctx, discrepancy, autoAdjustReason)
+}
+
+// markOrderReconciled records that the order is compared, so it is not reconciled again for the period.
+func (s *Service) markOrderReconciled(ctx context.Context, order *model.Order) error {
+	if err := s.storage.MarkOrderReconciled(ctx, order.ID); err != nil {
+		return fmt.Errorf("failed to mark order reconciled: %w", err)
+	}
+
+	return nil
+}
+
+// adjustOrderDiscrepancy corrects the order to match accrual system.
+func (s *Service) adjustOrderDiscrepancy(ctx context.Context, discrepancy *model.OrderDiscrepancy, reason string) (*model.OrderDiscrepancy, error) {
+	status, ok := discrepancy.CorrectedStatus()
+	if !ok {
+		return nil, application.ErrUnprocessable
+	}
+
+	adjusted, err := s.storage.AdjustOrderDiscrepancy(ctx, &storage.AdjustOrderDiscrepancy{
+		ID:     discrepancy.ID,
+		Status: status,
+		Reason: reason,
+	})
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			// adjusted concurrently
+			return nil, application.ErrConflict
+		}
+		if errors.Is(err, application.ErrNotEnoughBonuses) {
+			return nil, application.ErrNotEnoughBonuses
+		}
+		return nil, fmt.Errorf("failed to adjust order discrepancy: %w", err)
+	}
+
+	return adjusted, nil
+}
+
+func (s *Service) reconcileOrderJob(order *model.Order) func(ctx context.Context) (*model.OrderDiscrepancy, error) {
+	return func(ctx context.Context) (*model.OrderDiscrepancy, error) {
+		return s.reconcileOrder(ctx, order)
+	}
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/service/mocks"
+	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/dtroode/gophermart/internal/workerpool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_ReconcileOrders(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobCtx := func(number string) any {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			key, _ := workerpool.GetKeyFromContext(ctx)
+			idempotencyKey, _ := workerpool.GetIdempotencyKeyFromContext(ctx)
+			return key == userID.String() && idempotencyKey == "reconcile/"+number
+		})
+	}
+
+	claim := mock.MatchedBy(func(dto *storage.ClaimOrdersForReconciliation) bool {
+		return dto.BatchSize == 10 &&
+			dto.FinalizedAfter.Before(time.Now().Add(-47*time.Hour)) &&
+			dto.ReconciledBefore.Before(time.Now().Add(-time.Hour+time.Minute)) &&
+			dto.LeaseUntil.After(time.Now())
+	})
+	// result completes the submitted job with res
+	result := func(res *workerpool.Result) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			args.Get(3).(func(*workerpool.Result))(res)
+		}
+	}
+	discrepancy := &model.OrderDiscrepancy{OrderNumber: "4561261212345467"}
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		poolMock     *mocks.WorkerPool
+		expectedResp *model.ReconciliationReport
+		expectedErr  error
+	}{
+		"failed to claim orders": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimOrdersForReconciliation", ctx, claim).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to claim orders for reconciliation: %w", errors.New("storage error")),
+		},
+		"no orders": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimOrdersForReconciliation", ctx, claim).Once().Return([]*model.Order{}, nil)
+				return mock
+			}(),
+			poolMock:     mocks.NewWorkerPool(t),
+			expectedResp: &model.ReconciliationReport{},
+		},
+		"failed to reconcile order": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimOrdersForReconciliation", ctx, claim).Once().Return([]*model.Order{
+					{ID: uuid.New(), UserID: userID, Number: "66465778752", Status: model.OrderStatusProcessed},
+					{ID: uuid.New(), UserID: userID, Number: "4561261212345467", Status: model.OrderStatusProcessed},
+				}, nil)
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				poolMock.On("SubmitFunc", jobCtx("66465778752"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Err: errors.New("accrual error")}))
+				poolMock.On("SubmitFunc", jobCtx("4561261212345467"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Value: discrepancy}))
+				return poolMock
+			}(),
+			// the order that failed is not counted, it is claimed again after the lease
+			expectedResp: &model.ReconciliationReport{
+				Checked:       1,
+				Discrepancies: []*model.OrderDiscrepancy{discrepancy},
+			},
+			expectedErr: fmt.Errorf("failed to reconcile orders: %w", errors.Join(fmt.Errorf("order 66465778752: %w", errors.New("accrual error")))),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimOrdersForReconciliation", ctx, claim).Once().Return([]*model.Order{
+					{ID: uuid.New(), UserID: userID, Number: "66465778752", Status: model.OrderStatusProcessed},
+					{ID: uuid.New(), UserID: userID, Number: "4561261212345467", Status: model.OrderStatusProcessed},
+				}, nil)
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				// the order matches accrual system
+				poolMock.On("SubmitFunc", jobCtx("66465778752"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{}))
+				poolMock.On("SubmitFunc", jobCtx("4561261212345467"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Value: discrepancy}))
+				return poolMock
+			}(),
+			expectedResp: &model.ReconciliationReport{
+				Checked:       2,
+				Discrepancies: []*model.OrderDiscrepancy{discrepancy},
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := NewService(tt.storageMock, nil, nil, nil, tt.poolMock,
+				WithReconcileBatchSize(10),
+				WithReconcileWindow(48*time.Hour),
+				WithReconcilePeriod(time.Hour),
+			)
+
+			resp, err := s.ReconcileOrders(ctx)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+
+	t.Run("context is done", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		s := NewService(mocks.NewStorage(t), nil, nil, nil, mocks.NewWorkerPool(t))
+
+		resp, err := s.ReconcileOrders(canceled)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestService_reconcileOrderJob(t *testing.T) {
+	orderID := uuid.New()
+	discrepancyID := uuid.New()
+	orderNumber := "1234"
+
+	order := &model.Order{
+		ID:              orderID,
+		Number:          orderNumber,
+		Status:          model.OrderStatusProcessed,
+		Accrual:         5000,
+		AccrualProvider: "partner",
+	}
+	found := &model.OrderDiscrepancy{
+		ID:              discrepancyID,
+		OrderID:         orderID,
+		OrderNumber:     orderNumber,
+		Status:          model.OrderStatusProcessed,
+		Accrual:         5000,
+		ExpectedStatus:  model.AccrualOrderStatusProcessed,
+		ExpectedAccrual: 7550,
+	}
+	adjusted := &model.OrderDiscrepancy{
+		ID:              discrepancyID,
+		OrderID:         orderID,
+		OrderNumber:     orderNumber,
+		Status:          model.OrderStatusProcessed,
+		Accrual:         5000,
+		ExpectedStatus:  model.AccrualOrderStatusProcessed,
+		ExpectedAccrual: 7550,
+		AdjustedAt:      time.Now(),
+		Adjustment:      2550,
+		Reason:          autoAdjustReason,
+	}
+	processed := func(accrual float32) *mocks.AccrualAdapter {
+		accrualMock := mocks.NewAccrualAdapter(t)
+		accrualMock.On("GetOrder", mock.Anything, "partner", orderNumber).Once().
+			Return(&model.AccrualOrder{Number: orderNumber, Status: model.AccrualOrderStatusProcessed, Accrual: accrual}, nil)
+		return accrualMock
+	}
+	markReconciled := func(storageMock *mocks.Storage) {
+		storageMock.On("MarkOrderReconciled", mock.Anything, orderID).Once().Return(nil)
+	}
+	saveFound := func(storageMock *mocks.Storage) {
+		storageMock.On("SaveOrderDiscrepancy", mock.Anything, &model.OrderDiscrepancy{
+			OrderID:         orderID,
+			OrderNumber:     orderNumber,
+			Status:          model.OrderStatusProcessed,
+			Accrual:         5000,
+			ExpectedStatus:  model.AccrualOrderStatusProcessed,
+			ExpectedAccrual: 7550,
+		}).Once().Return(found, nil)
+		markReconciled(storageMock)
+	}
+
+	tests := map[string]struct {
+		opts         []Option
+		accrualMock  *mocks.AccrualAdapter
+		storageMock  *mocks.Storage
+		expectedResp *model.OrderDiscrepancy
+		expectedErr  error
+	}{
+		"order matches": {
+			accrualMock: processed(50),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				markReconciled(storageMock)
+				return storageMock
+			}(),
+		},
+		"failed to mark order reconciled": {
+			accrualMock: processed(50),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("MarkOrderReconciled", mock.Anything, orderID).Once().Return(errors.New("storage error"))
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to mark order reconciled: %w", errors.New("storage error")),
+		},
+		"failed to get order": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrualMock := mocks.NewAccrualAdapter(t)
+				accrualMock.On("GetOrder", mock.Anything, "partner", orderNumber).Once().
+					Return(nil, application.ErrAccrualOrderNotRegistered)
+				return accrualMock
+			}(),
+			// the order is not marked reconciled, so it is claimed again after the lease
+			storageMock: mocks.NewStorage(t),
+			expectedErr: fmt.Errorf("failed to get order from accrual system: %w", application.ErrAccrualOrderNotRegistered),
+		},
+		"accrual changed": {
+			accrualMock: processed(75.5),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				saveFound(storageMock)
+				return storageMock
+			}(),
+			expectedResp: found,
+		},
+		"order became invalid": {
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrualMock := mocks.NewAccrualAdapter(t)
+				accrualMock.On("GetOrder", mock.Anything, "partner", orderNumber).Once().
+					Return(&model.AccrualOrder{Number: orderNumber, Status: model.AccrualOrderStatusInvalid}, nil)
+				return accrualMock
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("SaveOrderDiscrepancy", mock.Anything, &model.OrderDiscrepancy{
+					OrderID:        orderID,
+					OrderNumber:    orderNumber,
+					Status:         model.OrderStatusProcessed,
+					Accrual:        5000,
+					ExpectedStatus: model.AccrualOrderStatusInvalid,
+				}).Once().Return(&model.OrderDiscrepancy{ID: discrepancyID}, nil)
+				markReconciled(storageMock)
+				return storageMock
+			}(),
+			expectedResp: &model.OrderDiscrepancy{ID: discrepancyID},
+		},
+		"failed to save discrepancy": {
+			accrualMock: processed(75.5),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("SaveOrderDiscrepancy", mock.Anything, mock.Anything).Once().Return(nil, errors.New("storage error"))
+				return storageMock
+			}(),
+			expectedErr: fmt.Errorf("failed to save order discrepancy: %w", errors.New("storage error")),
+		},
+		"auto adjust": {
+			opts:        []Option{WithAutoAdjust()},
+			accrualMock: processed(75.5),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				saveFound(storageMock)
+				storageMock.On("AdjustOrderDiscrepancy", mock.Anything, &storage.AdjustOrderDiscrepancy{
+					ID:     discrepancyID,
+					Status: model.OrderStatusProcessed,
+					Reason: autoAdjustReason,
+				}).Once().Return(adjusted, nil)
+				return storageMock
+			}(),
+			expectedResp: adjusted,
+		},
+		"auto adjust skips order processed by accrual system": {
+			opts: []Option{WithAutoAdjust()},
+			accrualMock: func() *mocks.AccrualAdapter {
+				accrualMock := mocks.NewAccrualAdapter(t)
+				accrualMock.On("GetOrder", mock.Anything, "partner", orderNumber).Once().
+					Return(&model.AccrualOrder{Number: orderNumber, Status: model.AccrualOrderStatusProcessing}, nil)
+				return accrualMock
+			}(),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				storageMock.On("SaveOrderDiscrepancy", mock.Anything, mock.Anything).Once().
+					Return(&model.OrderDiscrepancy{ID: discrepancyID, ExpectedStatus: model.AccrualOrderStatusProcessing}, nil)
+				markReconciled(storageMock)
+				return storageMock
+			}(),
+			expectedResp: &model.OrderDiscrepancy{ID: discrepancyID, ExpectedStatus: model.AccrualOrderStatusProcessing},
+		},
+		"auto adjust failed": {
+			opts:        []Option{WithAutoAdjust()},
+			accrualMock: processed(75.5),
+			storageMock: func() *mocks.Storage {
+				storageMock := mocks.NewStorage(t)
+				saveFound(storageMock)
+				storageMock.On("AdjustOrderDiscrepancy", mock.Anything, mock.Anything).Once().Return(nil, application.ErrNotEnoughBonuses)
+				return storageMock
+			}(),
+			expectedErr: application.ErrNotEnoughBonuses,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewService(tt.storageMock, nil, nil, tt.accrualMock, nil, tt.opts...)
+
+			res, err := s.reconcileOrderJob(order)(context.Background())
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, res)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"sync"
 	"time"
@@ -13,14 +14,16 @@ import (
 	"github.com/dtroode/gophermart/internal/application/request"
 	"github.com/dtroode/gophermart/internal/application/response"
 	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/dtroode/gophermart/internal/logger"
 	"github.com/dtroode/gophermart/internal/workerpool"
 	"github.com/google/uuid"
 )
 
 type Storage interface {
 	GetUser(ctx context.Context, id uuid.UUID) (*model.User, error)
-	GetUserByLogin(ctx context.Context, login string) (*model.User, error)
+	GetUserByLogin(ctx context.Context, dto *storage.GetUserByLogin) (*model.User, error)
 	SaveUser(ctx context.Context, user *model.User) (*model.User, error)
+	SetUserPassword(ctx context.Context, dto *storage.SetUserPassword) error
 	WithdrawUserBonuses(ctx context.Context, dto *storage.WithdrawUserBonuses) (*model.User, error)
 	GetOrderByNumber(ctx context.Context, number string) (*model.Order, error)
 	SaveOrder(ctx context.Context, order *model.Order) (*model.Order, error)
@@ -30,22 +33,238 @@ type Storage interface {
 	GetUserWithdrawalSum(ctx context.Context, userID uuid.UUID) (int32, error)
 	GetUserWithdrawals(ctx context.Context, userID uuid.UUID) ([]*model.WithdrawalOrder, error)
 	GetUserOrdersNewestFirst(ctx context.Context, userID uuid.UUID) ([]*model.Order, error)
+	GetOrderEvents(ctx context.Context, orderID uuid.UUID) ([]*model.OrderEvent, error)
+	RecordOrderAttempt(ctx context.Context, dto *storage.RecordOrderAttempt) (*model.Order, error)
+	PostponeOrderCheck(ctx context.Context, dto *storage.PostponeOrderCheck) (*model.Order, error)
+	ReleaseOrder(ctx context.Context, id uuid.UUID) error
+	ClaimDueOrders(ctx context.Context, dto *storage.ClaimDueOrders) ([]*model.Order, error)
+	DeadLetterOrder(ctx context.Context, dto *storage.DeadLetterOrder) (*model.OrderDeadLetter, error)
+	ListOrderDeadLetters(ctx context.Context) ([]*model.OrderDeadLetter, error)
+	RetryOrderDeadLetter(ctx context.Context, number string) (*model.Order, error)
+	RetryAllOrderDeadLetters(ctx context.Context) (int64, error)
+	InvalidateOrderDeadLetter(ctx context.Context, number string) (*model.Order, error)
+	ClaimOrdersForReconciliation(ctx context.Context, dto *storage.ClaimOrdersForReconciliation) ([]*model.Order, error)
+	MarkOrderReconciled(ctx context.Context, id uuid.UUID) error
+	SaveOrderDiscrepancy(ctx context.Context, discrepancy *model.OrderDiscrepancy) (*model.OrderDiscrepancy, error)
+	ListOrderDiscrepancies(ctx context.Context) ([]*model.OrderDiscrepancy, error)
+	GetOrderDiscrepancy(ctx context.Context, id uuid.UUID) (*model.OrderDiscrepancy, error)
+	AdjustOrderDiscrepancy(ctx context.Context, dto *storage.AdjustOrderDiscrepancy) (*model.OrderDiscrepancy, error)
+	SaveSession(ctx context.Context, session *model.Session) (*model.Session, error)
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	RotateSession(ctx context.Context, dto *storage.RotateSession) (*model.Session, error)
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*model.Session, error)
+	IsSessionFamilyActive(ctx context.Context, dto *storage.IsSessionFamilyActive) (bool, error)
+	RevokeSession(ctx context.Context, dto *storage.RevokeSession) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+	SaveSigningKey(ctx context.Context, key *model.SigningKey) (*model.SigningKey, error)
+	ListSigningKeys(ctx context.Context) ([]*model.SigningKey, error)
+	DeleteExpiredSigningKeys(ctx context.Context) error
 }
 
+// Hasher hashes passwords. NeedsRehash reports hashes made with outdated parameters,
+// they are replaced at the next successful login.
 type Hasher interface {
 	Hash(ctx context.Context, password []byte) (string, error)
+	Verify(ctx context.Context, password []byte, hash string) (bool, error)
+	NeedsRehash(hash string) bool
 }
 
+// LoginThrottle slows down guessing passwords. Allow returns for how long attempts
+// with the key must wait, or reserves the attempt. Failure and Success record results
+// of reserved attempts, Release forgets the reserved attempt keeping failures.
+type LoginThrottle interface {
+	Allow(key string) time.Duration
+	Failure(key string)
+	Success(key string)
+	Release(key string)
+}
+
+// CredentialPolicy checks credentials of new users. NormalizeLogin returns login in the form
+// it is stored and the key logins are unique by, Check returns PolicyError naming broken rules.
+type CredentialPolicy interface {
+	NormalizeLogin(login string) (normalized, key string)
+	Check(login, password string) error
+}
+
+// TokenManager issues short-lived access tokens and opaque refresh tokens exchanged for them.
+// Access tokens are signed with keys kept in storage, so every instance signs and verifies
+// with the same keys: the service generates them on schedule and loads them with SetSigningKeys.
 type TokenManager interface {
-	CreateToken(userID uuid.UUID) (string, error)
+	CreateToken(userID, sessionID uuid.UUID) (string, error)
+	CreateRefreshToken() (string, error)
+	GenerateSigningKey(activeAt, retireAt time.Time) (*model.SigningKey, error)
+	SetSigningKeys(keys []*model.SigningKey) error
 }
 
+// AccrualAdapter reaches accrual systems. Route picks the provider for a new order,
+// which is then passed to every GetOrder of the order.
 type AccrualAdapter interface {
-	GetOrder(ctx context.Context, orderNumber string) (*model.AccrualOrder, error)
+	Route(orderNumber string) string
+	GetOrder(ctx context.Context, provider, orderNumber string) (*model.AccrualOrder, error)
 }
 
 type WorkerPool interface {
-	Submit(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (any, error), expectResult bool) chan *workerpool.Result
+	SubmitFunc(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (any, error), done func(*workerpool.Result))
+	TrySubmitFunc(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (any, error), done func(*workerpool.Result)) error
+	Resize(size int) error
+	Stats() workerpool.Stats
+}
+
+const (
+	orderCheckTimeout = 30 * time.Second
+	orderStoreTimeout = 5 * time.Second
+
+	defaultPollBatchSize      = 100
+	defaultOrderLease         = 1 * time.Minute
+	defaultOrderRetryInterval = 1 * time.Second
+
+	defaultReconcileBatchSize = 100
+	defaultReconcileWindow    = 7 * 24 * time.Hour
+	defaultReconcilePeriod    = 24 * time.Hour
+
+	defaultRefreshTokenTTL      = 30 * 24 * time.Hour
+	defaultSessionCheckInterval = 10 * time.Second
+
+	defaultSigningKeyRotation    = 24 * time.Hour
+	defaultSigningKeyPublishLead = 1 * time.Hour
+)
+
+type Option func(*Service)
+
+// WithPollBatchSize sets the number of orders claimed by one PollOrders call.
+func WithPollBatchSize(size int) Option {
+	return func(s *Service) {
+		s.pollBatchSize = size
+	}
+}
+
+// WithOrderLease sets for how long a claimed order is hidden from other pollers
+// and reconcilers. It should be longer than the time needed to check a batch of orders.
+func WithOrderLease(lease time.Duration) Option {
+	return func(s *Service) {
+		s.orderLease = lease
+	}
+}
+
+// WithOrderRetryInterval sets the delay between checks of an order that is not final yet.
+func WithOrderRetryInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.orderRetryInterval = interval
+	}
+}
+
+// WithMaxOrderAttempts sets the number of failed checks in a row after which
+// an order is moved to dead letters. Zero means no limit.
+func WithMaxOrderAttempts(attempts int) Option {
+	return func(s *Service) {
+		s.maxOrderAttempts = attempts
+	}
+}
+
+// WithPushFallback switches the service to push mode, where accrual system notifies
+// about order updates. Orders are polled only if no update came within fallback.
+func WithPushFallback(fallback time.Duration) Option {
+	return func(s *Service) {
+		s.pushFallback = fallback
+	}
+}
+
+// WithReconcileBatchSize sets the number of orders checked by one ReconcileOrders call.
+func WithReconcileBatchSize(size int) Option {
+	return func(s *Service) {
+		s.reconcileBatchSize = size
+	}
+}
+
+// WithReconcileWindow sets for how long after finalization orders are reconciled.
+func WithReconcileWindow(window time.Duration) Option {
+	return func(s *Service) {
+		s.reconcileWindow = window
+	}
+}
+
+// WithReconcilePeriod sets how often the same order is reconciled.
+func WithReconcilePeriod(period time.Duration) Option {
+	return func(s *Service) {
+		s.reconcilePeriod = period
+	}
+}
+
+// WithAutoAdjust makes reconciliation correct orders that don't match accrual system,
+// instead of waiting for an administrator.
+func WithAutoAdjust() Option {
+	return func(s *Service) {
+		s.autoAdjust = true
+	}
+}
+
+// WithRefreshTokenTTL sets for how long a refresh token can be exchanged for new tokens.
+func WithRefreshTokenTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.refreshTokenTTL = ttl
+	}
+}
+
+// WithSessionCheckInterval sets for how long a session family found active is not checked again.
+// A family revoked on another instance keeps its access tokens working till then, zero checks every request.
+func WithSessionCheckInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.sessionCheckInterval = interval
+	}
+}
+
+// WithSigningKeyRotation sets for how long a key signs access tokens before the next one replaces it.
+func WithSigningKeyRotation(rotation time.Duration) Option {
+	return func(s *Service) {
+		s.signingKeyRotation = rotation
+	}
+}
+
+// WithSigningKeyPublishLead sets for how long the next key is published before it signs tokens.
+// It should be longer than verifiers cache the key set and than the interval of RotateSigningKeys calls.
+func WithSigningKeyPublishLead(lead time.Duration) Option {
+	return func(s *Service) {
+		s.signingKeyPublishLead = lead
+	}
+}
+
+// WithLoginThrottle throttles failed logins per login from all clients, including logins
+// that don't exist. Anyone can fail logins of others, so it should only slow down, not lock out.
+func WithLoginThrottle(throttle LoginThrottle) Option {
+	return func(s *Service) {
+		s.loginThrottle = throttle
+	}
+}
+
+// WithLoginClientThrottle throttles failed logins per login from one client address,
+// it can lock the login out for the client without locking out the owner.
+func WithLoginClientThrottle(throttle LoginThrottle) Option {
+	return func(s *Service) {
+		s.loginClientThrottle = throttle
+	}
+}
+
+// WithClientThrottle throttles failed logins per client address. Successful login
+// doesn't reset it, so a client can't guess passwords of others between logins to own account.
+func WithClientThrottle(throttle LoginThrottle) Option {
+	return func(s *Service) {
+		s.clientThrottle = throttle
+	}
+}
+
+// WithCredentialPolicy checks credentials of new users and compares logins by key
+// the policy makes, otherwise logins are taken as they are.
+func WithCredentialPolicy(policy CredentialPolicy) Option {
+	return func(s *Service) {
+		s.credentialPolicy = policy
+	}
+}
+
+// WithLogger sets logger reporting errors that don't fail the request, like failed password rehash.
+func WithLogger(l *logger.Logger) Option {
+	return func(s *Service) {
+		s.logger = l
+	}
 }
 
 type Service struct {
@@ -55,6 +274,38 @@ type Service struct {
 	accrualAdapter AccrualAdapter
 	pool           WorkerPool
 	sync.Mutex
+
+	pollBatchSize      int
+	orderLease         time.Duration
+	orderRetryInterval time.Duration
+	maxOrderAttempts   int
+	pushFallback       time.Duration
+
+	reconcileBatchSize int
+	reconcileWindow    time.Duration
+	reconcilePeriod    time.Duration
+	autoAdjust         bool
+
+	refreshTokenTTL time.Duration
+	// activeSessions caches session families found active, so access tokens are checked
+	// against storage at most once per sessionCheckInterval
+	sessionCheckInterval time.Duration
+	activeSessions       *sessionCache
+
+	signingKeyRotation    time.Duration
+	signingKeyPublishLead time.Duration
+
+	loginThrottle       LoginThrottle
+	loginClientThrottle LoginThrottle
+	clientThrottle      LoginThrottle
+
+	credentialPolicy CredentialPolicy
+
+	// dummyHash is checked for logins that don't exist, so they take as long as existing ones
+	dummyHashMu sync.Mutex
+	dummyHash   string
+
+	logger *logger.Logger
 }
 
 func NewService(
@@ -63,75 +314,252 @@ func NewService(
 	tokenManager TokenManager,
 	accrualAdapter AccrualAdapter,
 	pool WorkerPool,
+	opts ...Option,
 ) *Service {
-	return &Service{
-		storage:        storage,
-		hasher:         hasher,
-		tokenManager:   tokenManager,
-		accrualAdapter: accrualAdapter,
-		pool:           pool,
+	s := &Service{
+		storage:            storage,
+		hasher:             hasher,
+		tokenManager:       tokenManager,
+		accrualAdapter:     accrualAdapter,
+		pool:               pool,
+		pollBatchSize:      defaultPollBatchSize,
+		orderLease:         defaultOrderLease,
+		orderRetryInterval: defaultOrderRetryInterval,
+		reconcileBatchSize: defaultReconcileBatchSize,
+		reconcileWindow:    defaultReconcileWindow,
+		reconcilePeriod:    defaultReconcilePeriod,
+		refreshTokenTTL:    defaultRefreshTokenTTL,
+
+		sessionCheckInterval: defaultSessionCheckInterval,
+		activeSessions:       newSessionCache(),
+
+		signingKeyRotation:    defaultSigningKeyRotation,
+		signingKeyPublishLead: defaultSigningKeyPublishLead,
+
+		logger: &logger.Logger{Logger: slog.Default()},
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
-func (s *Service) RegisterUser(ctx context.Context, params *request.RegisterUser) (string, error) {
-	user, err := s.storage.GetUserByLogin(ctx, params.Login)
+// RegisterUser checks credentials against credential policy, so PolicyError names the broken rules,
+// and returns ErrConflict if the login differs from a taken one only in case or Unicode form.
+func (s *Service) RegisterUser(ctx context.Context, params *request.RegisterUser) (*response.Tokens, error) {
+	login, key := s.normalizeLogin(params.Login)
+	if s.credentialPolicy != nil {
+		if err := s.credentialPolicy.Check(login, params.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.storage.GetUserByLogin(ctx, &storage.GetUserByLogin{
+		Login:    login,
+		LoginKey: key,
+	})
 	if err != nil {
 		if !errors.Is(err, application.ErrNotFound) {
-			return "", fmt.Errorf("failed to check user with login: %w", err)
+			return nil, fmt.Errorf("failed to check user with login: %w", err)
 		}
 	}
 
 	if err == nil && user != nil {
-		return "", application.ErrConflict
+		return nil, application.ErrConflict
 	}
 
 	hash, err := s.hasher.Hash(ctx, []byte(params.Password))
 	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	user = &model.User{
-		Login:    params.Login,
+		Login:    login,
 		Password: hash,
+		LoginKey: key,
 	}
 
 	user, err = s.storage.SaveUser(ctx, user)
 	if err != nil {
-		return "", fmt.Errorf("failed to save user: %w", err)
+		if errors.Is(err, application.ErrConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save user: %w", err)
 	}
 
-	token, err := s.tokenManager.CreateToken(user.ID)
+	return s.startSession(ctx, user.ID, params.UserAgent)
+}
+
+// Login checks password of the user and starts new session. Failed attempts are throttled
+// per login and per client, logins that don't exist are checked against dummy hash,
+// so they can't be told apart by response time.
+func (s *Service) Login(ctx context.Context, params *request.Login) (*response.Tokens, error) {
+	login, key := s.normalizeLogin(params.Login)
+	attempt, err := s.throttleLogin(key, params.ClientIP)
 	if err != nil {
-		return "", fmt.Errorf("failed to create token: %w", err)
+		return nil, err
 	}
+	// attempts ending with error are neither failed nor successful
+	defer attempt.release()
 
-	return token, nil
-}
+	user, err := s.storage.GetUserByLogin(ctx, &storage.GetUserByLogin{
+		Login:    login,
+		LoginKey: key,
+	})
+	if err != nil && !errors.Is(err, application.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
 
-func (s *Service) Login(ctx context.Context, params *request.Login) (string, error) {
-	user, err := s.storage.GetUserByLogin(ctx, params.Login)
-	if err != nil {
-		if errors.Is(err, application.ErrNotFound) {
-			return "", application.ErrUnauthorized
+	var hash string
+	if user != nil {
+		hash = user.Password
+	} else {
+		hash, err = s.getDummyHash(ctx)
+		if err != nil {
+			return nil, err
 		}
-		return "", fmt.Errorf("failed to get user: %w", err)
 	}
 
-	hash, err := s.hasher.Hash(ctx, []byte(params.Password))
+	ok, err := s.hasher.Verify(ctx, []byte(params.Password), hash)
 	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !ok || user == nil {
+		attempt.failed()
+		return nil, application.ErrUnauthorized
+	}
+
+	attempt.succeeded()
+
+	// the password is checked, so the user logs in even if the hash stays outdated till the next login
+	if s.hasher.NeedsRehash(user.Password) {
+		if err := s.rehashPassword(ctx, user.ID, []byte(params.Password)); err != nil {
+			s.logger.Error("failed to rehash password", "user_id", user.ID, "error", err)
+		}
 	}
 
-	if user.Password != hash {
-		return "", application.ErrUnauthorized
+	return s.startSession(ctx, user.ID, params.UserAgent)
+}
+
+// normalizeLogin returns login as it is stored and the key logins are unique by.
+func (s *Service) normalizeLogin(login string) (string, string) {
+	if s.credentialPolicy == nil {
+		return login, login
 	}
 
-	token, err := s.tokenManager.CreateToken(user.ID)
+	return s.credentialPolicy.NormalizeLogin(login)
+}
+
+// throttleLogin reserves the attempt in throttles of the login, of the login from the client
+// and of the client, or returns ThrottledError if any of them makes the attempt wait.
+func (s *Service) throttleLogin(loginKey, clientIP string) (*loginAttempt, error) {
+	var keys []throttledKey
+	if s.loginThrottle != nil {
+		keys = append(keys, throttledKey{throttle: s.loginThrottle, key: loginKey})
+	}
+	if s.loginClientThrottle != nil {
+		// addresses have no spaces, so keys of different pairs don't collide
+		keys = append(keys, throttledKey{throttle: s.loginClientThrottle, key: loginKey + " " + clientIP})
+	}
+	if s.clientThrottle != nil && clientIP != "" {
+		keys = append(keys, throttledKey{throttle: s.clientThrottle, key: clientIP, keepFailures: true})
+	}
+
+	attempt := &loginAttempt{}
+	var wait time.Duration
+	for _, k := range keys {
+		if w := k.throttle.Allow(k.key); w > 0 {
+			wait = max(wait, w)
+			continue
+		}
+		attempt.keys = append(attempt.keys, k)
+	}
+
+	if wait > 0 {
+		attempt.release()
+		return nil, &application.ThrottledError{RetryAfter: wait}
+	}
+
+	return attempt, nil
+}
+
+// throttledKey is a key login attempt is reserved with in a throttle.
+type throttledKey struct {
+	throttle LoginThrottle
+	key      string
+	// keepFailures leaves failures of the key after successful login
+	keepFailures bool
+}
+
+// loginAttempt is a login attempt reserved in throttles till its result is reported.
+type loginAttempt struct {
+	keys     []throttledKey
+	reported bool
+}
+
+func (a *loginAttempt) failed() {
+	a.reported = true
+	for _, k := range a.keys {
+		k.throttle.Failure(k.key)
+	}
+}
+
+func (a *loginAttempt) succeeded() {
+	a.reported = true
+	for _, k := range a.keys {
+		if k.keepFailures {
+			k.throttle.Release(k.key)
+			continue
+		}
+		k.throttle.Success(k.key)
+	}
+}
+
+// release forgets reservations of the attempt if its result is not reported.
+func (a *loginAttempt) release() {
+	if a.reported {
+		return
+	}
+	a.reported = true
+	for _, k := range a.keys {
+		k.throttle.Release(k.key)
+	}
+}
+
+// getDummyHash returns hash made with current parameters, so checking it costs as much as checking real ones.
+func (s *Service) getDummyHash(ctx context.Context) (string, error) {
+	s.dummyHashMu.Lock()
+	defer s.dummyHashMu.Unlock()
+
+	if s.dummyHash == "" {
+		hash, err := s.hasher.Hash(ctx, []byte(uuid.NewString()))
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		s.dummyHash = hash
+	}
+
+	return s.dummyHash, nil
+}
+
+// rehashPassword replaces hash of the user password with one made with current parameters.
+func (s *Service) rehashPassword(ctx context.Context, userID uuid.UUID, password []byte) error {
+	hash, err := s.hasher.Hash(ctx, password)
 	if err != nil {
-		return "", fmt.Errorf("failed to create token: %w", err)
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	return token, nil
+	err = s.storage.SetUserPassword(ctx, &storage.SetUserPassword{
+		ID:       userID,
+		Password: hash,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set user password: %w", err)
+	}
+
+	return nil
 }
 
 func (s *Service) checkByLuhn(number string) error {
@@ -184,16 +612,106 @@ func (s *Service) UploadOrder(ctx context.Context, params *request.UploadOrder)
 	}
 
 	order = model.NewOrder(params.UserID, params.OrderNumber)
+	order.AccrualProvider = s.accrualAdapter.Route(params.OrderNumber)
+	if s.isPushMode() {
+		order.NextAttemptAt = time.Now().Add(s.pushFallback)
+	} else {
+		// the order is leased to this instance right away, so pollers don't pick it up
+		// while the first check is running
+		order.NextAttemptAt = time.Now().Add(s.orderLease)
+	}
 	order, err = s.storage.SaveOrder(ctx, order)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save order: %w", err)
 	}
 
-	s.pool.Submit(context.Background(), 1*time.Hour, s.checkOrderJob(order.ID, order.Number, 1*time.Second), false)
+	if !s.isPushMode() {
+		if err := s.submitOrderCheck(ctx, order); err != nil {
+			return nil, err
+		}
+	}
 
 	return order, nil
 }
 
+// ApplyAccrualEvent applies order update pushed by accrual system.
+// Updates for orders in final status or in dead letters are ignored,
+// dead lettered orders are checked again only when an administrator retries them.
+func (s *Service) ApplyAccrualEvent(ctx context.Context, event *model.AccrualOrder) (*model.Order, error) {
+	order, err := s.storage.GetOrderByNumber(ctx, event.Number)
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return nil, application.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.IsFinal() || order.DeadLettered {
+		return order, nil
+	}
+
+	return s.applyAccrualOrder(ctx, order, event, s.postponeOrderCheck)
+}
+
+// PollOrders claims orders due for a check and checks them in the worker pool.
+// It returns the number of claimed orders. Orders are claimed with a lease,
+// so several instances of the service can poll the same database.
+func (s *Service) PollOrders(ctx context.Context) (int, error) {
+	orders, err := s.storage.ClaimDueOrders(ctx, &storage.ClaimDueOrders{
+		BatchSize:  int32(s.pollBatchSize),
+		LeaseUntil: time.Now().Add(s.orderLease),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due orders: %w", err)
+	}
+
+	futures := make([]*workerpool.Future[*model.Order], len(orders))
+	for i, order := range orders {
+		futures[i] = workerpool.Submit(s.pool, orderJobContext(order, checkOrderJobKind), orderCheckTimeout, s.checkOrderJob(order))
+	}
+
+	var errs []error
+	for i, future := range futures {
+		if _, err := future.Await(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", orders[i].Number, err))
+		}
+	}
+	if len(errs) > 0 {
+		return len(orders), fmt.Errorf("failed to check orders: %w", errors.Join(errs...))
+	}
+
+	return len(orders), nil
+}
+
+func (s *Service) isPushMode() bool {
+	return s.pushFallback > 0
+}
+
+// checkInterval returns the delay before the next check of an order that is not final yet.
+func (s *Service) checkInterval() time.Duration {
+	if s.isPushMode() {
+		return s.pushFallback
+	}
+	return s.orderRetryInterval
+}
+
+// submitOrderCheck queues the first check of the order without waiting for room in the queue.
+// If the pool is overloaded, the order stays saved and its lease is released,
+// so pollers pick it up without waiting for the lease to expire. The client is told
+// to retry later even if the release fails, then the order waits for the lease.
+func (s *Service) submitOrderCheck(ctx context.Context, order *model.Order) error {
+	_, submitErr := workerpool.TrySubmit(s.pool, orderJobContext(order, checkOrderJobKind), orderCheckTimeout, s.checkOrderJob(order))
+	if submitErr == nil {
+		return nil
+	}
+
+	if err := s.storage.ReleaseOrder(ctx, order.ID); err != nil {
+		s.logger.Error("failed to release order", "order", order.Number, "error", err)
+	}
+
+	return fmt.Errorf("%w: %w", application.ErrBusy, submitErr)
+}
+
 func (s *Service) ListUserOrders(ctx context.Context, id uuid.UUID) ([]*response.UserOrder, error) {
 	orders, err := s.storage.GetUserOrdersNewestFirst(ctx, id)
 	if err != nil {
@@ -218,6 +736,62 @@ func (s *Service) ListUserOrders(ctx context.Context, id uuid.UUID) ([]*response
 	return resp, nil
 }
 
+// GetUserOrder returns the order with its processing timeline.
+// Orders of other users are reported as not found.
+func (s *Service) GetUserOrder(ctx context.Context, userID uuid.UUID, number string) (*response.UserOrderDetails, error) {
+	order, err := s.storage.GetOrderByNumber(ctx, number)
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return nil, application.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.UserID != userID {
+		return nil, application.ErrNotFound
+	}
+
+	events, err := s.storage.GetOrderEvents(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+
+	resp := &response.UserOrderDetails{
+		Number:     order.Number,
+		Status:     string(order.Status),
+		Accrual:    float32(order.Accrual) / 100.0,
+		UploadedAt: order.CreatedAt.Format(time.RFC3339),
+		Timeline:   make([]*response.OrderEvent, len(events)),
+	}
+
+	for i, event := range events {
+		resp.Timeline[i] = &response.OrderEvent{
+			Type:      string(event.Type),
+			Status:    string(event.Status),
+			Accrual:   float32(event.Accrual) / 100.0,
+			Error:     event.PublicError,
+			CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return resp, nil
+}
+
+// publicOrderError turns error of processing an order into a message safe to show the user.
+func publicOrderError(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, application.ErrAccrualOrderNotRegistered):
+		return "order is not registered in accrual system yet"
+	case errors.Is(err, application.ErrAccrualTooManyRequests),
+		errors.Is(err, application.ErrAccrualUnavailable):
+		return "accrual system is temporarily unavailable, order will be checked later"
+	default:
+		return "failed to check order in accrual system"
+	}
+}
+
 func (s *Service) GetUserBalance(ctx context.Context, id uuid.UUID) (*response.UserBalance, error) {
 	user, err := s.storage.GetUser(ctx, id)
 	if err != nil {
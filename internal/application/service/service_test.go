@@ -28,22 +28,53 @@ func TestService_RegisterUser(t *testing.T) {
 	ctx := context.WithValue(context.Background(), tnk, "RegisterUser")
 
 	params := &request.RegisterUser{
-		Login:    "test-login",
-		Password: "test-password",
+		Login:     "test-login",
+		Password:  "test-password",
+		UserAgent: "test-agent",
 	}
+	familyID := uuid.New()
+	policyErr := &application.PolicyError{Violations: []application.PolicyViolation{
+		{Field: "password", Rule: "min_length", Message: "must be at least 16 characters long"},
+	}}
+	byLogin := &storage.GetUserByLogin{Login: params.Login, LoginKey: params.Login}
 
 	tests := map[string]struct {
 		storageMock      *mocks.Storage
 		hasherMock       *mocks.Hasher
 		tokenManagerMock *mocks.TokenManager
 		workerPoolMock   *mocks.WorkerPool
-		expectedResp     string
+		policyMock       *mocks.CredentialPolicy
+		expectedResp     *response.Tokens
 		expectedErr      error
 	}{
+		"credentials violate policy": {
+			policyMock: func() *mocks.CredentialPolicy {
+				mock := mocks.NewCredentialPolicy(t)
+				mock.On("NormalizeLogin", params.Login).Once().Return(params.Login, params.Login)
+				mock.On("Check", params.Login, params.Password).Once().Return(policyErr)
+				return mock
+			}(),
+			expectedErr: policyErr,
+		},
+		"login differs from taken one in case": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, &storage.GetUserByLogin{Login: params.Login, LoginKey: "key"}).Once().
+					Return(&model.User{Login: "Test-Login", LoginKey: "key"}, nil)
+				return mock
+			}(),
+			policyMock: func() *mocks.CredentialPolicy {
+				mock := mocks.NewCredentialPolicy(t)
+				mock.On("NormalizeLogin", params.Login).Once().Return(params.Login, "key")
+				mock.On("Check", params.Login, params.Password).Once().Return(nil)
+				return mock
+			}(),
+			expectedErr: application.ErrConflict,
+		},
 		"failed to get user by login": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, errors.New("storage error"))
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, errors.New("storage error"))
 				return mock
 			}(),
 			expectedErr: fmt.Errorf("failed to check user with login: %w", errors.New("storage error")),
@@ -51,7 +82,7 @@ func TestService_RegisterUser(t *testing.T) {
 		"login is taken": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(&model.User{}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{}, nil)
 				return mock
 			}(),
 			expectedErr: application.ErrConflict,
@@ -59,7 +90,7 @@ func TestService_RegisterUser(t *testing.T) {
 		"failed to hash password": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, application.ErrNotFound)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
@@ -72,8 +103,8 @@ func TestService_RegisterUser(t *testing.T) {
 		"failed to save user": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, application.ErrNotFound)
-				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash"}).Once().Return(nil, errors.New("storage error"))
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash", LoginKey: params.Login}).Once().Return(nil, errors.New("storage error"))
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
@@ -83,11 +114,65 @@ func TestService_RegisterUser(t *testing.T) {
 			}(),
 			expectedErr: fmt.Errorf("failed to save user: %w", errors.New("storage error")),
 		},
+		"login is taken while registering": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash", LoginKey: params.Login}).Once().Return(nil, application.ErrConflict)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
+				return mock
+			}(),
+			expectedErr: application.ErrConflict,
+		},
+		"failed to create refresh token": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash", LoginKey: params.Login}).Once().Return(&model.User{Login: params.Login, ID: uuid.Max}, nil)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("", errors.New("token manager error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to create refresh token: %w", errors.New("token manager error")),
+		},
+		"failed to save session": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash", LoginKey: params.Login}).Once().Return(&model.User{Login: params.Login, ID: uuid.Max}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to save session: %w", errors.New("storage error")),
+		},
 		"failed to create token": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, application.ErrNotFound)
-				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash"}).Once().Return(&model.User{Login: params.Login, ID: uuid.Max}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash", LoginKey: params.Login}).Once().Return(&model.User{Login: params.Login, ID: uuid.Max}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
@@ -97,7 +182,8 @@ func TestService_RegisterUser(t *testing.T) {
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				mock := mocks.NewTokenManager(t)
-				mock.On("CreateToken", uuid.Max).Once().Return("", errors.New("token manager error"))
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("", errors.New("token manager error"))
 				return mock
 			}(),
 			expectedErr: fmt.Errorf("failed to create token: %w", errors.New("token manager error")),
@@ -105,8 +191,30 @@ func TestService_RegisterUser(t *testing.T) {
 		"success": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, application.ErrNotFound)
-				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash"}).Once().Return(&model.User{Login: params.Login, ID: uuid.Max}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: params.Login, Password: "hash", LoginKey: params.Login}).Once().Return(&model.User{Login: params.Login, ID: uuid.Max}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
+				return mock
+			}(),
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
+		},
+		"success with normalized login": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, &storage.GetUserByLogin{Login: "normalized-login", LoginKey: "key"}).Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveUser", ctx, &model.User{Login: "normalized-login", Password: "hash", LoginKey: "key"}).Once().Return(&model.User{Login: "normalized-login", ID: uuid.Max}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
@@ -116,21 +224,34 @@ func TestService_RegisterUser(t *testing.T) {
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				mock := mocks.NewTokenManager(t)
-				mock.On("CreateToken", uuid.Max).Once().Return("token", nil)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
 				return mock
 			}(),
-			expectedResp: "token",
+			policyMock: func() *mocks.CredentialPolicy {
+				mock := mocks.NewCredentialPolicy(t)
+				mock.On("NormalizeLogin", params.Login).Once().Return("normalized-login", "key")
+				mock.On("Check", "normalized-login", params.Password).Once().Return(nil)
+				return mock
+			}(),
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
 		},
 	}
 
 	for tn, tt := range tests {
 		t.Run(tn, func(t *testing.T) {
+			var opts []service.Option
+			if tt.policyMock != nil {
+				opts = append(opts, service.WithCredentialPolicy(tt.policyMock))
+			}
+
 			s := service.NewService(
 				tt.storageMock,
 				tt.hasherMock,
 				tt.tokenManagerMock,
 				nil,
-				nil)
+				nil,
+				opts...)
 
 			resp, err := s.RegisterUser(ctx, params)
 
@@ -150,105 +271,343 @@ func TestService_Login(t *testing.T) {
 	ctx := context.WithValue(context.Background(), tnk, "Login")
 
 	params := &request.Login{
-		Login:    "test-login",
-		Password: "test-password",
+		Login:     "test-login",
+		Password:  "test-password",
+		UserAgent: "test-agent",
+		ClientIP:  "127.0.0.1",
 	}
+	familyID := uuid.New()
+	anyBytes := mock.AnythingOfType("[]uint8")
+	byLogin := &storage.GetUserByLogin{Login: params.Login, LoginKey: params.Login}
 
 	tests := map[string]struct {
-		storageMock      *mocks.Storage
-		hasherMock       *mocks.Hasher
-		tokenManagerMock *mocks.TokenManager
-		expectedResp     string
-		expectedErr      error
+		storageMock             *mocks.Storage
+		hasherMock              *mocks.Hasher
+		tokenManagerMock        *mocks.TokenManager
+		loginThrottleMock       *mocks.LoginThrottle
+		loginClientThrottleMock *mocks.LoginThrottle
+		clientThrottleMock      *mocks.LoginThrottle
+		policyMock              *mocks.CredentialPolicy
+		expectedResp            *response.Tokens
+		expectedErr             error
 	}{
+		"login is throttled for the client": {
+			loginThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				// the reserved attempt is forgotten
+				mock.On("Allow", params.Login).Once().Return(time.Duration(0))
+				mock.On("Release", params.Login).Once()
+				return mock
+			}(),
+			loginClientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login+" "+params.ClientIP).Once().Return(time.Minute)
+				return mock
+			}(),
+			expectedErr: &application.ThrottledError{RetryAfter: time.Minute},
+		},
+		"failed to get user releases attempt": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			loginClientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login+" "+params.ClientIP).Once().Return(time.Duration(0))
+				mock.On("Release", params.Login+" "+params.ClientIP).Once()
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to get user: %w", errors.New("storage error")),
+		},
+		"login is throttled": {
+			loginThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login).Once().Return(2 * time.Second)
+				return mock
+			}(),
+			clientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.ClientIP).Once().Return(5 * time.Second)
+				return mock
+			}(),
+			expectedErr: &application.ThrottledError{RetryAfter: 5 * time.Second},
+		},
 		"failed to get user": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, errors.New("storage error"))
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, errors.New("storage error"))
 				return mock
 			}(),
 			expectedErr: fmt.Errorf("failed to get user: %w", errors.New("storage error")),
 		},
+		"failed to hash dummy password": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Hash", ctx, anyBytes).Once().Return("", errors.New("hasher error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to hash password: %w", errors.New("hasher error")),
+		},
 		"user not found": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(nil, application.ErrNotFound)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				// the password is checked anyway, so that unknown logins take as long as known ones
+				mock.On("Hash", ctx, anyBytes).Once().Return("dummy-hash", nil)
+				mock.On("Verify", ctx, []byte(params.Password), "dummy-hash").Once().Return(true, nil)
+				return mock
+			}(),
+			loginThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login).Once().Return(time.Duration(0))
+				mock.On("Failure", params.Login).Once()
+				return mock
+			}(),
+			clientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.ClientIP).Once().Return(time.Duration(0))
+				mock.On("Failure", params.ClientIP).Once()
 				return mock
 			}(),
 			expectedErr: application.ErrUnauthorized,
 		},
-		"failed to hash password": {
+		"failed to verify password": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(&model.User{}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{Password: "hash"}, nil)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
 				mock := mocks.NewHasher(t)
-				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("", errors.New("hasher error"))
+				mock.On("Verify", ctx, []byte(params.Password), "hash").Once().Return(false, errors.New("hasher error"))
 				return mock
 			}(),
-			expectedErr: fmt.Errorf("failed to hash password: %w", errors.New("hasher error")),
+			expectedErr: fmt.Errorf("failed to verify password: %w", errors.New("hasher error")),
 		},
 		"password doesn't match hash": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(&model.User{Password: "diff-hash"}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{Password: "diff-hash"}, nil)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
 				mock := mocks.NewHasher(t)
-				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
+				mock.On("Verify", ctx, []byte(params.Password), "diff-hash").Once().Return(false, nil)
+				return mock
+			}(),
+			loginThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login).Once().Return(time.Duration(0))
+				mock.On("Failure", params.Login).Once()
+				return mock
+			}(),
+			clientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.ClientIP).Once().Return(time.Duration(0))
+				mock.On("Failure", params.ClientIP).Once()
 				return mock
 			}(),
 			expectedErr: application.ErrUnauthorized,
 		},
-		"failed to create token": {
+		"failed to rehash password": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(&model.User{ID: uuid.Max, Password: "hash"}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{ID: uuid.Max, Password: "legacy-hash"}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
 				mock := mocks.NewHasher(t)
+				mock.On("Verify", ctx, []byte(params.Password), "legacy-hash").Once().Return(true, nil)
+				mock.On("NeedsRehash", "legacy-hash").Once().Return(true)
+				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("", errors.New("hasher error"))
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
+				return mock
+			}(),
+			// the user logs in with the outdated hash
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
+		},
+		"failed to set user password": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{ID: uuid.Max, Password: "legacy-hash"}, nil)
+				mock.On("SetUserPassword", ctx, &storage.SetUserPassword{ID: uuid.Max, Password: "hash"}).Once().Return(errors.New("storage error"))
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Verify", ctx, []byte(params.Password), "legacy-hash").Once().Return(true, nil)
+				mock.On("NeedsRehash", "legacy-hash").Once().Return(true)
 				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
 				return mock
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				mock := mocks.NewTokenManager(t)
-				mock.On("CreateToken", uuid.Max).Once().Return("", errors.New("token manager error"))
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
+				return mock
+			}(),
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
+		},
+		"failed to create token": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{ID: uuid.Max, Password: "hash"}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Verify", ctx, []byte(params.Password), "hash").Once().Return(true, nil)
+				mock.On("NeedsRehash", "hash").Once().Return(false)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("", errors.New("token manager error"))
 				return mock
 			}(),
 			expectedErr: fmt.Errorf("failed to create token: %w", errors.New("token manager error")),
 		},
-		"success": {
+		"rehashed password": {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
-				mock.On("GetUserByLogin", ctx, params.Login).Once().Return(&model.User{ID: uuid.Max, Password: "hash"}, nil)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{ID: uuid.Max, Password: "legacy-hash"}, nil)
+				mock.On("SetUserPassword", ctx, &storage.SetUserPassword{ID: uuid.Max, Password: "hash"}).Once().Return(nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
 				return mock
 			}(),
 			hasherMock: func() *mocks.Hasher {
 				mock := mocks.NewHasher(t)
+				mock.On("Verify", ctx, []byte(params.Password), "legacy-hash").Once().Return(true, nil)
+				mock.On("NeedsRehash", "legacy-hash").Once().Return(true)
 				mock.On("Hash", ctx, []byte(params.Password)).Once().Return("hash", nil)
 				return mock
 			}(),
 			tokenManagerMock: func() *mocks.TokenManager {
 				mock := mocks.NewTokenManager(t)
-				mock.On("CreateToken", uuid.Max).Once().Return("token", nil)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
 				return mock
 			}(),
-			expectedResp: "token",
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, byLogin).Once().Return(&model.User{ID: uuid.Max, Password: "hash"}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Verify", ctx, []byte(params.Password), "hash").Once().Return(true, nil)
+				mock.On("NeedsRehash", "hash").Once().Return(false)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
+				return mock
+			}(),
+			loginThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login).Once().Return(time.Duration(0))
+				mock.On("Success", params.Login).Once()
+				return mock
+			}(),
+			loginClientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				mock.On("Allow", params.Login+" "+params.ClientIP).Once().Return(time.Duration(0))
+				mock.On("Success", params.Login+" "+params.ClientIP).Once()
+				return mock
+			}(),
+			clientThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				// failures of the client are not forgotten
+				mock.On("Allow", params.ClientIP).Once().Return(time.Duration(0))
+				mock.On("Release", params.ClientIP).Once()
+				return mock
+			}(),
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
+		},
+		"login in other case": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetUserByLogin", ctx, &storage.GetUserByLogin{Login: params.Login, LoginKey: "key"}).Once().
+					Return(&model.User{ID: uuid.Max, Login: "Test-Login", Password: "hash", LoginKey: "key"}, nil)
+				mock.On("SaveSession", ctx, newSession(uuid.Max, "test-agent")).Once().Return(&model.Session{UserID: uuid.Max, FamilyID: familyID}, nil)
+				return mock
+			}(),
+			hasherMock: func() *mocks.Hasher {
+				mock := mocks.NewHasher(t)
+				mock.On("Verify", ctx, []byte(params.Password), "hash").Once().Return(true, nil)
+				mock.On("NeedsRehash", "hash").Once().Return(false)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", uuid.Max, familyID).Once().Return("token", nil)
+				return mock
+			}(),
+			loginThrottleMock: func() *mocks.LoginThrottle {
+				mock := mocks.NewLoginThrottle(t)
+				// logins differing in case share failures
+				mock.On("Allow", "key").Once().Return(time.Duration(0))
+				mock.On("Success", "key").Once()
+				return mock
+			}(),
+			policyMock: func() *mocks.CredentialPolicy {
+				mock := mocks.NewCredentialPolicy(t)
+				mock.On("NormalizeLogin", params.Login).Once().Return(params.Login, "key")
+				return mock
+			}(),
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
 		},
 	}
 
 	for tn, tt := range tests {
 		t.Run(tn, func(t *testing.T) {
+			var opts []service.Option
+			if tt.loginThrottleMock != nil {
+				opts = append(opts, service.WithLoginThrottle(tt.loginThrottleMock))
+			}
+			if tt.loginClientThrottleMock != nil {
+				opts = append(opts, service.WithLoginClientThrottle(tt.loginClientThrottleMock))
+			}
+			if tt.clientThrottleMock != nil {
+				opts = append(opts, service.WithClientThrottle(tt.clientThrottleMock))
+			}
+			if tt.policyMock != nil {
+				opts = append(opts, service.WithCredentialPolicy(tt.policyMock))
+			}
+
 			s := service.NewService(
 				tt.storageMock,
 				tt.hasherMock,
 				tt.tokenManagerMock,
 				nil,
-				nil)
+				nil,
+				opts...)
 
 			resp, err := s.Login(ctx, params)
 
@@ -264,6 +623,16 @@ func TestService_Login(t *testing.T) {
 	}
 }
 
+// newOrder matches a new order leased for the first check.
+func newOrder(userID uuid.UUID, number string) any {
+	return mock.MatchedBy(func(order *model.Order) bool {
+		return order.UserID == userID &&
+			order.Number == number &&
+			order.Status == model.OrderStatusNew &&
+			order.NextAttemptAt.After(time.Now())
+	})
+}
+
 func TestService_UploadOrder(t *testing.T) {
 	ctx := context.WithValue(context.Background(), tnk, "UploadOrder")
 	ctx, cancel := context.WithCancel(ctx)
@@ -272,6 +641,11 @@ func TestService_UploadOrder(t *testing.T) {
 	params := &request.UploadOrder{
 		UserID: uuid.New(),
 	}
+	jobCtx := mock.MatchedBy(func(ctx context.Context) bool {
+		key, _ := workerpool.GetKeyFromContext(ctx)
+		idempotencyKey, _ := workerpool.GetIdempotencyKeyFromContext(ctx)
+		return key == params.UserID.String() && idempotencyKey == "check/66465778752"
+	})
 
 	tests := map[string]struct {
 		orderNumber  string
@@ -321,7 +695,12 @@ func TestService_UploadOrder(t *testing.T) {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
 				mock.On("GetOrderByNumber", ctx, "4561261212345467").Once().Return(nil, application.ErrNotFound)
-				mock.On("SaveOrder", ctx, &model.Order{UserID: params.UserID, Number: "4561261212345467", Status: model.OrderStatusNew}).Once().Return(nil, errors.New("storage error"))
+				mock.On("SaveOrder", ctx, newOrder(params.UserID, "4561261212345467")).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			accrualMock: func() *mocks.AccrualAdapter {
+				mock := mocks.NewAccrualAdapter(t)
+				mock.On("Route", "4561261212345467").Once().Return("default")
 				return mock
 			}(),
 
@@ -332,20 +711,86 @@ func TestService_UploadOrder(t *testing.T) {
 			storageMock: func() *mocks.Storage {
 				mock := mocks.NewStorage(t)
 				mock.On("GetOrderByNumber", ctx, "66465778752").Once().Return(nil, application.ErrNotFound)
-				mock.On("SaveOrder", ctx, &model.Order{UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew}).Once().Return(&model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew}, nil)
+				mock.On("SaveOrder", ctx, newOrder(params.UserID, "66465778752")).Once().Return(&model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew}, nil)
 				return mock
 			}(),
 			accrualMock: func() *mocks.AccrualAdapter {
-				return mocks.NewAccrualAdapter(t)
+				mock := mocks.NewAccrualAdapter(t)
+				mock.On("Route", "66465778752").Once().Return("default")
+				return mock
 			}(),
 			poolMock: func() *mocks.WorkerPool {
 				poolMock := mocks.NewWorkerPool(t)
-				resultCh := make(chan *workerpool.Result)
-				poolMock.On("Submit", context.Background(), 1*time.Hour, mock.Anything, false).Maybe().Return(resultCh)
+				poolMock.On("TrySubmitFunc", jobCtx, 30*time.Second, mock.Anything, mock.Anything).Once().Return(nil)
 				return poolMock
 			}(),
 			expectedResp: &model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew},
 		},
+		"queue is full": {
+			orderNumber: "66465778752",
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "66465778752").Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveOrder", ctx, newOrder(params.UserID, "66465778752")).Once().Return(&model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew}, nil)
+				mock.On("ReleaseOrder", ctx, uuid.Max).Once().Return(nil)
+				return mock
+			}(),
+			accrualMock: func() *mocks.AccrualAdapter {
+				mock := mocks.NewAccrualAdapter(t)
+				mock.On("Route", "66465778752").Once().Return("default")
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				poolMock.On("TrySubmitFunc", jobCtx, 30*time.Second, mock.Anything, mock.Anything).Once().Return(workerpool.ErrQueueFull)
+				return poolMock
+			}(),
+			expectedErr: fmt.Errorf("%w: %w", application.ErrBusy, workerpool.ErrQueueFull),
+		},
+		"failed to release order": {
+			orderNumber: "66465778752",
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "66465778752").Once().Return(nil, application.ErrNotFound)
+				mock.On("SaveOrder", ctx, newOrder(params.UserID, "66465778752")).Once().Return(&model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew}, nil)
+				mock.On("ReleaseOrder", ctx, uuid.Max).Once().Return(errors.New("storage error"))
+				return mock
+			}(),
+			accrualMock: func() *mocks.AccrualAdapter {
+				mock := mocks.NewAccrualAdapter(t)
+				mock.On("Route", "66465778752").Once().Return("default")
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				poolMock.On("TrySubmitFunc", jobCtx, 30*time.Second, mock.Anything, mock.Anything).Once().Return(workerpool.ErrQueueFull)
+				return poolMock
+			}(),
+			// the order is checked after the lease, the client still retries later
+			expectedErr: fmt.Errorf("%w: %w", application.ErrBusy, workerpool.ErrQueueFull),
+		},
+		"order routed to partner provider": {
+			orderNumber: "66465778752",
+			storageMock: func() *mocks.Storage {
+				m := mocks.NewStorage(t)
+				m.On("GetOrderByNumber", ctx, "66465778752").Once().Return(nil, application.ErrNotFound)
+				m.On("SaveOrder", ctx, mock.MatchedBy(func(order *model.Order) bool {
+					return order.Number == "66465778752" && order.AccrualProvider == "partner"
+				})).Once().Return(&model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew, AccrualProvider: "partner"}, nil)
+				return m
+			}(),
+			accrualMock: func() *mocks.AccrualAdapter {
+				mock := mocks.NewAccrualAdapter(t)
+				mock.On("Route", "66465778752").Once().Return("partner")
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				poolMock.On("TrySubmitFunc", jobCtx, 30*time.Second, mock.Anything, mock.Anything).Once().Return(nil)
+				return poolMock
+			}(),
+			expectedResp: &model.Order{ID: uuid.Max, UserID: params.UserID, Number: "66465778752", Status: model.OrderStatusNew, AccrualProvider: "partner"},
+		},
 	}
 
 	for tn, tt := range tests {
@@ -373,6 +818,249 @@ func TestService_UploadOrder(t *testing.T) {
 	}
 }
 
+func TestService_PollOrders(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "PollOrders")
+	userID := uuid.New()
+	jobCtx := func(number string) any {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			key, _ := workerpool.GetKeyFromContext(ctx)
+			idempotencyKey, _ := workerpool.GetIdempotencyKeyFromContext(ctx)
+			return key == userID.String() && idempotencyKey == "check/"+number
+		})
+	}
+
+	claim := mock.MatchedBy(func(dto *storage.ClaimDueOrders) bool {
+		return dto.BatchSize == 10 && dto.LeaseUntil.After(time.Now())
+	})
+	// result completes the submitted job with res
+	result := func(res *workerpool.Result) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			args.Get(3).(func(*workerpool.Result))(res)
+		}
+	}
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		poolMock     *mocks.WorkerPool
+		expectedResp int
+		expectedErr  error
+	}{
+		"failed to claim due orders": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimDueOrders", ctx, claim).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to claim due orders: %w", errors.New("storage error")),
+		},
+		"no due orders": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimDueOrders", ctx, claim).Once().Return([]*model.Order{}, nil)
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				return mocks.NewWorkerPool(t)
+			}(),
+		},
+		"failed to check order": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimDueOrders", ctx, claim).Once().Return([]*model.Order{
+					{ID: uuid.New(), UserID: userID, Number: "66465778752", Status: model.OrderStatusNew},
+					{ID: uuid.New(), UserID: userID, Number: "4561261212345467", Status: model.OrderStatusProcessing},
+				}, nil)
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				poolMock.On("SubmitFunc", jobCtx("66465778752"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Value: &model.Order{}}))
+				poolMock.On("SubmitFunc", jobCtx("4561261212345467"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Err: errors.New("accrual error")}))
+				return poolMock
+			}(),
+			expectedResp: 2,
+			expectedErr:  fmt.Errorf("failed to check orders: %w", errors.Join(fmt.Errorf("order 4561261212345467: %w", errors.New("accrual error")))),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ClaimDueOrders", ctx, claim).Once().Return([]*model.Order{
+					{ID: uuid.New(), UserID: userID, Number: "66465778752", Status: model.OrderStatusNew},
+					{ID: uuid.New(), UserID: userID, Number: "4561261212345467", Status: model.OrderStatusProcessing},
+				}, nil)
+				return mock
+			}(),
+			poolMock: func() *mocks.WorkerPool {
+				poolMock := mocks.NewWorkerPool(t)
+				poolMock.On("SubmitFunc", jobCtx("66465778752"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Value: &model.Order{}}))
+				poolMock.On("SubmitFunc", jobCtx("4561261212345467"), 30*time.Second, mock.Anything, mock.Anything).Once().
+					Run(result(&workerpool.Result{Value: &model.Order{}}))
+				return poolMock
+			}(),
+			expectedResp: 2,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, tt.poolMock, service.WithPollBatchSize(10))
+
+			resp, err := s.PollOrders(ctx)
+
+			if tt.expectedErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedErr.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_UploadOrder_PushMode(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "UploadOrder")
+	userID := uuid.New()
+
+	storageMock := mocks.NewStorage(t)
+	storageMock.On("GetOrderByNumber", ctx, "66465778752").Once().Return(nil, application.ErrNotFound)
+	storageMock.On("SaveOrder", ctx, mock.MatchedBy(func(order *model.Order) bool {
+		// the first poll is postponed until the push fallback
+		return order.NextAttemptAt.After(time.Now().Add(time.Hour))
+	})).Once().Return(&model.Order{ID: uuid.Max, UserID: userID, Number: "66465778752", Status: model.OrderStatusNew}, nil)
+
+	accrualMock := mocks.NewAccrualAdapter(t)
+	accrualMock.On("Route", "66465778752").Once().Return("default")
+
+	// the pool is not used in push mode
+	s := service.NewService(storageMock, nil, nil, accrualMock, mocks.NewWorkerPool(t), service.WithPushFallback(2*time.Hour))
+
+	resp, err := s.UploadOrder(ctx, &request.UploadOrder{UserID: userID, OrderNumber: "66465778752"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Order{ID: uuid.Max, UserID: userID, Number: "66465778752", Status: model.OrderStatusNew}, resp)
+}
+
+func TestService_ApplyAccrualEvent(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "ApplyAccrualEvent")
+	orderID := uuid.New()
+
+	tests := map[string]struct {
+		event        *model.AccrualOrder
+		storageMock  *mocks.Storage
+		expectedResp *model.Order
+		expectedErr  error
+	}{
+		"order not found": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessed, Accrual: 50},
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "1234").Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"failed to get order": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessed, Accrual: 50},
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "1234").Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to get order: %w", errors.New("storage error")),
+		},
+		"order is already final": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessed, Accrual: 50},
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "1234").Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusInvalid}, nil)
+				return mock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusInvalid},
+		},
+		"order processed": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessed, Accrual: 50},
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "1234").Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusNew}, nil)
+				mock.On("SetOrderStatusAndAccrual", ctx, &storage.SetOrderStatusAndAccrual{
+					ID:      orderID,
+					Status:  model.OrderStatusProcessed,
+					Accrual: 5000,
+				}).Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessed, Accrual: 5000}, nil)
+				return mock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessed, Accrual: 5000},
+		},
+		"order processing": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessing},
+			storageMock: func() *mocks.Storage {
+				mockStorage := mocks.NewStorage(t)
+				mockStorage.On("GetOrderByNumber", ctx, "1234").Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusNew}, nil)
+				mockStorage.On("SetOrderStatus", ctx, &storage.SetOrderStatus{
+					ID:     orderID,
+					Status: model.OrderStatusProcessing,
+				}).Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing}, nil)
+				// fallback poll is postponed again without counting an attempt
+				mockStorage.On("PostponeOrderCheck", ctx, mock.MatchedBy(func(dto *storage.PostponeOrderCheck) bool {
+					return dto.ID == orderID && dto.NextAttemptAt.After(time.Now().Add(time.Hour))
+				})).Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing, Attempts: 2}, nil)
+				return mockStorage
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing, Attempts: 2},
+		},
+		"order dead lettered before postpone": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessing},
+			storageMock: func() *mocks.Storage {
+				mockStorage := mocks.NewStorage(t)
+				mockStorage.On("GetOrderByNumber", ctx, "1234").Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing}, nil)
+				mockStorage.On("PostponeOrderCheck", ctx, mock.AnythingOfType("*storage.PostponeOrderCheck")).Once().Return(nil, application.ErrNotFound)
+				return mockStorage
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing},
+		},
+		"failed to postpone order check": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessing},
+			storageMock: func() *mocks.Storage {
+				mockStorage := mocks.NewStorage(t)
+				mockStorage.On("GetOrderByNumber", ctx, "1234").Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing}, nil)
+				mockStorage.On("PostponeOrderCheck", ctx, mock.AnythingOfType("*storage.PostponeOrderCheck")).Once().Return(nil, errors.New("storage error"))
+				return mockStorage
+			}(),
+			expectedErr: fmt.Errorf("failed to postpone order check: %w", errors.New("storage error")),
+		},
+		"dead lettered order is ignored": {
+			event: &model.AccrualOrder{Number: "1234", Status: model.AccrualOrderStatusProcessed, Accrual: 50},
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, "1234").Once().Return(&model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing, DeadLettered: true}, nil)
+				return mock
+			}(),
+			expectedResp: &model.Order{ID: orderID, Number: "1234", Status: model.OrderStatusProcessing, DeadLettered: true},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil, service.WithPushFallback(2*time.Hour))
+
+			resp, err := s.ApplyAccrualEvent(ctx, tt.event)
+
+			if tt.expectedErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedErr.Error(), err.Error())
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp, resp)
+			}
+		})
+	}
+}
+
 func TestService_ListUserOrders(t *testing.T) {
 	ctx := context.WithValue(context.Background(), tnk, "ListUserOrders")
 	userID := uuid.New()
@@ -511,6 +1199,102 @@ func TestService_ListUserOrders(t *testing.T) {
 	}
 }
 
+func TestService_GetUserOrder(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "GetUserOrder")
+	userID := uuid.New()
+	orderID := uuid.New()
+	now := time.Now()
+
+	order := &model.Order{
+		ID:        orderID,
+		UserID:    userID,
+		Number:    "4561261212345467",
+		Status:    model.OrderStatusProcessed,
+		Accrual:   10000,
+		CreatedAt: now.Add(-time.Hour),
+	}
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		expectedResp *response.UserOrderDetails
+		expectedErr  error
+	}{
+		"order not found": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, order.Number).Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"failed to get order": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, order.Number).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to get order: %w", errors.New("storage error")),
+		},
+		"order of another user": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, order.Number).Once().Return(&model.Order{
+					ID:     orderID,
+					UserID: uuid.New(),
+					Number: order.Number,
+				}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"failed to get order events": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, order.Number).Once().Return(order, nil)
+				mock.On("GetOrderEvents", ctx, orderID).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to get order events: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetOrderByNumber", ctx, order.Number).Once().Return(order, nil)
+				mock.On("GetOrderEvents", ctx, orderID).Once().Return([]*model.OrderEvent{
+					{Type: model.OrderEventUploaded, Status: model.OrderStatusNew, CreatedAt: now.Add(-time.Hour)},
+					{Type: model.OrderEventChecked, Status: model.OrderStatusNew, Error: "order is not registered", PublicError: "order is not registered in accrual system yet", CreatedAt: now.Add(-time.Hour)},
+					{Type: model.OrderEventChecked, Status: model.OrderStatusNew, Error: "failed to request order: dial tcp: connection refused", PublicError: "failed to check order in accrual system", CreatedAt: now.Add(-time.Minute)},
+					{Type: model.OrderEventStatusChanged, Status: model.OrderStatusProcessed, Accrual: 10000, CreatedAt: now},
+				}, nil)
+				return mock
+			}(),
+			expectedResp: &response.UserOrderDetails{
+				Number:     order.Number,
+				Status:     string(model.OrderStatusProcessed),
+				Accrual:    100,
+				UploadedAt: now.Add(-time.Hour).Format(time.RFC3339),
+				Timeline: []*response.OrderEvent{
+					{Type: "UPLOADED", Status: "NEW", CreatedAt: now.Add(-time.Hour).Format(time.RFC3339)},
+					{Type: "CHECKED", Status: "NEW", Error: "order is not registered in accrual system yet", CreatedAt: now.Add(-time.Hour).Format(time.RFC3339)},
+					{Type: "CHECKED", Status: "NEW", Error: "failed to check order in accrual system", CreatedAt: now.Add(-time.Minute).Format(time.RFC3339)},
+					{Type: "STATUS_CHANGED", Status: "PROCESSED", Accrual: 100, CreatedAt: now.Format(time.RFC3339)},
+				},
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			resp, err := s.GetUserOrder(ctx, userID, order.Number)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
 func TestService_GetUserBalance(t *testing.T) {
 	ctx := context.WithValue(context.Background(), tnk, "GetUserBalance")
 	userID := uuid.New()
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/request"
+	"github.com/dtroode/gophermart/internal/application/response"
+	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/google/uuid"
+)
+
+// hashRefreshToken returns the form refresh token is stored in, so a leaked table can't be used to refresh.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// startSession starts new session family for the user and returns its tokens.
+func (s *Service) startSession(ctx context.Context, userID uuid.UUID, userAgent string) (*response.Tokens, error) {
+	refreshToken, err := s.tokenManager.CreateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	session, err := s.storage.SaveSession(ctx, &model.Session{
+		UserID:    userID,
+		TokenHash: hashRefreshToken(refreshToken),
+		UserAgent: userAgent,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return s.sessionTokens(session, refreshToken)
+}
+
+func (s *Service) sessionTokens(session *model.Session, refreshToken string) (*response.Tokens, error) {
+	token, err := s.tokenManager.CreateToken(session.UserID, session.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &response.Tokens{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// RefreshToken exchanges refresh token for new access and refresh tokens.
+// A refresh token can be exchanged once. When an exchanged token comes again,
+// either it or its successor is stolen, so the whole session family is revoked.
+func (s *Service) RefreshToken(ctx context.Context, params *request.RefreshToken) (*response.Tokens, error) {
+	session, err := s.storage.GetSessionByTokenHash(ctx, hashRefreshToken(params.RefreshToken))
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return nil, application.ErrUnauthorized
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if !session.RevokedAt.IsZero() || !time.Now().Before(session.ExpiresAt) {
+		return nil, application.ErrUnauthorized
+	}
+	if !session.RotatedAt.IsZero() {
+		return nil, s.revokeReusedSession(ctx, session)
+	}
+
+	refreshToken, err := s.tokenManager.CreateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	rotated, err := s.storage.RotateSession(ctx, &storage.RotateSession{
+		ID:        session.ID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			// the token was exchanged or revoked concurrently
+			return nil, s.revokeReusedSession(ctx, session)
+		}
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	return s.sessionTokens(rotated, refreshToken)
+}
+
+// revokeReusedSession revokes family of the session whose refresh token is used again.
+func (s *Service) revokeReusedSession(ctx context.Context, session *model.Session) error {
+	s.activeSessions.forget(session.FamilyID)
+	err := s.storage.RevokeSession(ctx, &storage.RevokeSession{
+		UserID:   session.UserID,
+		FamilyID: session.FamilyID,
+	})
+	if err != nil && !errors.Is(err, application.ErrNotFound) {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return application.ErrUnauthorized
+}
+
+// Logout revokes the session, so its refresh token can't be exchanged anymore
+// and access tokens issued for it are rejected, see CheckSession.
+func (s *Service) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		// the token was issued before sessions, there is nothing to revoke
+		return nil
+	}
+
+	s.activeSessions.forget(sessionID)
+	err := s.storage.RevokeSession(ctx, &storage.RevokeSession{
+		UserID:   userID,
+		FamilyID: sessionID,
+	})
+	if err != nil && !errors.Is(err, application.ErrNotFound) {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// ListUserSessions returns devices the user is signed in on, current is the session of the request.
+func (s *Service) ListUserSessions(ctx context.Context, userID, currentID uuid.UUID) ([]*response.UserSession, error) {
+	sessions, err := s.storage.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		return nil, application.ErrNoData
+	}
+
+	resp := make([]*response.UserSession, len(sessions))
+	for i, session := range sessions {
+		resp[i] = &response.UserSession{
+			ID:         session.FamilyID.String(),
+			UserAgent:  session.UserAgent,
+			StartedAt:  session.StartedAt.Format(time.RFC3339),
+			LastUsedAt: session.CreatedAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+			Current:    session.FamilyID == currentID,
+		}
+	}
+
+	return resp, nil
+}
+
+// RevokeUserSession signs the user out on the device of the session.
+func (s *Service) RevokeUserSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	s.activeSessions.forget(sessionID)
+	err := s.storage.RevokeSession(ctx, &storage.RevokeSession{
+		UserID:   userID,
+		FamilyID: sessionID,
+	})
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			return application.ErrNotFound
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions signs the user out on all devices.
+func (s *Service) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	s.activeSessions.forgetUser(userID)
+	if err := s.storage.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+// CheckSession returns ErrUnauthorized if the session family the access token is issued for is revoked.
+// Families found active are not checked again for the session check interval, so a family revoked
+// on another instance keeps its tokens working till then. Tokens issued before sessions are not checked.
+func (s *Service) CheckSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return nil
+	}
+
+	now := time.Now()
+	if s.activeSessions.active(userID, sessionID, now) {
+		return nil
+	}
+
+	active, err := s.storage.IsSessionFamilyActive(ctx, &storage.IsSessionFamilyActive{
+		UserID:   userID,
+		FamilyID: sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if !active {
+		return application.ErrUnauthorized
+	}
+
+	if s.sessionCheckInterval > 0 {
+		s.activeSessions.store(userID, sessionID, now.Add(s.sessionCheckInterval), now)
+	}
+
+	return nil
+}
+
+// sessionCache remembers till when session families are known to be active.
+// Expired entries are swept when new ones are stored.
+type sessionCache struct {
+	mu        sync.Mutex
+	families  map[uuid.UUID]cachedSession
+	nextSweep time.Time
+}
+
+type cachedSession struct {
+	userID uuid.UUID
+	until  time.Time
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{
+		families: map[uuid.UUID]cachedSession{},
+	}
+}
+
+func (c *sessionCache) active(userID, familyID uuid.UUID, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.families[familyID]
+
+	return ok && cached.userID == userID && now.Before(cached.until)
+}
+
+func (c *sessionCache) store(userID, familyID uuid.UUID, until, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !now.Before(c.nextSweep) {
+		for id, cached := range c.families {
+			if !now.Before(cached.until) {
+				delete(c.families, id)
+			}
+		}
+		c.nextSweep = until
+	}
+
+	c.families[familyID] = cachedSession{userID: userID, until: until}
+}
+
+func (c *sessionCache) forget(familyID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.families, familyID)
+}
+
+func (c *sessionCache) forgetUser(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cached := range c.families {
+		if cached.userID == userID {
+			delete(c.families, id)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/request"
+	"github.com/dtroode/gophermart/internal/application/response"
+	"github.com/dtroode/gophermart/internal/application/service"
+	mocks "github.com/dtroode/gophermart/internal/application/service/mocks"
+	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSession matches session started with "refresh-token" returned by token manager mock.
+func newSession(userID uuid.UUID, userAgent string) any {
+	return mock.MatchedBy(func(s *model.Session) bool {
+		return s.UserID == userID &&
+			s.TokenHash == tokenHash("refresh-token") &&
+			s.UserAgent == userAgent &&
+			s.ExpiresAt.After(time.Now())
+	})
+}
+
+func TestService_RefreshToken(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "RefreshToken")
+
+	userID := uuid.New()
+	familyID := uuid.New()
+	params := &request.RefreshToken{RefreshToken: "old-refresh-token"}
+
+	session := &model.Session{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	rotate := mock.MatchedBy(func(dto *storage.RotateSession) bool {
+		return dto.ID == session.ID &&
+			dto.TokenHash == tokenHash("refresh-token") &&
+			dto.ExpiresAt.After(time.Now())
+	})
+	revoke := &storage.RevokeSession{UserID: userID, FamilyID: familyID}
+
+	tests := map[string]struct {
+		storageMock      *mocks.Storage
+		tokenManagerMock *mocks.TokenManager
+		expectedResp     *response.Tokens
+		expectedErr      error
+	}{
+		"unknown token": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(nil, application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrUnauthorized,
+		},
+		"failed to get session": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to get session: %w", errors.New("storage error")),
+		},
+		"session is revoked": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(&model.Session{
+					ID:        session.ID,
+					ExpiresAt: session.ExpiresAt,
+					RevokedAt: time.Now(),
+				}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrUnauthorized,
+		},
+		"session is expired": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(&model.Session{
+					ID:        session.ID,
+					ExpiresAt: time.Now().Add(-time.Minute),
+				}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrUnauthorized,
+		},
+		"reused token revokes family": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(&model.Session{
+					ID:        session.ID,
+					FamilyID:  familyID,
+					UserID:    userID,
+					ExpiresAt: session.ExpiresAt,
+					RotatedAt: time.Now(),
+				}, nil)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(nil)
+				return mock
+			}(),
+			expectedErr: application.ErrUnauthorized,
+		},
+		"failed to revoke reused session": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(&model.Session{
+					ID:        session.ID,
+					FamilyID:  familyID,
+					UserID:    userID,
+					ExpiresAt: session.ExpiresAt,
+					RotatedAt: time.Now(),
+				}, nil)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to revoke session: %w", errors.New("storage error")),
+		},
+		"token exchanged concurrently": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(session, nil)
+				mock.On("RotateSession", ctx, rotate).Once().Return(nil, application.ErrNotFound)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				return mock
+			}(),
+			expectedErr: application.ErrUnauthorized,
+		},
+		"failed to rotate session": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(session, nil)
+				mock.On("RotateSession", ctx, rotate).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to rotate session: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("GetSessionByTokenHash", ctx, tokenHash("old-refresh-token")).Once().Return(session, nil)
+				mock.On("RotateSession", ctx, rotate).Once().Return(&model.Session{
+					ID:       uuid.New(),
+					FamilyID: familyID,
+					UserID:   userID,
+				}, nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("CreateRefreshToken").Once().Return("refresh-token", nil)
+				mock.On("CreateToken", userID, familyID).Once().Return("token", nil)
+				return mock
+			}(),
+			expectedResp: &response.Tokens{AccessToken: "token", RefreshToken: "refresh-token"},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, tt.tokenManagerMock, nil, nil)
+
+			resp, err := s.RefreshToken(ctx, params)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_Logout(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "Logout")
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	revoke := &storage.RevokeSession{UserID: userID, FamilyID: sessionID}
+
+	tests := map[string]struct {
+		sessionID   uuid.UUID
+		storageMock *mocks.Storage
+		expectedErr error
+	}{
+		"token without session": {
+			sessionID: uuid.Nil,
+		},
+		"already revoked": {
+			sessionID: sessionID,
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(application.ErrNotFound)
+				return mock
+			}(),
+		},
+		"failed to revoke session": {
+			sessionID: sessionID,
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to revoke session: %w", errors.New("storage error")),
+		},
+		"success": {
+			sessionID: sessionID,
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(nil)
+				return mock
+			}(),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			err := s.Logout(ctx, userID, tt.sessionID)
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestService_ListUserSessions(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "ListUserSessions")
+
+	userID := uuid.New()
+	currentID := uuid.New()
+	otherID := uuid.New()
+	startedAt := time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		storageMock  *mocks.Storage
+		expectedResp []*response.UserSession
+		expectedErr  error
+	}{
+		"storage error": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListUserSessions", ctx, userID).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to list user sessions: %w", errors.New("storage error")),
+		},
+		"no sessions": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListUserSessions", ctx, userID).Once().Return([]*model.Session{}, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrNoData,
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListUserSessions", ctx, userID).Once().Return([]*model.Session{
+					{
+						FamilyID:  currentID,
+						UserAgent: "phone",
+						StartedAt: startedAt,
+						CreatedAt: startedAt.Add(time.Hour),
+						ExpiresAt: startedAt.Add(30 * 24 * time.Hour),
+					},
+					{
+						FamilyID:  otherID,
+						StartedAt: startedAt,
+						CreatedAt: startedAt,
+						ExpiresAt: startedAt.Add(time.Hour),
+					},
+				}, nil)
+				return mock
+			}(),
+			expectedResp: []*response.UserSession{
+				{
+					ID:         currentID.String(),
+					UserAgent:  "phone",
+					StartedAt:  "2025-06-20T10:00:00Z",
+					LastUsedAt: "2025-06-20T11:00:00Z",
+					ExpiresAt:  "2025-07-20T10:00:00Z",
+					Current:    true,
+				},
+				{
+					ID:         otherID.String(),
+					StartedAt:  "2025-06-20T10:00:00Z",
+					LastUsedAt: "2025-06-20T10:00:00Z",
+					ExpiresAt:  "2025-06-20T11:00:00Z",
+				},
+			},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			resp, err := s.ListUserSessions(ctx, userID, currentID)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedResp, resp)
+		})
+	}
+}
+
+func TestService_RevokeUserSession(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "RevokeUserSession")
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	revoke := &storage.RevokeSession{UserID: userID, FamilyID: sessionID}
+
+	tests := map[string]struct {
+		storageMock *mocks.Storage
+		expectedErr error
+	}{
+		"not found": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(application.ErrNotFound)
+				return mock
+			}(),
+			expectedErr: application.ErrNotFound,
+		},
+		"storage error": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to revoke session: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeSession", ctx, revoke).Once().Return(nil)
+				return mock
+			}(),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			err := s.RevokeUserSession(ctx, userID, sessionID)
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestService_RevokeUserSessions(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "RevokeUserSessions")
+
+	userID := uuid.New()
+
+	tests := map[string]struct {
+		storageMock *mocks.Storage
+		expectedErr error
+	}{
+		"storage error": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeUserSessions", ctx, userID).Once().Return(errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to revoke user sessions: %w", errors.New("storage error")),
+		},
+		"success": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("RevokeUserSessions", ctx, userID).Once().Return(nil)
+				return mock
+			}(),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			err := s.RevokeUserSessions(ctx, userID)
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestService_CheckSession(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "CheckSession")
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	family := &storage.IsSessionFamilyActive{UserID: userID, FamilyID: sessionID}
+
+	tests := map[string]struct {
+		sessionID   uuid.UUID
+		storageMock *mocks.Storage
+		expectedErr error
+	}{
+		"token without session": {
+			sessionID: uuid.Nil,
+		},
+		"failed to check session": {
+			sessionID: sessionID,
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("IsSessionFamilyActive", ctx, family).Once().Return(false, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to check session: %w", errors.New("storage error")),
+		},
+		"session is revoked": {
+			sessionID: sessionID,
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("IsSessionFamilyActive", ctx, family).Once().Return(false, nil)
+				return mock
+			}(),
+			expectedErr: application.ErrUnauthorized,
+		},
+		"success": {
+			sessionID: sessionID,
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("IsSessionFamilyActive", ctx, family).Once().Return(true, nil)
+				return mock
+			}(),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, nil, nil, nil)
+
+			err := s.CheckSession(ctx, userID, tt.sessionID)
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+
+	t.Run("active session is cached till revoked", func(t *testing.T) {
+		storageMock := mocks.NewStorage(t)
+		storageMock.On("IsSessionFamilyActive", ctx, family).Once().Return(true, nil)
+		storageMock.On("RevokeSession", ctx, &storage.RevokeSession{UserID: userID, FamilyID: sessionID}).Once().Return(nil)
+		storageMock.On("IsSessionFamilyActive", ctx, family).Once().Return(false, nil)
+
+		s := service.NewService(storageMock, nil, nil, nil, nil)
+
+		require.NoError(t, s.CheckSession(ctx, userID, sessionID))
+		require.NoError(t, s.CheckSession(ctx, userID, sessionID))
+		// the token of other user is not taken from cache
+		other := &storage.IsSessionFamilyActive{UserID: uuid.Max, FamilyID: sessionID}
+		storageMock.On("IsSessionFamilyActive", ctx, other).Once().Return(false, nil)
+		assert.ErrorIs(t, s.CheckSession(ctx, uuid.Max, sessionID), application.ErrUnauthorized)
+
+		require.NoError(t, s.RevokeUserSession(ctx, userID, sessionID))
+		assert.ErrorIs(t, s.CheckSession(ctx, userID, sessionID), application.ErrUnauthorized)
+	})
+
+	t.Run("sessions are not cached without interval", func(t *testing.T) {
+		storageMock := mocks.NewStorage(t)
+		storageMock.On("IsSessionFamilyActive", ctx, family).Twice().Return(true, nil)
+
+		s := service.NewService(storageMock, nil, nil, nil, nil, service.WithSessionCheckInterval(0))
+
+		require.NoError(t, s.CheckSession(ctx, userID, sessionID))
+		require.NoError(t, s.CheckSession(ctx, userID, sessionID))
+	})
+}
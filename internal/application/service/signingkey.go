@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+)
+
+// RotateSigningKeys makes sure there is a key signing access tokens now and, once the current key
+// is about to retire, the next one. The next key signs from the moment the current one retires,
+// so it is published for the publish lead before verifiers see tokens signed by it.
+// Then keys are loaded from storage, which picks up keys created by other instances.
+func (s *Service) RotateSigningKeys(ctx context.Context) error {
+	keys, err := s.storage.ListSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	now := time.Now()
+
+	var last *model.SigningKey
+	for _, key := range keys {
+		if last == nil || key.RetireAt.After(last.RetireAt) {
+			last = key
+		}
+	}
+
+	if last == nil || !last.RetireAt.After(now.Add(s.signingKeyPublishLead)) {
+		activeAt := now
+		if last != nil && last.RetireAt.After(now) {
+			activeAt = last.RetireAt
+		}
+
+		key, err := s.tokenManager.GenerateSigningKey(activeAt, activeAt.Add(s.signingKeyRotation))
+		if err != nil {
+			return fmt.Errorf("failed to generate signing key: %w", err)
+		}
+
+		// on conflict another instance has saved the next key, it is loaded below
+		if _, err := s.storage.SaveSigningKey(ctx, key); err != nil && !errors.Is(err, application.ErrConflict) {
+			return fmt.Errorf("failed to save signing key: %w", err)
+		}
+
+		keys, err = s.storage.ListSigningKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list signing keys: %w", err)
+		}
+	}
+
+	if err := s.tokenManager.SetSigningKeys(keys); err != nil {
+		return fmt.Errorf("failed to set signing keys: %w", err)
+	}
+
+	if err := s.storage.DeleteExpiredSigningKeys(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired signing keys: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/service"
+	mocks "github.com/dtroode/gophermart/internal/application/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_RotateSigningKeys(t *testing.T) {
+	ctx := context.WithValue(context.Background(), tnk, "RotateSigningKeys")
+
+	now := time.Now()
+	rotation := 24 * time.Hour
+	lead := time.Hour
+
+	current := &model.SigningKey{ID: uuid.New(), ActiveAt: now.Add(-time.Hour), RetireAt: now.Add(10 * time.Hour)}
+	retiring := &model.SigningKey{ID: uuid.New(), ActiveAt: now.Add(-23 * time.Hour), RetireAt: now.Add(30 * time.Minute)}
+	retired := &model.SigningKey{ID: uuid.New(), ActiveAt: now.Add(-25 * time.Hour), RetireAt: now.Add(-time.Hour)}
+	generated := &model.SigningKey{ID: uuid.New()}
+
+	// activeNow matches activation time of the key generated when no key signs now
+	activeNow := mock.MatchedBy(func(activeAt time.Time) bool {
+		return !activeAt.Before(now) && activeAt.Before(time.Now())
+	})
+	retireInRotation := mock.MatchedBy(func(retireAt time.Time) bool {
+		return retireAt.Sub(now) >= rotation && retireAt.Sub(time.Now()) <= rotation
+	})
+
+	tests := map[string]struct {
+		storageMock      *mocks.Storage
+		tokenManagerMock *mocks.TokenManager
+		expectedErr      error
+	}{
+		"failed to list signing keys": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to list signing keys: %w", errors.New("storage error")),
+		},
+		"no keys": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return(nil, nil)
+				mock.On("SaveSigningKey", ctx, generated).Once().Return(generated, nil)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{generated}, nil)
+				mock.On("DeleteExpiredSigningKeys", ctx).Once().Return(nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("GenerateSigningKey", activeNow, retireInRotation).Once().Return(generated, nil)
+				mock.On("SetSigningKeys", []*model.SigningKey{generated}).Once().Return(nil)
+				return mock
+			}(),
+		},
+		"current key signs longer than publish lead": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retired, current}, nil)
+				mock.On("DeleteExpiredSigningKeys", ctx).Once().Return(nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("SetSigningKeys", []*model.SigningKey{retired, current}).Once().Return(nil)
+				return mock
+			}(),
+		},
+		"current key is about to retire": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retiring}, nil)
+				mock.On("SaveSigningKey", ctx, generated).Once().Return(generated, nil)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retiring, generated}, nil)
+				mock.On("DeleteExpiredSigningKeys", ctx).Once().Return(nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				// the next key signs right after the current one retires
+				mock.On("GenerateSigningKey", retiring.RetireAt, retiring.RetireAt.Add(rotation)).Once().Return(generated, nil)
+				mock.On("SetSigningKeys", []*model.SigningKey{retiring, generated}).Once().Return(nil)
+				return mock
+			}(),
+		},
+		"last key is retired": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retired}, nil)
+				mock.On("SaveSigningKey", ctx, generated).Once().Return(generated, nil)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retired, generated}, nil)
+				mock.On("DeleteExpiredSigningKeys", ctx).Once().Return(nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("GenerateSigningKey", activeNow, retireInRotation).Once().Return(generated, nil)
+				mock.On("SetSigningKeys", []*model.SigningKey{retired, generated}).Once().Return(nil)
+				return mock
+			}(),
+		},
+		"other instance saved the next key": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retiring}, nil)
+				mock.On("SaveSigningKey", ctx, generated).Once().Return(nil, application.ErrConflict)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{retiring, current}, nil)
+				mock.On("DeleteExpiredSigningKeys", ctx).Once().Return(nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("GenerateSigningKey", retiring.RetireAt, retiring.RetireAt.Add(rotation)).Once().Return(generated, nil)
+				mock.On("SetSigningKeys", []*model.SigningKey{retiring, current}).Once().Return(nil)
+				return mock
+			}(),
+		},
+		"failed to generate signing key": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return(nil, nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("GenerateSigningKey", activeNow, retireInRotation).Once().Return(nil, errors.New("key error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to generate signing key: %w", errors.New("key error")),
+		},
+		"failed to save signing key": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return(nil, nil)
+				mock.On("SaveSigningKey", ctx, generated).Once().Return(nil, errors.New("storage error"))
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("GenerateSigningKey", activeNow, retireInRotation).Once().Return(generated, nil)
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to save signing key: %w", errors.New("storage error")),
+		},
+		"failed to set signing keys": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{current}, nil)
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("SetSigningKeys", []*model.SigningKey{current}).Once().Return(errors.New("key error"))
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to set signing keys: %w", errors.New("key error")),
+		},
+		"failed to delete expired signing keys": {
+			storageMock: func() *mocks.Storage {
+				mock := mocks.NewStorage(t)
+				mock.On("ListSigningKeys", ctx).Once().Return([]*model.SigningKey{current}, nil)
+				mock.On("DeleteExpiredSigningKeys", ctx).Once().Return(errors.New("storage error"))
+				return mock
+			}(),
+			tokenManagerMock: func() *mocks.TokenManager {
+				mock := mocks.NewTokenManager(t)
+				mock.On("SetSigningKeys", []*model.SigningKey{current}).Once().Return(nil)
+				return mock
+			}(),
+			expectedErr: fmt.Errorf("failed to delete expired signing keys: %w", errors.New("storage error")),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			s := service.NewService(tt.storageMock, nil, tt.tokenManagerMock, nil, nil,
+				service.WithSigningKeyRotation(rotation),
+				service.WithSigningKeyPublishLead(lead),
+			)
+
+			err := s.RotateSigningKeys(ctx)
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"time"
+
 	"github.com/dtroode/gophermart/internal/application/model"
 	"github.com/google/uuid"
 )
@@ -21,11 +23,61 @@ type SetOrderStatusAndAccrual struct {
 	Accrual int32
 }
 
+type RecordOrderAttempt struct {
+	ID uuid.UUID
+	// Attempts is the number of failed checks in a row, successful check resets it
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     string
+	// PublicError is the message about the failed check safe to show the user
+	PublicError string
+}
+
+type PostponeOrderCheck struct {
+	ID            uuid.UUID
+	NextAttemptAt time.Time
+}
+
+type ClaimDueOrders struct {
+	BatchSize  int32
+	LeaseUntil time.Time
+}
+
+type DeadLetterOrder struct {
+	ID          uuid.UUID
+	Error       string
+	PublicError string
+}
+
+type ClaimOrdersForReconciliation struct {
+	BatchSize        int32
+	FinalizedAfter   time.Time
+	ReconciledBefore time.Time
+	LeaseUntil       time.Time
+}
+
+type AdjustOrderDiscrepancy struct {
+	ID     uuid.UUID
+	Status model.OrderStatus
+	Reason string
+}
+
 type SetUserBalance struct {
 	ID      uuid.UUID
 	Balance int32
 }
 
+// GetUserByLogin finds user with exactly the login, or else with the login key.
+type GetUserByLogin struct {
+	Login    string
+	LoginKey string
+}
+
+type SetUserPassword struct {
+	ID       uuid.UUID
+	Password string
+}
+
 type IncrementUserBalance struct {
 	ID  uuid.UUID
 	Sum int32
@@ -36,3 +88,19 @@ type WithdrawUserBonuses struct {
 	OrderNum string
 	Sum      int32
 }
+
+type RotateSession struct {
+	ID        uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type RevokeSession struct {
+	UserID   uuid.UUID
+	FamilyID uuid.UUID
+}
+
+type IsSessionFamilyActive struct {
+	UserID   uuid.UUID
+	FamilyID uuid.UUID
+}
@@ -2,39 +2,157 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/argon2"
 )
 
+const argonSaltLen = 16
+
+// Parameters of hashes made before PHC strings. They are not kept in the hash,
+// so they stay fixed whatever parameters new hashes are made with.
+const (
+	legacyTime    = 1
+	legacyMemory  = 47104
+	legacyThreads = 1
+	legacyKeyLen  = 32
+)
+
+// ErrInvalidHash is returned for hash strings that are not produced by Argon2Id.
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// Argon2Id hashes passwords with random salt into PHC strings,
+// like $argon2id$v=19$m=47104,t=1,p=1$salt$hash, which keep the parameters the hash was made with.
 type Argon2Id struct {
-	salt    []byte
-	time    uint32
-	memory  uint32
-	threads uint8
-	keyLen  uint32
+	// legacySalt is the salt shared by hashes made before PHC strings, they are plain base64 keys.
+	legacySalt []byte
+	time       uint32
+	memory     uint32
+	threads    uint8
+	keyLen     uint32
 }
 
 func NewArgon2Id(
-	salt []byte,
+	legacySalt []byte,
 	time uint32,
 	memory uint32,
 	threads uint8,
 	keyLen uint32,
 ) *Argon2Id {
 	return &Argon2Id{
-		salt:    salt,
-		time:    time,
-		memory:  memory,
-		threads: threads,
-		keyLen:  keyLen,
+		legacySalt: legacySalt,
+		time:       time,
+		memory:     memory,
+		threads:    threads,
+		keyLen:     keyLen,
 	}
 }
 
+type argonParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
 func (a *Argon2Id) Hash(_ context.Context, password []byte) (string, error) {
-	key := argon2.IDKey(password, a.salt, a.time, a.memory, a.threads, a.keyLen)
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey(password, salt, a.time, a.memory, a.threads, a.keyLen)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.memory,
+		a.time,
+		a.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether password matches hash. Hashes made before PHC strings are still accepted.
+func (a *Argon2Id) Verify(_ context.Context, password []byte, hash string) (bool, error) {
+	if !isPHC(hash) {
+		key := argon2.IDKey(password, a.legacySalt, legacyTime, legacyMemory, legacyThreads, legacyKeyLen)
+		encoded := base64.StdEncoding.EncodeToString(key)
+
+		return subtle.ConstantTimeCompare([]byte(encoded), []byte(hash)) == 1, nil
+	}
+
+	params, err := parsePHC(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey(password, params.salt, params.time, params.memory, params.threads, uint32(len(params.key)))
+
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+// NeedsRehash reports whether hash is made with other parameters than the configured ones,
+// or with the shared legacy salt, so it should be replaced at the next successful login.
+func (a *Argon2Id) NeedsRehash(hash string) bool {
+	if !isPHC(hash) {
+		return true
+	}
+
+	params, err := parsePHC(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.time != a.time ||
+		params.memory != a.memory ||
+		params.threads != a.threads ||
+		uint32(len(params.key)) != a.keyLen ||
+		len(params.salt) != argonSaltLen
+}
+
+func isPHC(hash string) bool {
+	return strings.HasPrefix(hash, "$")
+}
+
+func parsePHC(hash string) (*argonParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHash, version)
+	}
+
+	params := &argonParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if params.time < 1 || params.threads < 1 {
+		return nil, ErrInvalidHash
+	}
+
+	var err error
+	params.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, ErrInvalidHash
+	}
+	params.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(params.key) == 0 {
+		return nil, ErrInvalidHash
+	}
 
-	hash := make([]byte, base64.StdEncoding.EncodedLen(len(key)))
-	base64.StdEncoding.Encode(hash, key)
-	return string(hash), nil
+	return params, nil
 }
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestArgon2Id_Hash(t *testing.T) {
+	a := NewArgon2Id([]byte("saltsalt"), 1, 64, 1, 32)
+
+	first, err := a.Hash(context.Background(), []byte("password"))
+	require.NoError(t, err)
+	second, err := a.Hash(context.Background(), []byte("password"))
+	require.NoError(t, err)
+
+	// every hash gets its own salt
+	assert.NotEqual(t, first, second)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=64,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, first)
+	assert.False(t, a.NeedsRehash(first))
+}
+
+func TestArgon2Id_Verify(t *testing.T) {
+	a := NewArgon2Id([]byte("saltsalt"), 1, 64, 1, 32)
+
+	hash, err := a.Hash(context.Background(), []byte("password"))
+	require.NoError(t, err)
+
+	// legacy hashes are checked with their own parameters, not the ones new hashes are made with
+	legacyKey := argon2.IDKey([]byte("password"), []byte("saltsalt"), legacyTime, legacyMemory, legacyThreads, legacyKeyLen)
+	legacyHash := base64.StdEncoding.EncodeToString(legacyKey)
+
+	tests := map[string]struct {
+		password      string
+		hash          string
+		expectedMatch bool
+		expectedErr   error
+	}{
+		"password matches": {
+			password:      "password",
+			hash:          hash,
+			expectedMatch: true,
+		},
+		"password doesn't match": {
+			password: "wrong-password",
+			hash:     hash,
+		},
+		"legacy hash matches": {
+			password:      "password",
+			hash:          legacyHash,
+			expectedMatch: true,
+		},
+		"legacy hash doesn't match": {
+			password: "wrong-password",
+			hash:     legacyHash,
+		},
+		"other algorithm": {
+			password:    "password",
+			hash:        "$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+			expectedErr: ErrInvalidHash,
+		},
+		"malformed parameters": {
+			password:    "password",
+			hash:        "$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$aGFzaA",
+			expectedErr: ErrInvalidHash,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			match, err := a.Verify(context.Background(), []byte(tt.password), tt.hash)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedMatch, match)
+		})
+	}
+}
+
+func TestArgon2Id_NeedsRehash(t *testing.T) {
+	old := NewArgon2Id([]byte("saltsalt"), 1, 64, 1, 32)
+
+	hash, err := old.Hash(context.Background(), []byte("password"))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		hasher   *Argon2Id
+		hash     string
+		expected bool
+	}{
+		"same parameters": {
+			hasher: old,
+			hash:   hash,
+		},
+		"other time": {
+			hasher:   NewArgon2Id([]byte("saltsalt"), 2, 64, 1, 32),
+			hash:     hash,
+			expected: true,
+		},
+		"other memory": {
+			hasher:   NewArgon2Id([]byte("saltsalt"), 1, 128, 1, 32),
+			hash:     hash,
+			expected: true,
+		},
+		"other key length": {
+			hasher:   NewArgon2Id([]byte("saltsalt"), 1, 64, 1, 16),
+			hash:     hash,
+			expected: true,
+		},
+		"legacy hash": {
+			hasher:   old,
+			hash:     base64.StdEncoding.EncodeToString(argon2.IDKey([]byte("password"), []byte("saltsalt"), 1, 64, 1, 32)),
+			expected: true,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, tt.hasher.NeedsRehash(tt.hash))
+		})
+	}
+}
@@ -10,6 +10,7 @@ type contextKey int
 
 const (
 	userIDKey contextKey = iota + 1
+	sessionIDKey
 )
 
 func SetUserIDToContext(ctx context.Context, userID uuid.UUID) context.Context {
@@ -20,3 +21,12 @@ func GetUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	u, ok := ctx.Value(userIDKey).(uuid.UUID)
 	return u, ok
 }
+
+func SetSessionIDToContext(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+func GetSessionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	s, ok := ctx.Value(sessionIDKey).(uuid.UUID)
+	return s, ok
+}
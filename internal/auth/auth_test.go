@@ -29,3 +29,13 @@ func Test_GetUserIDFromContext(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, userID, contextUserID)
 }
+
+func Test_GetSessionIDFromContext(t *testing.T) {
+	sessionID := uuid.New()
+	ctx := SetSessionIDToContext(context.Background(), sessionID)
+
+	contextSessionID, ok := GetSessionIDFromContext(ctx)
+
+	require.True(t, ok)
+	assert.Equal(t, sessionID, contextSessionID)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// jwksMaxAge is for how long verifiers may cache the key set. Keys are published
+// before they sign, for longer than that, so cached sets know every signing key.
+const jwksMaxAge = 5 * time.Minute
+
+// JWK is public key in JSON Web Key format, RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// Curve and X are set for Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	// N and E are set for RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public keys tokens can be verified with, including keys that don't sign yet.
+func (j *JWT) JWKS() *JWKS {
+	now := time.Now()
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	set := &JWKS{Keys: make([]JWK, 0, len(j.keys))}
+	for _, k := range j.keys {
+		if !now.Before(k.expiresAt) {
+			continue
+		}
+
+		jwk := JWK{
+			Use:       "sig",
+			Algorithm: k.method.Alg(),
+			KeyID:     k.id,
+		}
+		switch public := k.private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// ServeHTTP serves the key set for /.well-known/jwks.json.
+func (j *JWT) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	w.WriteHeader(http.StatusOK)
+
+	_ = json.NewEncoder(w).Encode(j.JWKS())
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWT_JWKS(t *testing.T) {
+	now := time.Now()
+
+	j, err := NewJWT(AlgorithmEdDSA, 15*time.Minute, testKEK)
+	require.NoError(t, err)
+	rs, err := NewJWT(AlgorithmRS256, 15*time.Minute, testKEK)
+	require.NoError(t, err)
+
+	edKey, err := j.GenerateSigningKey(now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	rsKey, err := rs.GenerateSigningKey(now.Add(-2*time.Hour), now.Add(-time.Minute))
+	require.NoError(t, err)
+	nextKey, err := j.GenerateSigningKey(now.Add(time.Hour), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	expiredKey, err := j.GenerateSigningKey(now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, j.SetSigningKeys([]*model.SigningKey{expiredKey, rsKey, edKey, nextKey}))
+
+	w := httptest.NewRecorder()
+	j.ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("content-type"))
+	assert.Equal(t, "public, max-age=300", res.Header.Get("cache-control"))
+
+	set := &JWKS{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(set))
+
+	// expired key is not published, retired one is till it expires and the next one before it signs
+	require.Len(t, set.Keys, 3)
+	keys := make(map[string]JWK)
+	for _, k := range set.Keys {
+		assert.Equal(t, "sig", k.Use)
+		keys[k.KeyID] = k
+	}
+	assert.Equal(t, "RSA", keys[rsKey.ID.String()].KeyType)
+	assert.Equal(t, AlgorithmRS256, keys[rsKey.ID.String()].Algorithm)
+	assert.Equal(t, "OKP", keys[edKey.ID.String()].KeyType)
+	assert.Equal(t, "Ed25519", keys[edKey.ID.String()].Curve)
+	assert.Equal(t, AlgorithmEdDSA, keys[nextKey.ID.String()].Algorithm)
+
+	// tokens are verified with published keys alone
+	userID := uuid.New()
+	tokenString, err := j.CreateToken(userID, uuid.New())
+	require.NoError(t, err)
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey(t, keys[token.Header["kid"].(string)]), nil
+	}, jwt.WithValidMethods([]string{AlgorithmEdDSA}))
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+
+	rsPublic := publicKey(t, keys[rsKey.ID.String()]).(*rsa.PublicKey)
+	assert.Equal(t, 65537, rsPublic.E)
+}
+
+// publicKey decodes key like a third party verifier would.
+func publicKey(t *testing.T, jwk JWK) any {
+	t.Helper()
+
+	switch jwk.KeyType {
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		require.NoError(t, err)
+		return ed25519.PublicKey(x)
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		require.NoError(t, err)
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	t.Fatalf("unexpected key type %q", jwk.KeyType)
+	return nil
+}
@@ -1,63 +1,320 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/dtroode/gophermart/internal/application/model"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+const (
+	// refreshTokenLen is the number of random bytes in refresh token.
+	refreshTokenLen = 32
+
+	rsaKeyBits = 2048
+
+	// KeyEncryptionKeyLen is the length of AES-256 key private signing keys are encrypted with.
+	KeyEncryptionKeyLen = 32
+)
+
+// Algorithms access tokens can be signed with.
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+)
+
+var (
+	// ErrUnsupportedAlgorithm is returned for signing algorithms other than EdDSA and RS256.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	// ErrNoSigningKey is returned when no key signs tokens at the moment.
+	ErrNoSigningKey = errors.New("no active signing key")
+	// ErrInvalidKeyEncryptionKey is returned for key encryption key of wrong length.
+	ErrInvalidKeyEncryptionKey = errors.New("key encryption key must be 32 bytes long")
+)
+
 type Claims struct {
 	jwt.RegisteredClaims
 	UserID uuid.UUID `json:"user_id"`
+	// SessionID is the session the token is issued for, tokens issued before sessions have nil one.
+	SessionID uuid.UUID `json:"session_id"`
 }
 
+// JWT signs access tokens with asymmetric keys identified by kid header,
+// so they can be verified by anyone knowing public keys, see JWKS.
+// Keys are generated and scheduled by the caller and loaded with SetSigningKeys.
+// Private keys leave JWT only encrypted with the key encryption key.
 type JWT struct {
-	secretKey string
+	algorithm string
+	ttl       time.Duration
+	kek       cipher.AEAD
+
+	mu   sync.RWMutex
+	keys []*signingKey
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	activeAt  time.Time
+	retireAt  time.Time
+	expiresAt time.Time
 }
 
-func NewJWT(secretKey string) *JWT {
+// NewJWT creates token manager issuing access tokens valid for ttl
+// and generating keys for algorithm, EdDSA or RS256. Private keys are encrypted
+// with AES-GCM under keyEncryptionKey, which must be 32 bytes long.
+func NewJWT(algorithm string, ttl time.Duration, keyEncryptionKey []byte) (*JWT, error) {
+	if signingMethod(algorithm) == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if len(keyEncryptionKey) != KeyEncryptionKeyLen {
+		return nil, ErrInvalidKeyEncryptionKey
+	}
+
+	block, err := aes.NewCipher(keyEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key cipher: %w", err)
+	}
+	kek, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key cipher: %w", err)
+	}
+
 	return &JWT{
-		secretKey: secretKey,
+		algorithm: algorithm,
+		ttl:       ttl,
+		kek:       kek,
+	}, nil
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	default:
+		return nil
+	}
+}
+
+// GenerateSigningKey generates key signing tokens from activeAt till retireAt.
+// It verifies tokens for one more token lifetime after it retires.
+func (j *JWT) GenerateSigningKey(activeAt, retireAt time.Time) (*model.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch j.algorithm {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	id := uuid.New()
+	// key id is authenticated with the key, so encrypted keys can't be swapped between rows
+	nonce := make([]byte, j.kek.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &model.SigningKey{
+		ID:         id,
+		Algorithm:  j.algorithm,
+		PrivateKey: j.kek.Seal(nonce, nonce, der, id[:]),
+		ActiveAt:   activeAt,
+		RetireAt:   retireAt,
+		ExpiresAt:  retireAt.Add(j.ttl),
+	}, nil
+}
+
+// SetSigningKeys decrypts keys and replaces keys tokens are signed and verified with.
+// If any key can't be decrypted or parsed, the keys are left as they were.
+func (j *JWT) SetSigningKeys(keys []*model.SigningKey) error {
+	parsed := make([]*signingKey, 0, len(keys))
+	for _, key := range keys {
+		k, err := j.parseSigningKey(key)
+		if err != nil {
+			return fmt.Errorf("failed to parse signing key %s: %w", key.ID, err)
+		}
+		parsed = append(parsed, k)
+	}
+
+	j.mu.Lock()
+	j.keys = parsed
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (j *JWT) parseSigningKey(key *model.SigningKey) (*signingKey, error) {
+	method := signingMethod(key.Algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, key.Algorithm)
+	}
+
+	nonceSize := j.kek.NonceSize()
+	if len(key.PrivateKey) < nonceSize {
+		return nil, errors.New("encrypted key is too short")
+	}
+	der, err := j.kek.Open(nil, key.PrivateKey[:nonceSize], key.PrivateKey[nonceSize:], key.ID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key: %w", err)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto.Signer
+	switch p := private.(type) {
+	case ed25519.PrivateKey:
+		if key.Algorithm == AlgorithmEdDSA {
+			signer = p
+		}
+	case *rsa.PrivateKey:
+		if key.Algorithm == AlgorithmRS256 {
+			signer = p
+		}
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("%T is not %s key", private, key.Algorithm)
+	}
+
+	return &signingKey{
+		id:        key.ID.String(),
+		method:    method,
+		private:   signer,
+		activeAt:  key.ActiveAt,
+		retireAt:  key.RetireAt,
+		expiresAt: key.ExpiresAt,
+	}, nil
+}
+
+// activeKey returns the key activated last among those signing at now.
+func (j *JWT) activeKey(now time.Time) *signingKey {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	var active *signingKey
+	for _, k := range j.keys {
+		if now.Before(k.activeAt) || !now.Before(k.retireAt) {
+			continue
+		}
+		if active == nil || k.activeAt.After(active.activeAt) {
+			active = k
+		}
+	}
+
+	return active
+}
+
+// verificationKey returns the key with id, if it is not expired at now.
+func (j *JWT) verificationKey(id string, now time.Time) *signingKey {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	for _, k := range j.keys {
+		if k.id == id && now.Before(k.expiresAt) {
+			return k
+		}
 	}
+
+	return nil
 }
 
 func (j *JWT) GetUserID(tokenString string) (uuid.UUID, error) {
+	claims, err := j.ParseToken(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+// ParseToken checks signature and expiration of the token and returns its claims.
+// The token is accepted if it is signed by any key that is not expired yet.
+func (j *JWT) ParseToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := t.Header["kid"].(string)
+		key := j.verificationKey(kid, time.Now())
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if t.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("wrong signing method %v", t.Header["alg"])
 		}
 
-		return []byte(j.secretKey), nil
-	})
+		return key.private.Public(), nil
+	}, jwt.WithValidMethods([]string{AlgorithmEdDSA, AlgorithmRS256}))
 
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to parse token '%s': %w", tokenString, err)
+		return nil, fmt.Errorf("failed to parse token '%s': %w", tokenString, err)
 	}
 
 	if !token.Valid {
-		return uuid.Nil, fmt.Errorf("token is invalid")
+		return nil, fmt.Errorf("token is invalid")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
 
-func (j *JWT) CreateToken(userID uuid.UUID) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+func (j *JWT) CreateToken(userID, sessionID uuid.UUID) (string, error) {
+	now := time.Now()
+
+	key := j.activeKey(now)
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(key.method, Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.ttl)),
 		},
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 	})
+	token.Header["kid"] = key.id
 
-	tokenString, err := token.SignedString([]byte(j.secretKey))
+	tokenString, err := token.SignedString(key.private)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 
 	return tokenString, nil
 }
+
+// CreateRefreshToken returns random opaque token, it is exchanged for new tokens of the session.
+func (j *JWT) CreateRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// maxLoginLength is the width of login column.
+	maxLoginLength = 64
+	// maxPasswordLength limits the work of hashing a password.
+	maxPasswordLength = 1024
+)
+
+// Rules of credential policy named in PolicyViolation.
+const (
+	RuleMinLength  = "min_length"
+	RuleMaxLength  = "max_length"
+	RuleCharset    = "charset"
+	RuleComplexity = "complexity"
+	RuleBreached   = "breached"
+)
+
+var ErrInvalidPolicy = errors.New("invalid credential policy")
+
+type CredentialPolicyConfig struct {
+	// LoginMinLength and LoginMaxLength are counted in characters, the maximum can't exceed 64.
+	LoginMinLength int
+	LoginMaxLength int
+	// LoginPattern is regular expression the whole login must match, empty allows any characters.
+	LoginPattern string
+	// PasswordMinLength is counted in characters.
+	PasswordMinLength int
+	// PasswordMinClasses is how many of lowercase letters, uppercase letters, digits
+	// and other characters the password must contain.
+	PasswordMinClasses int
+	// BreachedPasswordsFile lists passwords known from breaches, one per line,
+	// which are not accepted. Empty disables the check.
+	BreachedPasswordsFile string
+}
+
+// CredentialPolicy checks credentials of new users. Logins are compared in NFKC form
+// with case folded, so logins that look the same can't be registered twice.
+type CredentialPolicy struct {
+	cfg          CredentialPolicyConfig
+	loginPattern *regexp.Regexp
+	breached     map[string]struct{}
+}
+
+// NewCredentialPolicy creates policy, loading breached passwords if the file is set.
+func NewCredentialPolicy(cfg CredentialPolicyConfig) (*CredentialPolicy, error) {
+	if cfg.LoginMaxLength < 1 || cfg.LoginMaxLength > maxLoginLength {
+		return nil, fmt.Errorf("%w: login max length must be from 1 to %d", ErrInvalidPolicy, maxLoginLength)
+	}
+	if cfg.LoginMinLength > cfg.LoginMaxLength {
+		return nil, fmt.Errorf("%w: login min length exceeds max length", ErrInvalidPolicy)
+	}
+	if cfg.PasswordMinLength > maxPasswordLength {
+		return nil, fmt.Errorf("%w: password min length can't exceed %d", ErrInvalidPolicy, maxPasswordLength)
+	}
+	if cfg.PasswordMinClasses > 4 {
+		return nil, fmt.Errorf("%w: password can't contain more than 4 classes of characters", ErrInvalidPolicy)
+	}
+
+	p := &CredentialPolicy{cfg: cfg}
+
+	if cfg.LoginPattern != "" {
+		re, err := regexp.Compile(`^(?:` + cfg.LoginPattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to compile login pattern: %w", ErrInvalidPolicy, err)
+		}
+		p.loginPattern = re
+	}
+
+	if cfg.BreachedPasswordsFile != "" {
+		breached, err := readBreachedPasswords(cfg.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = breached
+	}
+
+	return p, nil
+}
+
+// NormalizeLogin returns login in NFKC form, which is stored, and the key logins are unique by.
+func (p *CredentialPolicy) NormalizeLogin(login string) (string, string) {
+	normalized := norm.NFKC.String(login)
+	// folding case may break the normal form, so the key is normalized once more;
+	// caser is stateful, so it is not shared between calls
+	key := norm.NFKC.String(cases.Fold().String(normalized))
+
+	return normalized, key
+}
+
+// Check returns PolicyError listing every rule the normalized login and the password break.
+func (p *CredentialPolicy) Check(login, password string) error {
+	var violations []application.PolicyViolation
+	violate := func(field, rule, message string) {
+		violations = append(violations, application.PolicyViolation{
+			Field:   field,
+			Rule:    rule,
+			Message: message,
+		})
+	}
+
+	loginLength := utf8.RuneCountInString(login)
+	switch {
+	case loginLength < p.cfg.LoginMinLength:
+		violate("login", RuleMinLength, fmt.Sprintf("must be at least %d characters long", p.cfg.LoginMinLength))
+	case loginLength > p.cfg.LoginMaxLength:
+		violate("login", RuleMaxLength, fmt.Sprintf("must be at most %d characters long", p.cfg.LoginMaxLength))
+	}
+	if login != "" && p.loginPattern != nil && !p.loginPattern.MatchString(login) {
+		violate("login", RuleCharset, "contains characters that are not allowed")
+	}
+
+	passwordLength := utf8.RuneCountInString(password)
+	switch {
+	case passwordLength < p.cfg.PasswordMinLength:
+		violate("password", RuleMinLength, fmt.Sprintf("must be at least %d characters long", p.cfg.PasswordMinLength))
+	case passwordLength > maxPasswordLength:
+		violate("password", RuleMaxLength, fmt.Sprintf("must be at most %d characters long", maxPasswordLength))
+	}
+	if characterClasses(password) < p.cfg.PasswordMinClasses {
+		violate("password", RuleComplexity, fmt.Sprintf(
+			"must contain at least %d of lowercase letters, uppercase letters, digits and other characters",
+			p.cfg.PasswordMinClasses,
+		))
+	}
+	if _, ok := p.breached[password]; ok {
+		violate("password", RuleBreached, "is known from data breaches, choose another one")
+	}
+
+	if len(violations) > 0 {
+		return &application.PolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// characterClasses counts which of lowercase letters, uppercase letters, digits
+// and other characters the password contains.
+func characterClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+
+	return lower + upper + digit + other
+}
+
+func readBreachedPasswords(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+	}
+	defer f.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		password := strings.TrimRight(scanner.Text(), "\r")
+		if password == "" {
+			continue
+		}
+		breached[password] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached passwords file: %w", err)
+	}
+
+	return breached, nil
+}
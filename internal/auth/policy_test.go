@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCredentialPolicy(t *testing.T) {
+	tests := map[string]struct {
+		cfg         CredentialPolicyConfig
+		expectedErr string
+	}{
+		"login longer than column": {
+			cfg:         CredentialPolicyConfig{LoginMaxLength: 65},
+			expectedErr: "invalid credential policy: login max length must be from 1 to 64",
+		},
+		"login min length exceeds max length": {
+			cfg:         CredentialPolicyConfig{LoginMinLength: 10, LoginMaxLength: 5},
+			expectedErr: "invalid credential policy: login min length exceeds max length",
+		},
+		"too many password classes": {
+			cfg:         CredentialPolicyConfig{LoginMaxLength: 64, PasswordMinClasses: 5},
+			expectedErr: "invalid credential policy: password can't contain more than 4 classes of characters",
+		},
+		"invalid login pattern": {
+			cfg:         CredentialPolicyConfig{LoginMaxLength: 64, LoginPattern: "[a-z"},
+			expectedErr: "invalid credential policy: failed to compile login pattern",
+		},
+		"missing breached passwords file": {
+			cfg:         CredentialPolicyConfig{LoginMaxLength: 64, BreachedPasswordsFile: filepath.Join(t.TempDir(), "missing.txt")},
+			expectedErr: "failed to open breached passwords file",
+		},
+		"valid": {
+			cfg: CredentialPolicyConfig{LoginMinLength: 1, LoginMaxLength: 64, PasswordMinLength: 8},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			p, err := NewCredentialPolicy(tt.cfg)
+
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, p)
+		})
+	}
+}
+
+func TestCredentialPolicy_NormalizeLogin(t *testing.T) {
+	p, err := NewCredentialPolicy(CredentialPolicyConfig{LoginMaxLength: 64})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		login              string
+		expectedNormalized string
+		expectedKey        string
+	}{
+		"ascii": {
+			login:              "User.Name",
+			expectedNormalized: "User.Name",
+			expectedKey:        "user.name",
+		},
+		"fullwidth letters": {
+			login:              "ＵＳＥＲ",
+			expectedNormalized: "USER",
+			expectedKey:        "user",
+		},
+		"combining accent": {
+			login:              "Jose\u0301",
+			expectedNormalized: "José",
+			expectedKey:        "josé",
+		},
+		"sharp s": {
+			login:              "Straße",
+			expectedNormalized: "Straße",
+			expectedKey:        "strasse",
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			normalized, key := p.NormalizeLogin(tt.login)
+
+			assert.Equal(t, tt.expectedNormalized, normalized)
+			assert.Equal(t, tt.expectedKey, key)
+		})
+	}
+}
+
+func TestCredentialPolicy_Check(t *testing.T) {
+	breachedFile := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(breachedFile, []byte("Password1!\r\n\nqwerty123\n"), 0o600))
+
+	p, err := NewCredentialPolicy(CredentialPolicyConfig{
+		LoginMinLength:        3,
+		LoginMaxLength:        10,
+		LoginPattern:          `[\p{L}\p{N}._-]+`,
+		PasswordMinLength:     8,
+		PasswordMinClasses:    3,
+		BreachedPasswordsFile: breachedFile,
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		login              string
+		password           string
+		expectedViolations []string
+	}{
+		"valid": {
+			login:    "юзер_1",
+			password: "Correct-horse",
+		},
+		"empty": {
+			expectedViolations: []string{"login min_length", "password min_length", "password complexity"},
+		},
+		"too long": {
+			login:              "very-long-login",
+			password:           "Aa1" + strings.Repeat("a", 1024),
+			expectedViolations: []string{"login max_length", "password max_length"},
+		},
+		"not allowed characters": {
+			login:              "user name",
+			password:           "Correct-horse",
+			expectedViolations: []string{"login charset"},
+		},
+		"too simple password": {
+			login:              "user",
+			password:           "correcthorse",
+			expectedViolations: []string{"password complexity"},
+		},
+		"breached password from line with windows ending": {
+			login:              "user",
+			password:           "Password1!",
+			expectedViolations: []string{"password breached"},
+		},
+		"breached password that is also too simple": {
+			login:              "user",
+			password:           "qwerty123",
+			expectedViolations: []string{"password complexity", "password breached"},
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			err := p.Check(tt.login, tt.password)
+
+			if tt.expectedViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *application.PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.ErrorIs(t, err, application.ErrPolicyViolation)
+
+			violations := make([]string, 0, len(policyErr.Violations))
+			for _, v := range policyErr.Violations {
+				assert.NotEmpty(t, v.Message)
+				violations = append(violations, v.Field+" "+v.Rule)
+			}
+			assert.Equal(t, tt.expectedViolations, violations)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+)
+
+const (
+	// maxDelayShift caps doubling of throttle delay, so it can't overflow.
+	maxDelayShift = 30
+	// reservationTimeout is for how long an attempt reserved by Allow counts as failed
+	// if its result is never reported.
+	reservationTimeout = time.Minute
+)
+
+type ThrottleConfig struct {
+	// FreeFailures is the number of failed attempts that are not delayed.
+	FreeFailures int
+	// Delay is the wait after the first failure over free ones, it doubles with every next failure.
+	Delay time.Duration
+	// MaxDelay caps the delay, zero caps it by Lockout if lockout is enabled.
+	MaxDelay time.Duration
+	// LockoutFailures is the number of failed attempts that lock the key out for Lockout,
+	// zero disables lockout.
+	LockoutFailures int
+	Lockout         time.Duration
+	// Window is for how long failures are remembered after the last one.
+	Window time.Duration
+}
+
+// Throttle slows down guessing passwords: keys, like logins or client addresses, wait
+// progressively longer after each failed attempt and are locked out after too many.
+// Allow reserves the attempt, which counts as failed till its result is reported,
+// so a burst of concurrent attempts can't get in before the first of them fails.
+//
+// Counters live in memory of the instance, so limits apply per instance:
+// behind n instances a key gets n times more attempts.
+type Throttle struct {
+	name   string
+	cfg    ThrottleConfig
+	logger *logger.Logger
+
+	mu       sync.Mutex
+	entries  map[string]*throttleEntry
+	prunedAt time.Time
+}
+
+type throttleEntry struct {
+	failures     int
+	failedAt     time.Time
+	blockedUntil time.Time
+	locked       bool
+	// pending is the number of reserved attempts without result, reservedAt is when the last one was reserved
+	pending    int
+	reservedAt time.Time
+}
+
+// release forgets one reserved attempt.
+func (e *throttleEntry) release() {
+	if e.pending > 0 {
+		e.pending--
+	}
+}
+
+// NewThrottle creates throttle, name tells keys of which kind it counts in logs.
+func NewThrottle(name string, cfg ThrottleConfig, l *logger.Logger) *Throttle {
+	return &Throttle{
+		name:     name,
+		cfg:      cfg,
+		logger:   l,
+		entries:  make(map[string]*throttleEntry),
+		prunedAt: time.Now(),
+	}
+}
+
+// Allow returns for how long attempts with the key must wait. If the attempt is allowed,
+// it returns zero and reserves the attempt, its result must be reported with Failure,
+// Success or Release. While attempts are reserved, the next one gets in only if it would
+// if all of them failed.
+func (t *Throttle) Allow(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	e, ok := t.entries[key]
+	if ok && e.locked && !now.Before(e.blockedUntil) {
+		t.unlock(key, e)
+		ok = false
+	}
+	if !ok {
+		e = &throttleEntry{}
+		t.entries[key] = e
+	}
+
+	if wait := e.blockedUntil.Sub(now); wait > 0 {
+		return wait
+	}
+	if e.pending > 0 && now.Sub(e.reservedAt) > reservationTimeout {
+		// results of these attempts are never coming
+		e.pending = 0
+	}
+	if e.pending > 0 {
+		failures := e.failures + e.pending
+		if t.cfg.LockoutFailures > 0 && failures >= t.cfg.LockoutFailures {
+			return t.cfg.Lockout
+		}
+		if over := failures - t.cfg.FreeFailures; over > 0 {
+			return t.delay(over)
+		}
+	}
+
+	e.pending++
+	e.reservedAt = now
+
+	return 0
+}
+
+// Failure records failed attempt with the key.
+func (t *Throttle) Failure(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+
+	e, ok := t.entries[key]
+	if ok && e.locked && !now.Before(e.blockedUntil) {
+		t.unlock(key, e)
+		ok = false
+	}
+	if !ok {
+		e = &throttleEntry{}
+		t.entries[key] = e
+	}
+	e.release()
+	if e.locked {
+		// attempts let in right before the lockout
+		return
+	}
+	if now.Sub(e.failedAt) > t.cfg.Window {
+		e.failures = 0
+	}
+
+	e.failures++
+	e.failedAt = now
+
+	if t.cfg.LockoutFailures > 0 && e.failures >= t.cfg.LockoutFailures {
+		e.locked = true
+		e.blockedUntil = now.Add(t.cfg.Lockout)
+		t.logger.Warn("locked out after failed attempts",
+			"throttle", t.name,
+			"key", key,
+			"failures", e.failures,
+			"until", e.blockedUntil,
+		)
+		return
+	}
+
+	if over := e.failures - t.cfg.FreeFailures; over > 0 {
+		e.blockedUntil = now.Add(t.delay(over))
+	}
+}
+
+// Success forgets failures of the key.
+func (t *Throttle) Success(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.entries, key)
+}
+
+// Release forgets the reserved attempt with the key, keeping failures,
+// e.g. when the attempt succeeded but failures of the key must not be forgotten.
+func (t *Throttle) Release(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		return
+	}
+	e.release()
+	if e.pending == 0 && e.failures == 0 && !e.locked {
+		delete(t.entries, key)
+	}
+}
+
+// delay returns wait after over failures over free ones.
+func (t *Throttle) delay(over int) time.Duration {
+	d := t.cfg.Delay << min(over-1, maxDelayShift)
+	switch {
+	case t.cfg.MaxDelay > 0:
+		d = min(d, t.cfg.MaxDelay)
+	case t.cfg.LockoutFailures > 0:
+		d = min(d, t.cfg.Lockout)
+	}
+
+	return d
+}
+
+func (t *Throttle) unlock(key string, e *throttleEntry) {
+	delete(t.entries, key)
+	t.logger.Info("lockout is over",
+		"throttle", t.name,
+		"key", key,
+		"failures", e.failures,
+	)
+}
+
+// prune forgets keys that are not blocked, have no reserved attempts and failed longer
+// than window ago, so counters of one-off clients don't pile up. It runs at most once per window.
+func (t *Throttle) prune(now time.Time) {
+	if now.Sub(t.prunedAt) < t.cfg.Window {
+		return
+	}
+	t.prunedAt = now
+
+	for key, e := range t.entries {
+		if now.Before(e.blockedUntil) || now.Sub(e.failedAt) <= t.cfg.Window {
+			continue
+		}
+		if e.pending > 0 && now.Sub(e.reservedAt) <= reservationTimeout {
+			continue
+		}
+		if e.locked {
+			t.unlock(key, e)
+			continue
+		}
+		delete(t.entries, key)
+	}
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	dummyLogger := &logger.Logger{Logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+
+	t.Run("delays progressively after free failures", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			FreeFailures: 2,
+			Delay:        time.Second,
+			Window:       time.Hour,
+		}, dummyLogger)
+
+		for range 2 {
+			require.Zero(t, th.Allow("user"))
+			th.Failure("user")
+		}
+		assert.Zero(t, th.Allow("user"))
+
+		th.Failure("user")
+		assert.InDelta(t, time.Second, th.Allow("user"), float64(50*time.Millisecond))
+
+		th.Failure("user")
+		assert.InDelta(t, 2*time.Second, th.Allow("user"), float64(50*time.Millisecond))
+
+		th.Failure("user")
+		assert.InDelta(t, 4*time.Second, th.Allow("user"), float64(50*time.Millisecond))
+
+		assert.Zero(t, th.Allow("other-user"), "keys are counted separately")
+	})
+
+	t.Run("success forgets failures", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			Delay:  time.Second,
+			Window: time.Hour,
+		}, dummyLogger)
+
+		th.Failure("user")
+		require.NotZero(t, th.Allow("user"))
+
+		th.Success("user")
+		assert.Zero(t, th.Allow("user"))
+	})
+
+	t.Run("failures are forgotten after window", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			FreeFailures: 1,
+			Delay:        time.Millisecond,
+			Window:       20 * time.Millisecond,
+		}, dummyLogger)
+
+		th.Failure("user")
+		time.Sleep(30 * time.Millisecond)
+
+		th.Failure("user")
+		assert.Zero(t, th.Allow("user"), "the failure should be the first one again")
+	})
+
+	t.Run("locks out after too many failures", func(t *testing.T) {
+		t.Parallel()
+
+		var logs bytes.Buffer
+		th := NewThrottle("client", ThrottleConfig{
+			FreeFailures:    5,
+			LockoutFailures: 3,
+			Lockout:         30 * time.Millisecond,
+			Window:          time.Hour,
+		}, &logger.Logger{Logger: slog.New(slog.NewJSONHandler(&logs, nil))})
+
+		for range 3 {
+			th.Failure("127.0.0.1")
+		}
+		assert.InDelta(t, 30*time.Millisecond, th.Allow("127.0.0.1"), float64(10*time.Millisecond))
+		assert.Contains(t, logs.String(), `"msg":"locked out after failed attempts","throttle":"client","key":"127.0.0.1","failures":3`)
+
+		// attempts let in before the lockout don't extend it
+		th.Failure("127.0.0.1")
+		assert.LessOrEqual(t, th.Allow("127.0.0.1"), 30*time.Millisecond)
+
+		time.Sleep(40 * time.Millisecond)
+
+		assert.Zero(t, th.Allow("127.0.0.1"))
+		assert.Contains(t, logs.String(), `"msg":"lockout is over","throttle":"client","key":"127.0.0.1"`)
+
+		th.Failure("127.0.0.1")
+		assert.Zero(t, th.Allow("127.0.0.1"), "failures should start over after lockout")
+	})
+
+	t.Run("delay doesn't exceed lockout", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			Delay:           time.Minute,
+			LockoutFailures: 100,
+			Lockout:         2 * time.Minute,
+			Window:          time.Hour,
+		}, dummyLogger)
+
+		for range 50 {
+			th.Failure("user")
+		}
+		assert.InDelta(t, 2*time.Minute, th.Allow("user"), float64(time.Second))
+	})
+
+	t.Run("reserves attempts", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			FreeFailures:    2,
+			Delay:           time.Second,
+			LockoutFailures: 4,
+			Lockout:         time.Minute,
+			Window:          time.Hour,
+		}, dummyLogger)
+
+		// a burst gets in only as far as it would if every attempt failed
+		for range 3 {
+			require.Zero(t, th.Allow("user"))
+		}
+		assert.Equal(t, time.Second, th.Allow("user"))
+
+		th.Failure("user")
+		th.Failure("user")
+		assert.Equal(t, time.Second, th.Allow("user"), "the third attempt is still reserved")
+
+		th.Release("user")
+		assert.Zero(t, th.Allow("user"))
+		assert.Equal(t, time.Second, th.Allow("user"))
+		th.Failure("user")
+		assert.InDelta(t, time.Second, th.Allow("user"), float64(50*time.Millisecond))
+
+		th.mu.Lock()
+		th.entries["user"].blockedUntil = time.Time{}
+		th.mu.Unlock()
+		require.Zero(t, th.Allow("user"))
+		assert.Equal(t, time.Minute, th.Allow("user"), "the next failure locks the key out")
+	})
+
+	t.Run("abandoned reservations expire", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			Delay:  time.Second,
+			Window: time.Hour,
+		}, dummyLogger)
+
+		require.Zero(t, th.Allow("user"))
+		require.NotZero(t, th.Allow("user"))
+
+		th.mu.Lock()
+		th.entries["user"].reservedAt = time.Now().Add(-2 * reservationTimeout)
+		th.mu.Unlock()
+
+		assert.Zero(t, th.Allow("user"))
+	})
+
+	t.Run("release keeps failures", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("client", ThrottleConfig{
+			FreeFailures: 1,
+			Delay:        time.Second,
+			Window:       time.Hour,
+		}, dummyLogger)
+
+		require.Zero(t, th.Allow("127.0.0.1"))
+		th.Failure("127.0.0.1")
+		require.Zero(t, th.Allow("127.0.0.1"))
+		th.Release("127.0.0.1")
+
+		th.mu.Lock()
+		assert.Equal(t, 1, th.entries["127.0.0.1"].failures)
+		th.mu.Unlock()
+
+		require.Zero(t, th.Allow("127.0.0.2"))
+		th.Release("127.0.0.2")
+
+		th.mu.Lock()
+		assert.NotContains(t, th.entries, "127.0.0.2", "keys without failures are forgotten")
+		th.mu.Unlock()
+	})
+
+	t.Run("max delay caps delay without lockout", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("login", ThrottleConfig{
+			Delay:    time.Second,
+			MaxDelay: 30 * time.Second,
+			Window:   time.Hour,
+		}, dummyLogger)
+
+		for range 50 {
+			th.Failure("user")
+		}
+		assert.InDelta(t, 30*time.Second, th.Allow("user"), float64(time.Second))
+	})
+
+	t.Run("prunes forgotten keys", func(t *testing.T) {
+		t.Parallel()
+
+		th := NewThrottle("client", ThrottleConfig{
+			Delay:  time.Millisecond,
+			Window: 20 * time.Millisecond,
+		}, dummyLogger)
+
+		th.Failure("127.0.0.1")
+		th.Failure("127.0.0.2")
+		time.Sleep(30 * time.Millisecond)
+
+		th.Failure("127.0.0.3")
+
+		th.mu.Lock()
+		defer th.mu.Unlock()
+		assert.Len(t, th.entries, 1)
+	})
+}
@@ -0,0 +1,73 @@
+// Package periodic runs background work on a ticker until it is stopped.
+package periodic
+
+import (
+	"context"
+	"time"
+)
+
+// Func does one round of work. It reports whether work is left,
+// so the next round starts without waiting for a tick.
+type Func func(ctx context.Context) bool
+
+type Option func(*Runner)
+
+// WithFirstRoundOnTick makes the runner wait for the first tick
+// instead of doing a round right away.
+func WithFirstRoundOnTick() Option {
+	return func(r *Runner) {
+		r.firstRoundOnTick = true
+	}
+}
+
+// WithTicks makes the runner start rounds on values from ticks instead of its own ticker.
+func WithTicks(ticks <-chan time.Time) Option {
+	return func(r *Runner) {
+		r.ticks = ticks
+	}
+}
+
+// Runner does rounds of work every interval.
+type Runner struct {
+	interval         time.Duration
+	round            Func
+	ticks            <-chan time.Time
+	firstRoundOnTick bool
+}
+
+func New(interval time.Duration, round Func, opts ...Option) *Runner {
+	r := &Runner{
+		interval: interval,
+		round:    round,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run does rounds until ctx is done. While work is left it does the next round
+// without waiting for the next tick.
+func (r *Runner) Run(ctx context.Context) {
+	ticks := r.ticks
+	if ticks == nil {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	next := !r.firstRoundOnTick
+	for {
+		if !next {
+			select {
+			case <-ticks:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		next = r.round(ctx) && ctx.Err() == nil
+	}
+}
@@ -0,0 +1,91 @@
+package periodic_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/periodic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner_Run(t *testing.T) {
+	t.Run("rounds follow each other while work is left", func(t *testing.T) {
+		ticks := make(chan time.Time)
+		rounds := make(chan struct{})
+		more := []bool{true, true, false, false}
+		var calls int
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := periodic.New(time.Hour, func(ctx context.Context) bool {
+			calls++
+			rounds <- struct{}{}
+			return calls <= len(more) && more[calls-1]
+		}, periodic.WithTicks(ticks))
+
+		done := make(chan struct{})
+		go func() {
+			r.Run(ctx)
+			close(done)
+		}()
+
+		// the first round starts right away and is followed by the next ones until no work is left
+		for range 3 {
+			<-rounds
+		}
+		// the tick is taken only when the runner waits for it
+		ticks <- time.Now()
+		<-rounds
+
+		cancel()
+		<-done
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("first round on tick", func(t *testing.T) {
+		ticks := make(chan time.Time)
+		rounds := make(chan struct{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := periodic.New(time.Hour, func(ctx context.Context) bool {
+			rounds <- struct{}{}
+			return false
+		}, periodic.WithTicks(ticks), periodic.WithFirstRoundOnTick())
+
+		done := make(chan struct{})
+		go func() {
+			r.Run(ctx)
+			close(done)
+		}()
+
+		select {
+		case ticks <- time.Now():
+		case <-rounds:
+			t.Fatal("round started before the first tick")
+		}
+		<-rounds
+
+		cancel()
+		<-done
+	})
+
+	t.Run("context is done while work is left", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var calls int
+		periodic.New(time.Hour, func(ctx context.Context) bool {
+			calls++
+			if calls == 2 {
+				cancel()
+			}
+			return true
+		}).Run(ctx)
+
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,48 @@
+package poller
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/periodic"
+)
+
+type OrderPoller interface {
+	PollOrders(ctx context.Context) (int, error)
+}
+
+// Poller periodically asks the service to check orders that are due.
+type Poller struct {
+	service  OrderPoller
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+func New(service OrderPoller, interval time.Duration, l *logger.Logger) *Poller {
+	return &Poller{
+		service:  service,
+		interval: interval,
+		logger:   l,
+	}
+}
+
+// Run polls orders until ctx is done. While there are due orders left
+// it polls again without waiting for the next tick.
+func (p *Poller) Run(ctx context.Context) {
+	periodic.New(p.interval, func(ctx context.Context) bool {
+		return p.poll(ctx) > 0
+	}).Run(ctx)
+}
+
+func (p *Poller) poll(ctx context.Context) int {
+	claimed, err := p.service.PollOrders(ctx)
+	if err != nil {
+		p.logger.Error("failed to poll orders", "error", err)
+	}
+	if claimed > 0 {
+		p.logger.Debug("polled orders", "count", claimed)
+	}
+
+	return claimed
+}
@@ -0,0 +1,45 @@
+package poller_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/poller"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderPollerFunc func(ctx context.Context) (int, error)
+
+func (f orderPollerFunc) PollOrders(ctx context.Context) (int, error) {
+	return f(ctx)
+}
+
+func TestPoller_Run(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	// the service stops the run once nothing is due, the timeout only keeps a broken run from hanging
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var calls int
+	due := []int{100, 100, 3}
+	s := orderPollerFunc(func(ctx context.Context) (int, error) {
+		calls++
+		if calls <= len(due) {
+			return due[calls-1], nil
+		}
+		cancel()
+		return 0, nil
+	})
+
+	poller.New(s, time.Hour, dummyLogger).Run(ctx)
+
+	// batches are followed by an immediate poll until nothing is due
+	assert.Equal(t, len(due)+1, calls)
+}
@@ -56,12 +56,74 @@ func (ns NullOrderStatus) Value() (driver.Value, error) {
 }
 
 type Order struct {
+	ID                  pgtype.UUID
+	UserID              pgtype.UUID
+	CreatedAt           pgtype.Timestamptz
+	Num                 string
+	Accrual             pgtype.Int4
+	Status              OrderStatus
+	Attempts            int32
+	NextAttemptAt       pgtype.Timestamptz
+	LastError           pgtype.Text
+	AccrualProvider     pgtype.Text
+	ReconciledAt        pgtype.Timestamptz
+	ReconcileLeaseUntil pgtype.Timestamptz
+}
+
+type OrderDeadLetter struct {
+	OrderID   pgtype.UUID
+	Error     string
+	Attempts  int32
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type OrderDiscrepancy struct {
+	ID              pgtype.UUID
+	OrderID         pgtype.UUID
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	Status          OrderStatus
+	Accrual         int32
+	ExpectedStatus  string
+	ExpectedAccrual int32
+	AdjustedAt      pgtype.Timestamptz
+	Adjustment      pgtype.Int4
+	Reason          pgtype.Text
+}
+
+type OrderEvent struct {
+	ID          pgtype.UUID
+	OrderID     pgtype.UUID
+	CreatedAt   pgtype.Timestamptz
+	Type        string
+	Status      NullOrderStatus
+	Accrual     pgtype.Int4
+	Error       pgtype.Text
+	PublicError pgtype.Text
+}
+
+type Session struct {
 	ID        pgtype.UUID
+	FamilyID  pgtype.UUID
 	UserID    pgtype.UUID
+	TokenHash string
+	UserAgent string
+	StartedAt pgtype.Timestamptz
 	CreatedAt pgtype.Timestamptz
-	Num       string
-	Accrual   pgtype.Int4
-	Status    OrderStatus
+	ExpiresAt pgtype.Timestamptz
+	RotatedAt pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+}
+
+type SigningKey struct {
+	ID         pgtype.UUID
+	Algorithm  string
+	PrivateKey []byte
+	CreatedAt  pgtype.Timestamptz
+	ActiveAt   pgtype.Timestamptz
+	RetireAt   pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
 }
 
 type User struct {
@@ -70,6 +132,7 @@ type User struct {
 	Password  string
 	CreatedAt pgtype.Timestamptz
 	Balance   pgtype.Int4
+	LoginKey  pgtype.Text
 }
 
 type Withdrawal struct {
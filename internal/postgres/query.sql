@@ -4,12 +4,14 @@ WHERE id = $1 LIMIT 1;
 
 -- name: GetUserByLogin :one
 SELECT * FROM users
-WHERE login = $1 LIMIT 1;
+WHERE login = sqlc.arg(login) OR login_key = sqlc.arg(login_key)
+ORDER BY login = sqlc.arg(login) DESC
+LIMIT 1;
 
 -- name: SaveUser :one
-INSERT INTO users (login, password)
-VALUES ($1, $2)
-RETURNING id, login, password, created_at, balance;
+INSERT INTO users (login, password, login_key)
+VALUES ($1, $2, $3)
+RETURNING id, login, password, created_at, balance, login_key;
 
 -- name: SetUserBalance :one
 UPDATE users
@@ -17,6 +19,11 @@ SET balance = $1
 WHERE id = $2
 RETURNING id, login, created_at, balance;
 
+-- name: SetUserPassword :exec
+UPDATE users
+SET password = $1
+WHERE id = $2;
+
 -- name: IncrementUserBalance :one
 UPDATE users
 SET balance = balance + $1
@@ -34,21 +41,25 @@ SELECT * FROM orders
 WHERE num = $1 LIMIT 1;
 
 -- name: SaveOrder :one
-INSERT INTO orders (user_id, num, accrual, status)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, created_at, num, accrual, status;
+INSERT INTO orders (user_id, num, accrual, status, next_attempt_at, accrual_provider)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
 
 -- name: SetOrderAccrual :one
 UPDATE orders
 SET accrual = $1
 WHERE id = $2
-RETURNING id, user_id, created_at, num, accrual, status;
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
 
 -- name: SetOrderStatus :one
 UPDATE orders
-SET status = $1
-WHERE id = $2
-RETURNING id, user_id, created_at, num, accrual, status;
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id) AND status::text = ANY(sqlc.arg(from_statuses)::text[])
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: GetOrderStatus :one
+SELECT status FROM orders
+WHERE id = $1;
 
 -- name: GetUserWithdrawalSum :one
 SELECT COALESCE(SUM(amount), 0) FROM withdrawals
@@ -67,3 +78,264 @@ ORDER BY created_at DESC;
 INSERT INTO withdrawals (user_id, order_num, amount)
 VALUES ($1, $2, $3)
 RETURNING id, user_id, order_num, created_at, amount;
+
+-- name: RecordOrderAttempt :one
+UPDATE orders
+SET attempts = $1, next_attempt_at = $2, last_error = $3
+WHERE id = $4
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: ReleaseOrder :exec
+UPDATE orders
+SET next_attempt_at = now()
+WHERE id = $1 AND status IN ('NEW', 'PROCESSING');
+
+-- name: PostponeOrderCheck :one
+UPDATE orders
+SET next_attempt_at = $1
+WHERE id = $2 AND status IN ('NEW', 'PROCESSING') AND next_attempt_at IS NOT NULL
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: ClaimDueOrders :many
+WITH due_users AS (
+    -- users whose orders are the most overdue, a batch can't take turns of more users than it holds
+    SELECT user_id FROM orders
+    WHERE status IN ('NEW', 'PROCESSING') AND next_attempt_at <= now()
+    GROUP BY user_id
+    ORDER BY min(next_attempt_at)
+    LIMIT sqlc.arg(batch_size)
+), candidates AS (
+    -- every user gives its share of the batch from its most overdue orders other instances
+    -- haven't locked, so a user with a large backlog can't take the place of the others
+    SELECT c.id, c.user_id, c.next_attempt_at
+    FROM due_users u
+    CROSS JOIN LATERAL (
+        SELECT id, user_id, next_attempt_at FROM orders
+        WHERE user_id = u.user_id AND status IN ('NEW', 'PROCESSING') AND next_attempt_at <= now()
+        ORDER BY next_attempt_at
+        LIMIT (SELECT (sqlc.arg(batch_size)::bigint + count(*) - 1) / count(*) FROM due_users)
+        FOR UPDATE SKIP LOCKED
+    ) c
+), turns AS (
+    -- users take turns: first the most overdue candidate of every user, then the second ones and so on
+    SELECT id FROM (
+        SELECT id, next_attempt_at,
+            row_number() OVER (PARTITION BY user_id ORDER BY next_attempt_at) AS user_turn
+        FROM candidates
+    ) ranked
+    ORDER BY user_turn, next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+)
+UPDATE orders
+SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (SELECT id FROM turns)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: DeadLetterOrder :one
+WITH parked AS (
+    UPDATE orders
+    SET next_attempt_at = NULL
+    WHERE id = $1 AND status IN ('NEW', 'PROCESSING')
+    RETURNING id, attempts
+)
+INSERT INTO order_dead_letters (order_id, error, attempts)
+SELECT id, sqlc.arg(error)::text, attempts FROM parked
+ON CONFLICT (order_id) DO UPDATE
+SET error = EXCLUDED.error, attempts = EXCLUDED.attempts, updated_at = now()
+RETURNING order_id, error, attempts, created_at, updated_at;
+
+-- name: ListOrderDeadLetters :many
+SELECT d.order_id, o.num, d.error, d.attempts, d.created_at, d.updated_at
+FROM order_dead_letters d
+JOIN orders o ON o.id = d.order_id
+ORDER BY d.created_at;
+
+-- name: RetryOrderDeadLetter :one
+WITH deleted AS (
+    DELETE FROM order_dead_letters
+    WHERE order_id = (SELECT id FROM orders WHERE num = sqlc.arg(num))
+    RETURNING order_id
+)
+UPDATE orders
+SET attempts = 0, next_attempt_at = now(), last_error = NULL
+WHERE id IN (SELECT order_id FROM deleted)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: RetryAllOrderDeadLetters :execrows
+WITH deleted AS (
+    DELETE FROM order_dead_letters
+    RETURNING order_id
+), retried AS (
+    UPDATE orders
+    SET attempts = 0, next_attempt_at = now(), last_error = NULL
+    WHERE id IN (SELECT order_id FROM deleted)
+    RETURNING id, status
+)
+INSERT INTO order_events (order_id, type, status)
+SELECT id, 'RETRIED', status FROM retried;
+
+-- name: InvalidateOrderDeadLetter :one
+WITH deleted AS (
+    DELETE FROM order_dead_letters
+    WHERE order_id = (SELECT id FROM orders WHERE num = sqlc.arg(num))
+    RETURNING order_id
+)
+UPDATE orders
+SET status = 'INVALID', next_attempt_at = NULL
+WHERE id IN (SELECT order_id FROM deleted) AND status IN ('NEW', 'PROCESSING')
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: CreateOrderEvent :exec
+INSERT INTO order_events (order_id, type, status, accrual, error, public_error)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetOrderEvents :many
+SELECT * FROM order_events
+WHERE order_id = $1
+ORDER BY created_at;
+
+-- name: ClaimOrdersForReconciliation :many
+UPDATE orders
+SET reconcile_lease_until = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT o.id FROM orders o
+    WHERE o.status IN ('PROCESSED', 'INVALID')
+        AND (o.reconciled_at IS NULL OR o.reconciled_at < sqlc.arg(reconciled_before))
+        AND (o.reconcile_lease_until IS NULL OR o.reconcile_lease_until <= now())
+        AND (
+            EXISTS (
+                SELECT 1 FROM order_events e
+                WHERE e.order_id = o.id
+                    AND e.type = 'STATUS_CHANGED'
+                    AND e.status IN ('PROCESSED', 'INVALID')
+                    AND e.created_at >= sqlc.arg(finalized_after)
+            )
+            -- orders finalized before status changes were recorded have no events,
+            -- they are taken by upload time instead
+            OR (
+                o.created_at >= sqlc.arg(finalized_after)
+                AND NOT EXISTS (
+                    SELECT 1 FROM order_events e
+                    WHERE e.order_id = o.id AND e.type = 'STATUS_CHANGED'
+                )
+            )
+        )
+    ORDER BY o.reconciled_at NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: MarkOrderReconciled :exec
+UPDATE orders
+SET reconciled_at = now(), reconcile_lease_until = NULL
+WHERE id = $1;
+
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: AdjustOrder :one
+UPDATE orders
+SET status = $1, accrual = $2
+WHERE id = $3
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until;
+
+-- name: SaveOrderDiscrepancy :one
+INSERT INTO order_discrepancies (order_id, status, accrual, expected_status, expected_accrual)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (order_id) WHERE adjusted_at IS NULL DO UPDATE
+SET status = EXCLUDED.status,
+    accrual = EXCLUDED.accrual,
+    expected_status = EXCLUDED.expected_status,
+    expected_accrual = EXCLUDED.expected_accrual,
+    updated_at = now()
+RETURNING *;
+
+-- name: ListOrderDiscrepancies :many
+SELECT d.id, d.order_id, o.num, d.created_at, d.updated_at, d.status, d.accrual,
+    d.expected_status, d.expected_accrual, d.adjusted_at, d.adjustment, d.reason
+FROM order_discrepancies d
+JOIN orders o ON o.id = d.order_id
+ORDER BY d.created_at DESC;
+
+-- name: GetOrderDiscrepancy :one
+SELECT d.id, d.order_id, o.num, d.created_at, d.updated_at, d.status, d.accrual,
+    d.expected_status, d.expected_accrual, d.adjusted_at, d.adjustment, d.reason
+FROM order_discrepancies d
+JOIN orders o ON o.id = d.order_id
+WHERE d.id = $1;
+
+-- name: GetOpenOrderDiscrepancyForUpdate :one
+SELECT * FROM order_discrepancies
+WHERE id = $1 AND adjusted_at IS NULL
+FOR UPDATE;
+
+-- name: MarkOrderDiscrepancyAdjusted :one
+UPDATE order_discrepancies
+SET adjusted_at = now(), updated_at = now(), adjustment = $1, reason = $2
+WHERE id = $3
+RETURNING *;
+
+-- name: SaveSession :one
+INSERT INTO sessions (user_id, token_hash, user_agent, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetSessionByTokenHash :one
+SELECT * FROM sessions
+WHERE token_hash = $1 LIMIT 1;
+
+-- name: MarkSessionRotated :one
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+RETURNING *;
+
+-- name: SaveRotatedSession :one
+INSERT INTO sessions (family_id, user_id, token_hash, user_agent, started_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListUserSessions :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: IsSessionFamilyActive :one
+SELECT EXISTS (
+    SELECT 1 FROM sessions
+    WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+);
+
+-- name: RevokeSessionFamily :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: LockSigningKeys :exec
+SELECT pg_advisory_xact_lock(hashtext('signing_keys'));
+
+-- name: SaveSigningKey :one
+INSERT INTO signing_keys (id, algorithm, private_key, active_at, retire_at, expires_at)
+SELECT sqlc.arg(id)::uuid, sqlc.arg(algorithm)::varchar, sqlc.arg(private_key)::bytea,
+    sqlc.arg(active_at)::timestamptz, sqlc.arg(retire_at)::timestamptz, sqlc.arg(expires_at)::timestamptz
+WHERE NOT EXISTS (
+    SELECT 1 FROM signing_keys WHERE retire_at > sqlc.arg(active_at)::timestamptz
+)
+RETURNING *;
+
+-- name: ListSigningKeys :many
+SELECT * FROM signing_keys
+WHERE expires_at > now()
+ORDER BY active_at;
+
+-- name: DeleteExpiredSigningKeys :exec
+DELETE FROM signing_keys
+WHERE expires_at <= now();
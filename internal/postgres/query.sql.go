@@ -11,6 +11,212 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const adjustOrder = `-- name: AdjustOrder :one
+UPDATE orders
+SET status = $1, accrual = $2
+WHERE id = $3
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+type AdjustOrderParams struct {
+	Status  OrderStatus
+	Accrual pgtype.Int4
+	ID      pgtype.UUID
+}
+
+func (q *Queries) AdjustOrder(ctx context.Context, arg AdjustOrderParams) (*Order, error) {
+	row := q.db.QueryRow(ctx, adjustOrder, arg.Status, arg.Accrual, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.Num,
+		&i.Accrual,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const claimDueOrders = `-- name: ClaimDueOrders :many
+WITH due_users AS (
+    -- users whose orders are the most overdue, a batch can't take turns of more users than it holds
+    SELECT user_id FROM orders
+    WHERE status IN ('NEW', 'PROCESSING') AND next_attempt_at <= now()
+    GROUP BY user_id
+    ORDER BY min(next_attempt_at)
+    LIMIT $1
+), candidates AS (
+    -- every user gives its share of the batch from its most overdue orders other instances
+    -- haven't locked, so a user with a large backlog can't take the place of the others
+    SELECT c.id, c.user_id, c.next_attempt_at
+    FROM due_users u
+    CROSS JOIN LATERAL (
+        SELECT id, user_id, next_attempt_at FROM orders
+        WHERE user_id = u.user_id AND status IN ('NEW', 'PROCESSING') AND next_attempt_at <= now()
+        ORDER BY next_attempt_at
+        LIMIT (SELECT ($1::bigint + count(*) - 1) / count(*) FROM due_users)
+        FOR UPDATE SKIP LOCKED
+    ) c
+), turns AS (
+    -- users take turns: first the most overdue candidate of every user, then the second ones and so on
+    SELECT id FROM (
+        SELECT id, next_attempt_at,
+            row_number() OVER (PARTITION BY user_id ORDER BY next_attempt_at) AS user_turn
+        FROM candidates
+    ) ranked
+    ORDER BY user_turn, next_attempt_at
+    LIMIT $1
+)
+UPDATE orders
+SET next_attempt_at = $2
+WHERE id IN (SELECT id FROM turns)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+type ClaimDueOrdersParams struct {
+	BatchSize  int32
+	LeaseUntil pgtype.Timestamptz
+}
+
+func (q *Queries) ClaimDueOrders(ctx context.Context, arg ClaimDueOrdersParams) ([]*Order, error) {
+	rows, err := q.db.Query(ctx, claimDueOrders, arg.BatchSize, arg.LeaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.Num,
+			&i.Accrual,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.AccrualProvider,
+			&i.ReconciledAt,
+			&i.ReconcileLeaseUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimOrdersForReconciliation = `-- name: ClaimOrdersForReconciliation :many
+UPDATE orders
+SET reconcile_lease_until = $1
+WHERE id IN (
+    SELECT o.id FROM orders o
+    WHERE o.status IN ('PROCESSED', 'INVALID')
+        AND (o.reconciled_at IS NULL OR o.reconciled_at < $2)
+        AND (o.reconcile_lease_until IS NULL OR o.reconcile_lease_until <= now())
+        AND (
+            EXISTS (
+                SELECT 1 FROM order_events e
+                WHERE e.order_id = o.id
+                    AND e.type = 'STATUS_CHANGED'
+                    AND e.status IN ('PROCESSED', 'INVALID')
+                    AND e.created_at >= $3
+            )
+            -- orders finalized before status changes were recorded have no events,
+            -- they are taken by upload time instead
+            OR (
+                o.created_at >= $3
+                AND NOT EXISTS (
+                    SELECT 1 FROM order_events e
+                    WHERE e.order_id = o.id AND e.type = 'STATUS_CHANGED'
+                )
+            )
+        )
+    ORDER BY o.reconciled_at NULLS FIRST
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+type ClaimOrdersForReconciliationParams struct {
+	LeaseUntil       pgtype.Timestamptz
+	ReconciledBefore pgtype.Timestamptz
+	FinalizedAfter   pgtype.Timestamptz
+	BatchSize        int32
+}
+
+func (q *Queries) ClaimOrdersForReconciliation(ctx context.Context, arg ClaimOrdersForReconciliationParams) ([]*Order, error) {
+	rows, err := q.db.Query(ctx, claimOrdersForReconciliation, arg.LeaseUntil, arg.ReconciledBefore, arg.FinalizedAfter, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.Num,
+			&i.Accrual,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.AccrualProvider,
+			&i.ReconciledAt,
+			&i.ReconcileLeaseUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOrderEvent = `-- name: CreateOrderEvent :exec
+INSERT INTO order_events (order_id, type, status, accrual, error, public_error)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateOrderEventParams struct {
+	OrderID     pgtype.UUID
+	Type        string
+	Status      NullOrderStatus
+	Accrual     pgtype.Int4
+	Error       pgtype.Text
+	PublicError pgtype.Text
+}
+
+func (q *Queries) CreateOrderEvent(ctx context.Context, arg CreateOrderEventParams) error {
+	_, err := q.db.Exec(ctx, createOrderEvent,
+		arg.OrderID,
+		arg.Type,
+		arg.Status,
+		arg.Accrual,
+		arg.Error,
+		arg.PublicError,
+	)
+	return err
+}
+
 const createWithdrawal = `-- name: CreateWithdrawal :one
 INSERT INTO withdrawals (user_id, order_num, amount)
 VALUES ($1, $2, $3)
@@ -36,8 +242,75 @@ func (q *Queries) CreateWithdrawal(ctx context.Context, arg CreateWithdrawalPara
 	return &i, err
 }
 
+const deadLetterOrder = `-- name: DeadLetterOrder :one
+WITH parked AS (
+    UPDATE orders
+    SET next_attempt_at = NULL
+    WHERE id = $1 AND status IN ('NEW', 'PROCESSING')
+    RETURNING id, attempts
+)
+INSERT INTO order_dead_letters (order_id, error, attempts)
+SELECT id, $2::text, attempts FROM parked
+ON CONFLICT (order_id) DO UPDATE
+SET error = EXCLUDED.error, attempts = EXCLUDED.attempts, updated_at = now()
+RETURNING order_id, error, attempts, created_at, updated_at
+`
+
+type DeadLetterOrderParams struct {
+	ID    pgtype.UUID
+	Error string
+}
+
+func (q *Queries) DeadLetterOrder(ctx context.Context, arg DeadLetterOrderParams) (*OrderDeadLetter, error) {
+	row := q.db.QueryRow(ctx, deadLetterOrder, arg.ID, arg.Error)
+	var i OrderDeadLetter
+	err := row.Scan(
+		&i.OrderID,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const deleteExpiredSigningKeys = `-- name: DeleteExpiredSigningKeys :exec
+DELETE FROM signing_keys
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredSigningKeys(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredSigningKeys)
+	return err
+}
+
+const getOpenOrderDiscrepancyForUpdate = `-- name: GetOpenOrderDiscrepancyForUpdate :one
+SELECT id, order_id, created_at, updated_at, status, accrual, expected_status, expected_accrual, adjusted_at, adjustment, reason FROM order_discrepancies
+WHERE id = $1 AND adjusted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetOpenOrderDiscrepancyForUpdate(ctx context.Context, id pgtype.UUID) (*OrderDiscrepancy, error) {
+	row := q.db.QueryRow(ctx, getOpenOrderDiscrepancyForUpdate, id)
+	var i OrderDiscrepancy
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.Accrual,
+		&i.ExpectedStatus,
+		&i.ExpectedAccrual,
+		&i.AdjustedAt,
+		&i.Adjustment,
+		&i.Reason,
+	)
+	return &i, err
+}
+
 const getOrderByNumber = `-- name: GetOrderByNumber :one
-SELECT id, user_id, created_at, num, accrual, status FROM orders
+SELECT id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until FROM orders
 WHERE num = $1 LIMIT 1
 `
 
@@ -51,12 +324,157 @@ func (q *Queries) GetOrderByNumber(ctx context.Context, num string) (*Order, err
 		&i.Num,
 		&i.Accrual,
 		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const getOrderDiscrepancy = `-- name: GetOrderDiscrepancy :one
+SELECT d.id, d.order_id, o.num, d.created_at, d.updated_at, d.status, d.accrual,
+    d.expected_status, d.expected_accrual, d.adjusted_at, d.adjustment, d.reason
+FROM order_discrepancies d
+JOIN orders o ON o.id = d.order_id
+WHERE d.id = $1
+`
+
+type GetOrderDiscrepancyRow struct {
+	ID              pgtype.UUID
+	OrderID         pgtype.UUID
+	Num             string
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	Status          OrderStatus
+	Accrual         int32
+	ExpectedStatus  string
+	ExpectedAccrual int32
+	AdjustedAt      pgtype.Timestamptz
+	Adjustment      pgtype.Int4
+	Reason          pgtype.Text
+}
+
+func (q *Queries) GetOrderDiscrepancy(ctx context.Context, id pgtype.UUID) (*GetOrderDiscrepancyRow, error) {
+	row := q.db.QueryRow(ctx, getOrderDiscrepancy, id)
+	var i GetOrderDiscrepancyRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Num,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.Accrual,
+		&i.ExpectedStatus,
+		&i.ExpectedAccrual,
+		&i.AdjustedAt,
+		&i.Adjustment,
+		&i.Reason,
+	)
+	return &i, err
+}
+
+const getOrderEvents = `-- name: GetOrderEvents :many
+SELECT id, order_id, created_at, type, status, accrual, error, public_error FROM order_events
+WHERE order_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetOrderEvents(ctx context.Context, orderID pgtype.UUID) ([]*OrderEvent, error) {
+	rows, err := q.db.Query(ctx, getOrderEvents, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*OrderEvent
+	for rows.Next() {
+		var i OrderEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.CreatedAt,
+			&i.Type,
+			&i.Status,
+			&i.Accrual,
+			&i.Error,
+			&i.PublicError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id pgtype.UUID) (*Order, error) {
+	row := q.db.QueryRow(ctx, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.Num,
+		&i.Accrual,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const getOrderStatus = `-- name: GetOrderStatus :one
+SELECT status FROM orders
+WHERE id = $1
+`
+
+func (q *Queries) GetOrderStatus(ctx context.Context, id pgtype.UUID) (OrderStatus, error) {
+	row := q.db.QueryRow(ctx, getOrderStatus, id)
+	var status OrderStatus
+	err := row.Scan(&status)
+	return status, err
+}
+
+const getSessionByTokenHash = `-- name: GetSessionByTokenHash :one
+SELECT id, family_id, user_id, token_hash, user_agent, started_at, created_at, expires_at, rotated_at, revoked_at FROM sessions
+WHERE token_hash = $1 LIMIT 1
+`
+
+func (q *Queries) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*Session, error) {
+	row := q.db.QueryRow(ctx, getSessionByTokenHash, tokenHash)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.UserAgent,
+		&i.StartedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RotatedAt,
+		&i.RevokedAt,
 	)
 	return &i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, login, password, created_at, balance FROM users
+SELECT id, login, password, created_at, balance, login_key FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -69,17 +487,25 @@ func (q *Queries) GetUser(ctx context.Context, id pgtype.UUID) (*User, error) {
 		&i.Password,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.LoginKey,
 	)
 	return &i, err
 }
 
 const getUserByLogin = `-- name: GetUserByLogin :one
-SELECT id, login, password, created_at, balance FROM users
-WHERE login = $1 LIMIT 1
+SELECT id, login, password, created_at, balance, login_key FROM users
+WHERE login = $1 OR login_key = $2
+ORDER BY login = $1 DESC
+LIMIT 1
 `
 
-func (q *Queries) GetUserByLogin(ctx context.Context, login string) (*User, error) {
-	row := q.db.QueryRow(ctx, getUserByLogin, login)
+type GetUserByLoginParams struct {
+	Login    string
+	LoginKey pgtype.Text
+}
+
+func (q *Queries) GetUserByLogin(ctx context.Context, arg GetUserByLoginParams) (*User, error) {
+	row := q.db.QueryRow(ctx, getUserByLogin, arg.Login, arg.LoginKey)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -87,12 +513,13 @@ func (q *Queries) GetUserByLogin(ctx context.Context, login string) (*User, erro
 		&i.Password,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.LoginKey,
 	)
 	return &i, err
 }
 
 const getUserOrdersNewestFirst = `-- name: GetUserOrdersNewestFirst :many
-SELECT id, user_id, created_at, num, accrual, status FROM orders
+SELECT id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until FROM orders
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -113,6 +540,12 @@ func (q *Queries) GetUserOrdersNewestFirst(ctx context.Context, userID pgtype.UU
 			&i.Num,
 			&i.Accrual,
 			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.AccrualProvider,
+			&i.ReconciledAt,
+			&i.ReconcileLeaseUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -198,17 +631,478 @@ func (q *Queries) IncrementUserBalance(ctx context.Context, arg IncrementUserBal
 	return &i, err
 }
 
+const invalidateOrderDeadLetter = `-- name: InvalidateOrderDeadLetter :one
+WITH deleted AS (
+    DELETE FROM order_dead_letters
+    WHERE order_id = (SELECT id FROM orders WHERE num = $1)
+    RETURNING order_id
+)
+UPDATE orders
+SET status = 'INVALID', next_attempt_at = NULL
+WHERE id IN (SELECT order_id FROM deleted) AND status IN ('NEW', 'PROCESSING')
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+func (q *Queries) InvalidateOrderDeadLetter(ctx context.Context, num string) (*Order, error) {
+	row := q.db.QueryRow(ctx, invalidateOrderDeadLetter, num)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.Num,
+		&i.Accrual,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const isSessionFamilyActive = `-- name: IsSessionFamilyActive :one
+SELECT EXISTS (
+    SELECT 1 FROM sessions
+    WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+)
+`
+
+type IsSessionFamilyActiveParams struct {
+	FamilyID pgtype.UUID
+	UserID   pgtype.UUID
+}
+
+func (q *Queries) IsSessionFamilyActive(ctx context.Context, arg IsSessionFamilyActiveParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isSessionFamilyActive, arg.FamilyID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listOrderDeadLetters = `-- name: ListOrderDeadLetters :many
+SELECT d.order_id, o.num, d.error, d.attempts, d.created_at, d.updated_at
+FROM order_dead_letters d
+JOIN orders o ON o.id = d.order_id
+ORDER BY d.created_at
+`
+
+type ListOrderDeadLettersRow struct {
+	OrderID   pgtype.UUID
+	Num       string
+	Error     string
+	Attempts  int32
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListOrderDeadLetters(ctx context.Context) ([]*ListOrderDeadLettersRow, error) {
+	rows, err := q.db.Query(ctx, listOrderDeadLetters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*ListOrderDeadLettersRow
+	for rows.Next() {
+		var i ListOrderDeadLettersRow
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.Num,
+			&i.Error,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderDiscrepancies = `-- name: ListOrderDiscrepancies :many
+SELECT d.id, d.order_id, o.num, d.created_at, d.updated_at, d.status, d.accrual,
+    d.expected_status, d.expected_accrual, d.adjusted_at, d.adjustment, d.reason
+FROM order_discrepancies d
+JOIN orders o ON o.id = d.order_id
+ORDER BY d.created_at DESC
+`
+
+type ListOrderDiscrepanciesRow struct {
+	ID              pgtype.UUID
+	OrderID         pgtype.UUID
+	Num             string
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	Status          OrderStatus
+	Accrual         int32
+	ExpectedStatus  string
+	ExpectedAccrual int32
+	AdjustedAt      pgtype.Timestamptz
+	Adjustment      pgtype.Int4
+	Reason          pgtype.Text
+}
+
+func (q *Queries) ListOrderDiscrepancies(ctx context.Context) ([]*ListOrderDiscrepanciesRow, error) {
+	rows, err := q.db.Query(ctx, listOrderDiscrepancies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*ListOrderDiscrepanciesRow
+	for rows.Next() {
+		var i ListOrderDiscrepanciesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Num,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.Accrual,
+			&i.ExpectedStatus,
+			&i.ExpectedAccrual,
+			&i.AdjustedAt,
+			&i.Adjustment,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSigningKeys = `-- name: ListSigningKeys :many
+SELECT id, algorithm, private_key, created_at, active_at, retire_at, expires_at FROM signing_keys
+WHERE expires_at > now()
+ORDER BY active_at
+`
+
+func (q *Queries) ListSigningKeys(ctx context.Context) ([]*SigningKey, error) {
+	rows, err := q.db.Query(ctx, listSigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SigningKey
+	for rows.Next() {
+		var i SigningKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Algorithm,
+			&i.PrivateKey,
+			&i.CreatedAt,
+			&i.ActiveAt,
+			&i.RetireAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, family_id, user_id, token_hash, user_agent, started_at, created_at, expires_at, rotated_at, revoked_at FROM sessions
+WHERE user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID pgtype.UUID) ([]*Session, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.FamilyID,
+			&i.UserID,
+			&i.TokenHash,
+			&i.UserAgent,
+			&i.StartedAt,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.RotatedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockSigningKeys = `-- name: LockSigningKeys :exec
+SELECT pg_advisory_xact_lock(hashtext('signing_keys'))
+`
+
+func (q *Queries) LockSigningKeys(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockSigningKeys)
+	return err
+}
+
+const markOrderDiscrepancyAdjusted = `-- name: MarkOrderDiscrepancyAdjusted :one
+UPDATE order_discrepancies
+SET adjusted_at = now(), updated_at = now(), adjustment = $1, reason = $2
+WHERE id = $3
+RETURNING id, order_id, created_at, updated_at, status, accrual, expected_status, expected_accrual, adjusted_at, adjustment, reason
+`
+
+type MarkOrderDiscrepancyAdjustedParams struct {
+	Adjustment pgtype.Int4
+	Reason     pgtype.Text
+	ID         pgtype.UUID
+}
+
+func (q *Queries) MarkOrderDiscrepancyAdjusted(ctx context.Context, arg MarkOrderDiscrepancyAdjustedParams) (*OrderDiscrepancy, error) {
+	row := q.db.QueryRow(ctx, markOrderDiscrepancyAdjusted, arg.Adjustment, arg.Reason, arg.ID)
+	var i OrderDiscrepancy
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.Accrual,
+		&i.ExpectedStatus,
+		&i.ExpectedAccrual,
+		&i.AdjustedAt,
+		&i.Adjustment,
+		&i.Reason,
+	)
+	return &i, err
+}
+
+const markOrderReconciled = `-- name: MarkOrderReconciled :exec
+UPDATE orders
+SET reconciled_at = now(), reconcile_lease_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkOrderReconciled(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markOrderReconciled, id)
+	return err
+}
+
+const markSessionRotated = `-- name: MarkSessionRotated :one
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+RETURNING id, family_id, user_id, token_hash, user_agent, started_at, created_at, expires_at, rotated_at, revoked_at
+`
+
+func (q *Queries) MarkSessionRotated(ctx context.Context, id pgtype.UUID) (*Session, error) {
+	row := q.db.QueryRow(ctx, markSessionRotated, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.UserAgent,
+		&i.StartedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RotatedAt,
+		&i.RevokedAt,
+	)
+	return &i, err
+}
+
+const postponeOrderCheck = `-- name: PostponeOrderCheck :one
+UPDATE orders
+SET next_attempt_at = $1
+WHERE id = $2 AND status IN ('NEW', 'PROCESSING') AND next_attempt_at IS NOT NULL
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+type PostponeOrderCheckParams struct {
+	NextAttemptAt pgtype.Timestamptz
+	ID            pgtype.UUID
+}
+
+func (q *Queries) PostponeOrderCheck(ctx context.Context, arg PostponeOrderCheckParams) (*Order, error) {
+	row := q.db.QueryRow(ctx, postponeOrderCheck, arg.NextAttemptAt, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.Num,
+		&i.Accrual,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const recordOrderAttempt = `-- name: RecordOrderAttempt :one
+UPDATE orders
+SET attempts = $1, next_attempt_at = $2, last_error = $3
+WHERE id = $4
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+type RecordOrderAttemptParams struct {
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	ID            pgtype.UUID
+}
+
+func (q *Queries) RecordOrderAttempt(ctx context.Context, arg RecordOrderAttemptParams) (*Order, error) {
+	row := q.db.QueryRow(ctx, recordOrderAttempt, arg.Attempts, arg.NextAttemptAt, arg.LastError, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.Num,
+		&i.Accrual,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const releaseOrder = `-- name: ReleaseOrder :exec
+UPDATE orders
+SET next_attempt_at = now()
+WHERE id = $1 AND status IN ('NEW', 'PROCESSING')
+`
+
+func (q *Queries) ReleaseOrder(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, releaseOrder, id)
+	return err
+}
+
+const retryAllOrderDeadLetters = `-- name: RetryAllOrderDeadLetters :execrows
+WITH deleted AS (
+    DELETE FROM order_dead_letters
+    RETURNING order_id
+), retried AS (
+    UPDATE orders
+    SET attempts = 0, next_attempt_at = now(), last_error = NULL
+    WHERE id IN (SELECT order_id FROM deleted)
+    RETURNING id, status
+)
+INSERT INTO order_events (order_id, type, status)
+SELECT id, 'RETRIED', status FROM retried
+`
+
+func (q *Queries) RetryAllOrderDeadLetters(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, retryAllOrderDeadLetters)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const retryOrderDeadLetter = `-- name: RetryOrderDeadLetter :one
+WITH deleted AS (
+    DELETE FROM order_dead_letters
+    WHERE order_id = (SELECT id FROM orders WHERE num = $1)
+    RETURNING order_id
+)
+UPDATE orders
+SET attempts = 0, next_attempt_at = now(), last_error = NULL
+WHERE id IN (SELECT order_id FROM deleted)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
+`
+
+func (q *Queries) RetryOrderDeadLetter(ctx context.Context, num string) (*Order, error) {
+	row := q.db.QueryRow(ctx, retryOrderDeadLetter, num)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.Num,
+		&i.Accrual,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const revokeSessionFamily = `-- name: RevokeSessionFamily :execrows
+UPDATE sessions
+SET revoked_at = now()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionFamilyParams struct {
+	FamilyID pgtype.UUID
+	UserID   pgtype.UUID
+}
+
+func (q *Queries) RevokeSessionFamily(ctx context.Context, arg RevokeSessionFamilyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeSessionFamily, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, revokeUserSessions, userID)
+	return err
+}
+
 const saveOrder = `-- name: SaveOrder :one
-INSERT INTO orders (user_id, num, accrual, status)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, created_at, num, accrual, status
+INSERT INTO orders (user_id, num, accrual, status, next_attempt_at, accrual_provider)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
 `
 
 type SaveOrderParams struct {
-	UserID  pgtype.UUID
-	Num     string
-	Accrual pgtype.Int4
-	Status  OrderStatus
+	UserID          pgtype.UUID
+	Num             string
+	Accrual         pgtype.Int4
+	Status          OrderStatus
+	NextAttemptAt   pgtype.Timestamptz
+	AccrualProvider pgtype.Text
 }
 
 func (q *Queries) SaveOrder(ctx context.Context, arg SaveOrderParams) (*Order, error) {
@@ -217,6 +1111,8 @@ func (q *Queries) SaveOrder(ctx context.Context, arg SaveOrderParams) (*Order, e
 		arg.Num,
 		arg.Accrual,
 		arg.Status,
+		arg.NextAttemptAt,
+		arg.AccrualProvider,
 	)
 	var i Order
 	err := row.Scan(
@@ -226,23 +1122,192 @@ func (q *Queries) SaveOrder(ctx context.Context, arg SaveOrderParams) (*Order, e
 		&i.Num,
 		&i.Accrual,
 		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
+	)
+	return &i, err
+}
+
+const saveOrderDiscrepancy = `-- name: SaveOrderDiscrepancy :one
+INSERT INTO order_discrepancies (order_id, status, accrual, expected_status, expected_accrual)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (order_id) WHERE adjusted_at IS NULL DO UPDATE
+SET status = EXCLUDED.status,
+    accrual = EXCLUDED.accrual,
+    expected_status = EXCLUDED.expected_status,
+    expected_accrual = EXCLUDED.expected_accrual,
+    updated_at = now()
+RETURNING id, order_id, created_at, updated_at, status, accrual, expected_status, expected_accrual, adjusted_at, adjustment, reason
+`
+
+type SaveOrderDiscrepancyParams struct {
+	OrderID         pgtype.UUID
+	Status          OrderStatus
+	Accrual         int32
+	ExpectedStatus  string
+	ExpectedAccrual int32
+}
+
+func (q *Queries) SaveOrderDiscrepancy(ctx context.Context, arg SaveOrderDiscrepancyParams) (*OrderDiscrepancy, error) {
+	row := q.db.QueryRow(ctx, saveOrderDiscrepancy,
+		arg.OrderID,
+		arg.Status,
+		arg.Accrual,
+		arg.ExpectedStatus,
+		arg.ExpectedAccrual,
+	)
+	var i OrderDiscrepancy
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.Accrual,
+		&i.ExpectedStatus,
+		&i.ExpectedAccrual,
+		&i.AdjustedAt,
+		&i.Adjustment,
+		&i.Reason,
+	)
+	return &i, err
+}
+
+const saveRotatedSession = `-- name: SaveRotatedSession :one
+INSERT INTO sessions (family_id, user_id, token_hash, user_agent, started_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, family_id, user_id, token_hash, user_agent, started_at, created_at, expires_at, rotated_at, revoked_at
+`
+
+type SaveRotatedSessionParams struct {
+	FamilyID  pgtype.UUID
+	UserID    pgtype.UUID
+	TokenHash string
+	UserAgent string
+	StartedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) SaveRotatedSession(ctx context.Context, arg SaveRotatedSessionParams) (*Session, error) {
+	row := q.db.QueryRow(ctx, saveRotatedSession,
+		arg.FamilyID,
+		arg.UserID,
+		arg.TokenHash,
+		arg.UserAgent,
+		arg.StartedAt,
+		arg.ExpiresAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.UserAgent,
+		&i.StartedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RotatedAt,
+		&i.RevokedAt,
+	)
+	return &i, err
+}
+
+const saveSession = `-- name: SaveSession :one
+INSERT INTO sessions (user_id, token_hash, user_agent, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, family_id, user_id, token_hash, user_agent, started_at, created_at, expires_at, rotated_at, revoked_at
+`
+
+type SaveSessionParams struct {
+	UserID    pgtype.UUID
+	TokenHash string
+	UserAgent string
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) SaveSession(ctx context.Context, arg SaveSessionParams) (*Session, error) {
+	row := q.db.QueryRow(ctx, saveSession,
+		arg.UserID,
+		arg.TokenHash,
+		arg.UserAgent,
+		arg.ExpiresAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.UserAgent,
+		&i.StartedAt,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RotatedAt,
+		&i.RevokedAt,
+	)
+	return &i, err
+}
+
+const saveSigningKey = `-- name: SaveSigningKey :one
+INSERT INTO signing_keys (id, algorithm, private_key, active_at, retire_at, expires_at)
+SELECT $1::uuid, $2::varchar, $3::bytea,
+    $4::timestamptz, $5::timestamptz, $6::timestamptz
+WHERE NOT EXISTS (
+    SELECT 1 FROM signing_keys WHERE retire_at > $4::timestamptz
+)
+RETURNING id, algorithm, private_key, created_at, active_at, retire_at, expires_at
+`
+
+type SaveSigningKeyParams struct {
+	ID         pgtype.UUID
+	Algorithm  string
+	PrivateKey []byte
+	ActiveAt   pgtype.Timestamptz
+	RetireAt   pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+}
+
+func (q *Queries) SaveSigningKey(ctx context.Context, arg SaveSigningKeyParams) (*SigningKey, error) {
+	row := q.db.QueryRow(ctx, saveSigningKey,
+		arg.ID,
+		arg.Algorithm,
+		arg.PrivateKey,
+		arg.ActiveAt,
+		arg.RetireAt,
+		arg.ExpiresAt,
+	)
+	var i SigningKey
+	err := row.Scan(
+		&i.ID,
+		&i.Algorithm,
+		&i.PrivateKey,
+		&i.CreatedAt,
+		&i.ActiveAt,
+		&i.RetireAt,
+		&i.ExpiresAt,
 	)
 	return &i, err
 }
 
 const saveUser = `-- name: SaveUser :one
-INSERT INTO users (login, password)
-VALUES ($1, $2)
-RETURNING id, login, password, created_at, balance
+INSERT INTO users (login, password, login_key)
+VALUES ($1, $2, $3)
+RETURNING id, login, password, created_at, balance, login_key
 `
 
 type SaveUserParams struct {
 	Login    string
 	Password string
+	LoginKey pgtype.Text
 }
 
 func (q *Queries) SaveUser(ctx context.Context, arg SaveUserParams) (*User, error) {
-	row := q.db.QueryRow(ctx, saveUser, arg.Login, arg.Password)
+	row := q.db.QueryRow(ctx, saveUser, arg.Login, arg.Password, arg.LoginKey)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -250,6 +1315,7 @@ func (q *Queries) SaveUser(ctx context.Context, arg SaveUserParams) (*User, erro
 		&i.Password,
 		&i.CreatedAt,
 		&i.Balance,
+		&i.LoginKey,
 	)
 	return &i, err
 }
@@ -258,7 +1324,7 @@ const setOrderAccrual = `-- name: SetOrderAccrual :one
 UPDATE orders
 SET accrual = $1
 WHERE id = $2
-RETURNING id, user_id, created_at, num, accrual, status
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
 `
 
 type SetOrderAccrualParams struct {
@@ -276,6 +1342,12 @@ func (q *Queries) SetOrderAccrual(ctx context.Context, arg SetOrderAccrualParams
 		&i.Num,
 		&i.Accrual,
 		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
 	)
 	return &i, err
 }
@@ -283,17 +1355,18 @@ func (q *Queries) SetOrderAccrual(ctx context.Context, arg SetOrderAccrualParams
 const setOrderStatus = `-- name: SetOrderStatus :one
 UPDATE orders
 SET status = $1
-WHERE id = $2
-RETURNING id, user_id, created_at, num, accrual, status
+WHERE id = $2 AND status::text = ANY($3::text[])
+RETURNING id, user_id, created_at, num, accrual, status, attempts, next_attempt_at, last_error, accrual_provider, reconciled_at, reconcile_lease_until
 `
 
 type SetOrderStatusParams struct {
-	Status OrderStatus
-	ID     pgtype.UUID
+	Status       OrderStatus
+	ID           pgtype.UUID
+	FromStatuses []string
 }
 
 func (q *Queries) SetOrderStatus(ctx context.Context, arg SetOrderStatusParams) (*Order, error) {
-	row := q.db.QueryRow(ctx, setOrderStatus, arg.Status, arg.ID)
+	row := q.db.QueryRow(ctx, setOrderStatus, arg.Status, arg.ID, arg.FromStatuses)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -302,6 +1375,12 @@ func (q *Queries) SetOrderStatus(ctx context.Context, arg SetOrderStatusParams)
 		&i.Num,
 		&i.Accrual,
 		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.AccrualProvider,
+		&i.ReconciledAt,
+		&i.ReconcileLeaseUntil,
 	)
 	return &i, err
 }
@@ -337,6 +1416,22 @@ func (q *Queries) SetUserBalance(ctx context.Context, arg SetUserBalanceParams)
 	return &i, err
 }
 
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET password = $1
+WHERE id = $2
+`
+
+type SetUserPasswordParams struct {
+	Password string
+	ID       pgtype.UUID
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, setUserPassword, arg.Password, arg.ID)
+	return err
+}
+
 const substractUserBalance = `-- name: SubstractUserBalance :one
 UPDATE users
 SET balance = balance - $1
@@ -3,9 +3,10 @@ CREATE TYPE order_status AS ENUM ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED');
 CREATE TABLE users (
     id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
     login varchar(64) NOT NULL UNIQUE,
-    password varchar(64) NOT NULL,
+    password varchar(256) NOT NULL,
     created_at timestamptz NOT NULL DEFAULT now(),
-    balance integer DEFAULT 0
+    balance integer DEFAULT 0,
+    login_key varchar(256) UNIQUE
 );
 
 CREATE TABLE orders (
@@ -14,7 +15,13 @@ CREATE TABLE orders (
     created_at timestamptz NOT NULL DEFAULT now(),
     num varchar(256) NOT NULL UNIQUE,
     accrual integer,
-    status order_status NOT NULL DEFAULT 'NEW'
+    status order_status NOT NULL DEFAULT 'NEW',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz DEFAULT now(),
+    last_error text,
+    accrual_provider varchar(64),
+    reconciled_at timestamptz,
+    reconcile_lease_until timestamptz
 );
 
 CREATE TABLE withdrawals (
@@ -23,4 +30,60 @@ CREATE TABLE withdrawals (
     order_num varchar(256) NOT NULL,
     created_at timestamptz NOT NULL DEFAULT now(),
     amount integer NOT NULL
-);
\ No newline at end of file
+);
+
+CREATE TABLE order_dead_letters (
+    order_id uuid PRIMARY KEY references orders(id),
+    error text NOT NULL,
+    attempts integer NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE order_events (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id uuid NOT NULL references orders(id),
+    created_at timestamptz NOT NULL DEFAULT clock_timestamp(),
+    type varchar(32) NOT NULL,
+    status order_status,
+    accrual integer,
+    error text,
+    public_error text
+);
+
+CREATE TABLE order_discrepancies (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id uuid NOT NULL references orders(id),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    status order_status NOT NULL,
+    accrual integer NOT NULL,
+    expected_status varchar(32) NOT NULL,
+    expected_accrual integer NOT NULL,
+    adjusted_at timestamptz,
+    adjustment integer,
+    reason text
+);
+
+CREATE TABLE sessions (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id uuid NOT NULL DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL references users(id),
+    token_hash varchar(64) NOT NULL UNIQUE,
+    user_agent text NOT NULL DEFAULT '',
+    started_at timestamptz NOT NULL DEFAULT now(),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    rotated_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE TABLE signing_keys (
+    id uuid PRIMARY KEY,
+    algorithm varchar(16) NOT NULL,
+    private_key bytea NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    active_at timestamptz NOT NULL,
+    retire_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dtroode/gophermart/database"
 	"github.com/dtroode/gophermart/internal/application"
@@ -16,6 +17,11 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	// uniqueViolationCode is postgres error code of unique constraint violation.
+	uniqueViolationCode = "23505"
+)
+
 type Storage struct {
 	db      *pgxpool.Pool
 	queries *Queries
@@ -58,19 +64,14 @@ func (s *Storage) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error
 		return nil, err
 	}
 
-	user := &model.User{
-		ID:        dbUser.ID.Bytes,
-		Login:     dbUser.Login,
-		Password:  dbUser.Password,
-		CreatedAt: dbUser.CreatedAt.Time,
-		Balance:   dbUser.Balance.Int32,
-	}
-
-	return user, nil
+	return userFromDB(dbUser), nil
 }
 
-func (s *Storage) GetUserByLogin(ctx context.Context, login string) (*model.User, error) {
-	dbUser, err := s.queries.GetUserByLogin(ctx, login)
+func (s *Storage) GetUserByLogin(ctx context.Context, dto *storage.GetUserByLogin) (*model.User, error) {
+	dbUser, err := s.queries.GetUserByLogin(ctx, GetUserByLoginParams{
+		Login:    dto.Login,
+		LoginKey: pgtype.Text{String: dto.LoginKey, Valid: dto.LoginKey != ""},
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, application.ErrNotFound
@@ -78,71 +79,51 @@ func (s *Storage) GetUserByLogin(ctx context.Context, login string) (*model.User
 		return nil, err
 	}
 
-	user := &model.User{
-		ID:        dbUser.ID.Bytes,
-		Login:     dbUser.Login,
-		Password:  dbUser.Password,
-		CreatedAt: dbUser.CreatedAt.Time,
-		Balance:   dbUser.Balance.Int32,
-	}
-
-	return user, nil
+	return userFromDB(dbUser), nil
 }
 
+// SaveUser returns ErrConflict if the login or the login key is taken.
 func (s *Storage) SaveUser(ctx context.Context, user *model.User) (*model.User, error) {
 	params := SaveUserParams{
 		Login:    user.Login,
 		Password: user.Password,
+		LoginKey: pgtype.Text{String: user.LoginKey, Valid: user.LoginKey != ""},
 	}
 
 	dbUser, err := s.queries.SaveUser(ctx, params)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return nil, application.ErrConflict
+		}
 		return nil, err
 	}
 
-	user = &model.User{
-		ID:        dbUser.ID.Bytes,
-		Login:     dbUser.Login,
-		Password:  dbUser.Password,
-		CreatedAt: dbUser.CreatedAt.Time,
-		Balance:   dbUser.Balance.Int32,
-	}
-
-	return user, nil
+	return userFromDB(dbUser), nil
 }
 
 func (s *Storage) WithdrawUserBonuses(ctx context.Context, dto *storage.WithdrawUserBonuses) (*model.User, error) {
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start tx: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	qtx := s.queries.WithTx(tx)
-
-	dbUser, err := qtx.SubstractUserBalance(ctx, SubstractUserBalanceParams{
-		Balance: pgtype.Int4{Int32: dto.Sum, Valid: true},
-		ID:      pgtype.UUID{Bytes: dto.UserID, Valid: true},
-	})
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.ConstraintName == "users_balance_nonnegative" {
-				return nil, application.ErrNotEnoughBonuses
-			}
+	var dbUser *SubstractUserBalanceRow
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbUser, err = q.SubstractUserBalance(ctx, SubstractUserBalanceParams{
+			Balance: pgtype.Int4{Int32: dto.Sum, Valid: true},
+			ID:      pgtype.UUID{Bytes: dto.UserID, Valid: true},
+		})
+		if err != nil {
+			return balanceError(err)
 		}
-		return nil, err
-	}
 
-	_, err = qtx.CreateWithdrawal(ctx, CreateWithdrawalParams{
-		UserID:   dbUser.ID,
-		OrderNum: dto.OrderNum,
-		Amount:   dto.Sum,
+		_, err = q.CreateWithdrawal(ctx, CreateWithdrawalParams{
+			UserID:   dbUser.ID,
+			OrderNum: dto.OrderNum,
+			Amount:   dto.Sum,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
-	tx.Commit(ctx)
 
 	user := &model.User{
 		ID:        dbUser.ID.Bytes,
@@ -174,6 +155,15 @@ func (s *Storage) SetUserBalance(ctx context.Context, dto *storage.SetUserBalanc
 	return user, nil
 }
 
+func (s *Storage) SetUserPassword(ctx context.Context, dto *storage.SetUserPassword) error {
+	params := SetUserPasswordParams{
+		Password: dto.Password,
+		ID:       pgtype.UUID{Bytes: dto.ID, Valid: true},
+	}
+
+	return s.queries.SetUserPassword(ctx, params)
+}
+
 func (s *Storage) IncrementUserBalance(ctx context.Context, dto *storage.IncrementUserBalance) (*model.User, error) {
 	params := IncrementUserBalanceParams{
 		Balance: pgtype.Int4{Int32: dto.Sum, Valid: true},
@@ -203,38 +193,48 @@ func (s *Storage) GetOrderByNumber(ctx context.Context, number string) (*model.O
 		return nil, err
 	}
 
-	order := &model.Order{
-		ID:        dbOrder.ID.Bytes,
-		UserID:    dbOrder.UserID.Bytes,
-		CreatedAt: dbOrder.CreatedAt.Time,
-		Number:    dbOrder.Num,
-		Accrual:   dbOrder.Accrual.Int32,
-		Status:    model.OrderStatus(dbOrder.Status),
-	}
+	order := orderFromDB(dbOrder)
 
 	return order, nil
 }
 
 func (s *Storage) SaveOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	nextAttemptAt := order.NextAttemptAt
+	if nextAttemptAt.IsZero() {
+		nextAttemptAt = time.Now()
+	}
+
 	params := SaveOrderParams{
-		UserID:  pgtype.UUID{Bytes: order.UserID, Valid: true},
-		Num:     order.Number,
-		Accrual: pgtype.Int4{Int32: order.Accrual, Valid: true},
-		Status:  OrderStatus(order.Status),
+		UserID:        pgtype.UUID{Bytes: order.UserID, Valid: true},
+		Num:           order.Number,
+		Accrual:       pgtype.Int4{Int32: order.Accrual, Valid: true},
+		Status:        OrderStatus(order.Status),
+		NextAttemptAt: pgtype.Timestamptz{Time: nextAttemptAt, Valid: true},
+		AccrualProvider: pgtype.Text{
+			String: order.AccrualProvider,
+			Valid:  order.AccrualProvider != "",
+		},
 	}
-	dbOrder, err := s.queries.SaveOrder(ctx, params)
+
+	var dbOrder *Order
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbOrder, err = q.SaveOrder(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID: dbOrder.ID,
+			Type:    string(model.OrderEventUploaded),
+			Status:  NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	order = &model.Order{
-		ID:        dbOrder.ID.Bytes,
-		UserID:    dbOrder.UserID.Bytes,
-		CreatedAt: dbOrder.CreatedAt.Time,
-		Number:    dbOrder.Num,
-		Accrual:   dbOrder.Accrual.Int32,
-		Status:    model.OrderStatus(dbOrder.Status),
-	}
+	order = orderFromDB(dbOrder)
 
 	return order, nil
 }
@@ -249,88 +249,338 @@ func (s *Storage) SetOrderAccrual(ctx context.Context, dto *storage.SetOrderAccr
 		return nil, err
 	}
 
-	order := &model.Order{
-		ID:        dbOrder.ID.Bytes,
-		UserID:    dbOrder.UserID.Bytes,
-		CreatedAt: dbOrder.CreatedAt.Time,
-		Number:    dbOrder.Num,
-		Accrual:   dbOrder.Accrual.Int32,
-		Status:    model.OrderStatus(dbOrder.Status),
-	}
+	order := orderFromDB(dbOrder)
 
 	return order, nil
 }
 
+// SetOrderStatus moves the order to dto.Status if the transition is allowed
+// by its current status, otherwise it returns application.ErrIllegalTransition.
 func (s *Storage) SetOrderStatus(ctx context.Context, dto *storage.SetOrderStatus) (*model.Order, error) {
-	params := SetOrderStatusParams{
-		Status: OrderStatus(dto.Status),
-		ID:     pgtype.UUID{Bytes: dto.ID, Valid: true},
-	}
-	dbOrder, err := s.queries.SetOrderStatus(ctx, params)
+	var dbOrder *Order
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbOrder, err = s.transitionOrder(ctx, q, dto.ID, dto.Status)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID: dbOrder.ID,
+			Type:    string(model.OrderEventStatusChanged),
+			Status:  NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	order := &model.Order{
-		ID:        dbOrder.ID.Bytes,
-		UserID:    dbOrder.UserID.Bytes,
-		CreatedAt: dbOrder.CreatedAt.Time,
-		Number:    dbOrder.Num,
-		Accrual:   dbOrder.Accrual.Int32,
-		Status:    model.OrderStatus(dbOrder.Status),
-	}
+	order := orderFromDB(dbOrder)
 
 	return order, nil
 }
 
+// SetOrderStatusAndAccrual finalizes the order and credits its accrual to the user.
+// The order is credited at most once, since final statuses allow no transitions.
 func (s *Storage) SetOrderStatusAndAccrual(ctx context.Context, dto *storage.SetOrderStatusAndAccrual) (*model.Order, error) {
-	tx, err := s.db.Begin(ctx)
+	var dbOrder *Order
+	err := s.inTx(ctx, func(q *Queries) error {
+		_, err := s.transitionOrder(ctx, q, dto.ID, dto.Status)
+		if err != nil {
+			return err
+		}
+
+		accrualParams := SetOrderAccrualParams{
+			Accrual: pgtype.Int4{Int32: dto.Accrual, Valid: true},
+			ID:      pgtype.UUID{Bytes: dto.ID, Valid: true},
+		}
+		dbOrder, err = q.SetOrderAccrual(ctx, accrualParams)
+		if err != nil {
+			return err
+		}
+
+		userParams := IncrementUserBalanceParams{
+			Balance: pgtype.Int4{Int32: dto.Accrual, Valid: true},
+			ID:      dbOrder.UserID,
+		}
+		_, err = q.IncrementUserBalance(ctx, userParams)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID: dbOrder.ID,
+			Type:    string(model.OrderEventStatusChanged),
+			Status:  NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+			Accrual: dbOrder.Accrual,
+		})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to start tx: %w", err)
+		return nil, err
+	}
+
+	order := orderFromDB(dbOrder)
+
+	return order, nil
+}
+
+// transitionOrder updates order status only if the order is in one of the statuses
+// allowed to move to status. Concurrent transitions of the same order are serialized
+// by the row lock, so only one of them succeeds.
+func (s *Storage) transitionOrder(ctx context.Context, q *Queries, id uuid.UUID, status model.OrderStatus) (*Order, error) {
+	previous := status.PreviousStatuses()
+	fromStatuses := make([]string, len(previous))
+	for i, from := range previous {
+		fromStatuses[i] = string(from)
+	}
+
+	params := SetOrderStatusParams{
+		Status:       OrderStatus(status),
+		ID:           pgtype.UUID{Bytes: id, Valid: true},
+		FromStatuses: fromStatuses,
+	}
+	dbOrder, err := q.SetOrderStatus(ctx, params)
+	if err == nil {
+		return dbOrder, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
 	}
-	defer tx.Rollback(ctx)
 
-	qtx := s.queries.WithTx(tx)
+	if _, err := q.GetOrderStatus(ctx, params.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return nil, application.ErrIllegalTransition
+}
+
+// ReleaseOrder makes the order due again without counting an attempt.
+func (s *Storage) ReleaseOrder(ctx context.Context, id uuid.UUID) error {
+	return s.queries.ReleaseOrder(ctx, pgtype.UUID{Bytes: id, Valid: true})
+}
+
+// PostponeOrderCheck schedules the next check of the order without counting an attempt.
+// It returns ErrNotFound if the order is final or dead lettered.
+func (s *Storage) PostponeOrderCheck(ctx context.Context, dto *storage.PostponeOrderCheck) (*model.Order, error) {
+	dbOrder, err := s.queries.PostponeOrderCheck(ctx, PostponeOrderCheckParams{
+		NextAttemptAt: pgtype.Timestamptz{Time: dto.NextAttemptAt, Valid: true},
+		ID:            pgtype.UUID{Bytes: dto.ID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
 
-	statusParams := SetOrderStatusParams{
-		Status: OrderStatus(dto.Status),
-		ID:     pgtype.UUID{Bytes: dto.ID, Valid: true},
+	return orderFromDB(dbOrder), nil
+}
+
+// RecordOrderAttempt saves the outcome of the check to the order timeline and schedules the next one.
+func (s *Storage) RecordOrderAttempt(ctx context.Context, dto *storage.RecordOrderAttempt) (*model.Order, error) {
+	params := RecordOrderAttemptParams{
+		Attempts:      dto.Attempts,
+		NextAttemptAt: pgtype.Timestamptz{Time: dto.NextAttemptAt, Valid: true},
+		LastError:     pgtype.Text{String: dto.LastError, Valid: dto.LastError != ""},
+		ID:            pgtype.UUID{Bytes: dto.ID, Valid: true},
 	}
-	_, err = qtx.SetOrderStatus(ctx, statusParams)
+
+	var dbOrder *Order
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbOrder, err = q.RecordOrderAttempt(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID:     dbOrder.ID,
+			Type:        string(model.OrderEventChecked),
+			Status:      NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+			Error:       params.LastError,
+			PublicError: pgtype.Text{String: dto.PublicError, Valid: dto.PublicError != ""},
+		})
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
 		return nil, err
 	}
 
-	accrualParams := SetOrderAccrualParams{
-		Accrual: pgtype.Int4{Int32: dto.Accrual, Valid: true},
-		ID:      pgtype.UUID{Bytes: dto.ID, Valid: true},
+	return orderFromDB(dbOrder), nil
+}
+
+// ClaimDueOrders locks orders due for a check and leases them until dto.LeaseUntil,
+// so other instances skip them while they are processed. Users with due orders take turns,
+// each of them locks only its share of the batch.
+func (s *Storage) ClaimDueOrders(ctx context.Context, dto *storage.ClaimDueOrders) ([]*model.Order, error) {
+	params := ClaimDueOrdersParams{
+		BatchSize:  dto.BatchSize,
+		LeaseUntil: pgtype.Timestamptz{Time: dto.LeaseUntil, Valid: true},
+	}
+	dbOrders, err := s.queries.ClaimDueOrders(ctx, params)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
 	}
-	dbOrder, err := qtx.SetOrderAccrual(ctx, accrualParams)
+
+	orders := make([]*model.Order, len(dbOrders))
+
+	for i, dbOrder := range dbOrders {
+		orders[i] = orderFromDB(dbOrder)
+	}
+
+	return orders, nil
+}
+
+// DeadLetterOrder stops checks of the order and records why it failed.
+// It returns ErrNotFound if the order is final already.
+func (s *Storage) DeadLetterOrder(ctx context.Context, dto *storage.DeadLetterOrder) (*model.OrderDeadLetter, error) {
+	params := DeadLetterOrderParams{
+		ID:    pgtype.UUID{Bytes: dto.ID, Valid: true},
+		Error: dto.Error,
+	}
+
+	var dbDeadLetter *OrderDeadLetter
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbDeadLetter, err = q.DeadLetterOrder(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID:     dbDeadLetter.OrderID,
+			Type:        string(model.OrderEventFailed),
+			Error:       pgtype.Text{String: dbDeadLetter.Error, Valid: true},
+			PublicError: pgtype.Text{String: dto.PublicError, Valid: dto.PublicError != ""},
+		})
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
+
+	deadLetter := &model.OrderDeadLetter{
+		OrderID:   dbDeadLetter.OrderID.Bytes,
+		Error:     dbDeadLetter.Error,
+		Attempts:  dbDeadLetter.Attempts,
+		CreatedAt: dbDeadLetter.CreatedAt.Time,
+		UpdatedAt: dbDeadLetter.UpdatedAt.Time,
+	}
+
+	return deadLetter, nil
+}
+
+func (s *Storage) ListOrderDeadLetters(ctx context.Context) ([]*model.OrderDeadLetter, error) {
+	dbDeadLetters, err := s.queries.ListOrderDeadLetters(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, err
 	}
 
-	userParams := IncrementUserBalanceParams{
-		Balance: pgtype.Int4{Int32: dto.Accrual, Valid: true},
-		ID:      dbOrder.UserID,
+	deadLetters := make([]*model.OrderDeadLetter, len(dbDeadLetters))
+
+	for i, dbDeadLetter := range dbDeadLetters {
+		deadLetters[i] = &model.OrderDeadLetter{
+			OrderID:     dbDeadLetter.OrderID.Bytes,
+			OrderNumber: dbDeadLetter.Num,
+			Error:       dbDeadLetter.Error,
+			Attempts:    dbDeadLetter.Attempts,
+			CreatedAt:   dbDeadLetter.CreatedAt.Time,
+			UpdatedAt:   dbDeadLetter.UpdatedAt.Time,
+		}
 	}
-	_, err = qtx.IncrementUserBalance(ctx, userParams)
+
+	return deadLetters, nil
+}
+
+// RetryOrderDeadLetter removes the order from dead letters and schedules its check right away.
+// Failed attempts are forgotten, so the order gets the whole number of attempts again.
+func (s *Storage) RetryOrderDeadLetter(ctx context.Context, number string) (*model.Order, error) {
+	var dbOrder *Order
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbOrder, err = q.RetryOrderDeadLetter(ctx, number)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID: dbOrder.ID,
+			Type:    string(model.OrderEventRetried),
+			Status:  NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+		})
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
 		return nil, err
 	}
 
-	tx.Commit(ctx)
+	return orderFromDB(dbOrder), nil
+}
+
+// RetryAllOrderDeadLetters empties dead letters and schedules checks of their orders
+// with failed attempts forgotten, recording the retry in timelines of the orders.
+func (s *Storage) RetryAllOrderDeadLetters(ctx context.Context) (int64, error) {
+	return s.queries.RetryAllOrderDeadLetters(ctx)
+}
 
-	order := &model.Order{
-		ID:        dbOrder.ID.Bytes,
-		UserID:    dbOrder.UserID.Bytes,
-		CreatedAt: dbOrder.CreatedAt.Time,
-		Number:    dbOrder.Num,
-		Accrual:   dbOrder.Accrual.Int32,
-		Status:    model.OrderStatus(dbOrder.Status),
+// InvalidateOrderDeadLetter removes the order from dead letters and marks it invalid.
+func (s *Storage) InvalidateOrderDeadLetter(ctx context.Context, number string) (*model.Order, error) {
+	var dbOrder *Order
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		dbOrder, err = q.InvalidateOrderDeadLetter(ctx, number)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID: dbOrder.ID,
+			Type:    string(model.OrderEventStatusChanged),
+			Status:  NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+		})
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
 	}
 
-	return order, nil
+	return orderFromDB(dbOrder), nil
+}
+
+// GetOrderEvents returns order timeline, oldest events first.
+func (s *Storage) GetOrderEvents(ctx context.Context, orderID uuid.UUID) ([]*model.OrderEvent, error) {
+	dbEvents, err := s.queries.GetOrderEvents(ctx, pgtype.UUID{Bytes: orderID, Valid: true})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	events := make([]*model.OrderEvent, len(dbEvents))
+
+	for i, dbEvent := range dbEvents {
+		events[i] = &model.OrderEvent{
+			ID:          dbEvent.ID.Bytes,
+			OrderID:     dbEvent.OrderID.Bytes,
+			CreatedAt:   dbEvent.CreatedAt.Time,
+			Type:        model.OrderEventType(dbEvent.Type),
+			Status:      model.OrderStatus(dbEvent.Status.OrderStatus),
+			Accrual:     dbEvent.Accrual.Int32,
+			Error:       dbEvent.Error.String,
+			PublicError: dbEvent.PublicError.String,
+		}
+	}
+
+	return events, nil
 }
 
 func (s *Storage) GetUserOrdersNewestFirst(ctx context.Context, userID uuid.UUID) ([]*model.Order, error) {
@@ -342,15 +592,7 @@ func (s *Storage) GetUserOrdersNewestFirst(ctx context.Context, userID uuid.UUID
 	orders := make([]*model.Order, len(dbOrders))
 
 	for i, dbOrder := range dbOrders {
-		order := &model.Order{
-			ID:        dbOrder.ID.Bytes,
-			UserID:    dbOrder.UserID.Bytes,
-			CreatedAt: dbOrder.CreatedAt.Time,
-			Number:    dbOrder.Num,
-			Accrual:   dbOrder.Accrual.Int32,
-			Status:    model.OrderStatus(dbOrder.Status),
-		}
-		orders[i] = order
+		orders[i] = orderFromDB(dbOrder)
 	}
 
 	return orders, nil
@@ -390,3 +632,426 @@ func (s *Storage) GetUserWithdrawals(ctx context.Context, userID uuid.UUID) ([]*
 
 	return withdrawals, nil
 }
+
+// ClaimOrdersForReconciliation locks orders finalized after dto.FinalizedAfter
+// and not reconciled since dto.ReconciledBefore, and leases them until dto.LeaseUntil,
+// so other instances skip them. Orders without recorded status changes
+// are taken if they are uploaded after dto.FinalizedAfter.
+func (s *Storage) ClaimOrdersForReconciliation(ctx context.Context, dto *storage.ClaimOrdersForReconciliation) ([]*model.Order, error) {
+	params := ClaimOrdersForReconciliationParams{
+		LeaseUntil:       pgtype.Timestamptz{Time: dto.LeaseUntil, Valid: true},
+		ReconciledBefore: pgtype.Timestamptz{Time: dto.ReconciledBefore, Valid: true},
+		FinalizedAfter:   pgtype.Timestamptz{Time: dto.FinalizedAfter, Valid: true},
+		BatchSize:        dto.BatchSize,
+	}
+	dbOrders, err := s.queries.ClaimOrdersForReconciliation(ctx, params)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	orders := make([]*model.Order, len(dbOrders))
+
+	for i, dbOrder := range dbOrders {
+		orders[i] = orderFromDB(dbOrder)
+	}
+
+	return orders, nil
+}
+
+// MarkOrderReconciled ends the lease of the order compared with accrual system,
+// so it is not reconciled again for the reconcile period.
+func (s *Storage) MarkOrderReconciled(ctx context.Context, id uuid.UUID) error {
+	return s.queries.MarkOrderReconciled(ctx, pgtype.UUID{Bytes: id, Valid: true})
+}
+
+// SaveOrderDiscrepancy records the discrepancy, replacing the one of the same order
+// that is not adjusted yet.
+func (s *Storage) SaveOrderDiscrepancy(ctx context.Context, discrepancy *model.OrderDiscrepancy) (*model.OrderDiscrepancy, error) {
+	params := SaveOrderDiscrepancyParams{
+		OrderID:         pgtype.UUID{Bytes: discrepancy.OrderID, Valid: true},
+		Status:          OrderStatus(discrepancy.Status),
+		Accrual:         discrepancy.Accrual,
+		ExpectedStatus:  string(discrepancy.ExpectedStatus),
+		ExpectedAccrual: discrepancy.ExpectedAccrual,
+	}
+	dbDiscrepancy, err := s.queries.SaveOrderDiscrepancy(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return discrepancyFromDB(dbDiscrepancy, discrepancy.OrderNumber), nil
+}
+
+func (s *Storage) ListOrderDiscrepancies(ctx context.Context) ([]*model.OrderDiscrepancy, error) {
+	dbRows, err := s.queries.ListOrderDiscrepancies(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	discrepancies := make([]*model.OrderDiscrepancy, len(dbRows))
+
+	for i, dbRow := range dbRows {
+		discrepancies[i] = discrepancyFromDB(&OrderDiscrepancy{
+			ID:              dbRow.ID,
+			OrderID:         dbRow.OrderID,
+			CreatedAt:       dbRow.CreatedAt,
+			UpdatedAt:       dbRow.UpdatedAt,
+			Status:          dbRow.Status,
+			Accrual:         dbRow.Accrual,
+			ExpectedStatus:  dbRow.ExpectedStatus,
+			ExpectedAccrual: dbRow.ExpectedAccrual,
+			AdjustedAt:      dbRow.AdjustedAt,
+			Adjustment:      dbRow.Adjustment,
+			Reason:          dbRow.Reason,
+		}, dbRow.Num)
+	}
+
+	return discrepancies, nil
+}
+
+func (s *Storage) GetOrderDiscrepancy(ctx context.Context, id uuid.UUID) (*model.OrderDiscrepancy, error) {
+	dbRow, err := s.queries.GetOrderDiscrepancy(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
+
+	discrepancy := discrepancyFromDB(&OrderDiscrepancy{
+		ID:              dbRow.ID,
+		OrderID:         dbRow.OrderID,
+		CreatedAt:       dbRow.CreatedAt,
+		UpdatedAt:       dbRow.UpdatedAt,
+		Status:          dbRow.Status,
+		Accrual:         dbRow.Accrual,
+		ExpectedStatus:  dbRow.ExpectedStatus,
+		ExpectedAccrual: dbRow.ExpectedAccrual,
+		AdjustedAt:      dbRow.AdjustedAt,
+		Adjustment:      dbRow.Adjustment,
+		Reason:          dbRow.Reason,
+	}, dbRow.Num)
+
+	return discrepancy, nil
+}
+
+// AdjustOrderDiscrepancy sets accrual reported by accrual system to the order
+// and credits or debits the difference to the user. Discrepancies that are
+// already adjusted are reported as not found.
+func (s *Storage) AdjustOrderDiscrepancy(ctx context.Context, dto *storage.AdjustOrderDiscrepancy) (*model.OrderDiscrepancy, error) {
+	var (
+		dbDiscrepancy *OrderDiscrepancy
+		dbOrder       *Order
+	)
+	err := s.inTx(ctx, func(q *Queries) error {
+		discrepancy, err := q.GetOpenOrderDiscrepancyForUpdate(ctx, pgtype.UUID{Bytes: dto.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		order, err := q.GetOrderForUpdate(ctx, discrepancy.OrderID)
+		if err != nil {
+			return err
+		}
+		// the order may have changed since the discrepancy was found,
+		// so the difference is counted from its current accrual
+		adjustment := discrepancy.ExpectedAccrual - order.Accrual.Int32
+
+		dbOrder, err = q.AdjustOrder(ctx, AdjustOrderParams{
+			Status:  OrderStatus(dto.Status),
+			Accrual: pgtype.Int4{Int32: discrepancy.ExpectedAccrual, Valid: true},
+			ID:      order.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if adjustment != 0 {
+			_, err = q.IncrementUserBalance(ctx, IncrementUserBalanceParams{
+				Balance: pgtype.Int4{Int32: adjustment, Valid: true},
+				ID:      order.UserID,
+			})
+			if err != nil {
+				return balanceError(err)
+			}
+		}
+
+		err = q.CreateOrderEvent(ctx, CreateOrderEventParams{
+			OrderID: dbOrder.ID,
+			Type:    string(model.OrderEventAdjusted),
+			Status:  NullOrderStatus{OrderStatus: dbOrder.Status, Valid: true},
+			Accrual: dbOrder.Accrual,
+		})
+		if err != nil {
+			return err
+		}
+
+		dbDiscrepancy, err = q.MarkOrderDiscrepancyAdjusted(ctx, MarkOrderDiscrepancyAdjustedParams{
+			Adjustment: pgtype.Int4{Int32: adjustment, Valid: true},
+			Reason:     pgtype.Text{String: dto.Reason, Valid: true},
+			ID:         discrepancy.ID,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return discrepancyFromDB(dbDiscrepancy, dbOrder.Num), nil
+}
+
+func (s *Storage) SaveSession(ctx context.Context, session *model.Session) (*model.Session, error) {
+	params := SaveSessionParams{
+		UserID:    pgtype.UUID{Bytes: session.UserID, Valid: true},
+		TokenHash: session.TokenHash,
+		UserAgent: session.UserAgent,
+		ExpiresAt: pgtype.Timestamptz{Time: session.ExpiresAt, Valid: true},
+	}
+	dbSession, err := s.queries.SaveSession(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionFromDB(dbSession), nil
+}
+
+func (s *Storage) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	dbSession, err := s.queries.GetSessionByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return sessionFromDB(dbSession), nil
+}
+
+// RotateSession marks the session rotated and saves new session of its family.
+// Sessions that are already rotated or revoked are reported as not found.
+func (s *Storage) RotateSession(ctx context.Context, dto *storage.RotateSession) (*model.Session, error) {
+	var dbSession *Session
+	err := s.inTx(ctx, func(q *Queries) error {
+		rotated, err := q.MarkSessionRotated(ctx, pgtype.UUID{Bytes: dto.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		dbSession, err = q.SaveRotatedSession(ctx, SaveRotatedSessionParams{
+			FamilyID:  rotated.FamilyID,
+			UserID:    rotated.UserID,
+			TokenHash: dto.TokenHash,
+			UserAgent: rotated.UserAgent,
+			StartedAt: rotated.StartedAt,
+			ExpiresAt: pgtype.Timestamptz{Time: dto.ExpiresAt, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return sessionFromDB(dbSession), nil
+}
+
+// ListUserSessions returns sessions that can be refreshed, one per family.
+func (s *Storage) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	dbSessions, err := s.queries.ListUserSessions(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	sessions := make([]*model.Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = sessionFromDB(dbSession)
+	}
+
+	return sessions, nil
+}
+
+// IsSessionFamilyActive reports whether the family has sessions that are not revoked.
+func (s *Storage) IsSessionFamilyActive(ctx context.Context, dto *storage.IsSessionFamilyActive) (bool, error) {
+	return s.queries.IsSessionFamilyActive(ctx, IsSessionFamilyActiveParams{
+		FamilyID: pgtype.UUID{Bytes: dto.FamilyID, Valid: true},
+		UserID:   pgtype.UUID{Bytes: dto.UserID, Valid: true},
+	})
+}
+
+// RevokeSession revokes all sessions of the family. Family without sessions
+// to revoke is reported as not found.
+func (s *Storage) RevokeSession(ctx context.Context, dto *storage.RevokeSession) error {
+	revoked, err := s.queries.RevokeSessionFamily(ctx, RevokeSessionFamilyParams{
+		FamilyID: pgtype.UUID{Bytes: dto.FamilyID, Valid: true},
+		UserID:   pgtype.UUID{Bytes: dto.UserID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return application.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return s.queries.RevokeUserSessions(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+}
+
+// SaveSigningKey saves the key unless another key already signs after its activation,
+// which means another instance has just rotated keys. That is reported as conflict.
+func (s *Storage) SaveSigningKey(ctx context.Context, key *model.SigningKey) (*model.SigningKey, error) {
+	var dbKey *SigningKey
+	err := s.inTx(ctx, func(q *Queries) error {
+		if err := q.LockSigningKeys(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		dbKey, err = q.SaveSigningKey(ctx, SaveSigningKeyParams{
+			ID:         pgtype.UUID{Bytes: key.ID, Valid: true},
+			Algorithm:  key.Algorithm,
+			PrivateKey: key.PrivateKey,
+			ActiveAt:   pgtype.Timestamptz{Time: key.ActiveAt, Valid: true},
+			RetireAt:   pgtype.Timestamptz{Time: key.RetireAt, Valid: true},
+			ExpiresAt:  pgtype.Timestamptz{Time: key.ExpiresAt, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, application.ErrConflict
+		}
+		return nil, err
+	}
+
+	return signingKeyFromDB(dbKey), nil
+}
+
+// ListSigningKeys returns keys that are not expired yet, oldest first.
+func (s *Storage) ListSigningKeys(ctx context.Context) ([]*model.SigningKey, error) {
+	dbKeys, err := s.queries.ListSigningKeys(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	keys := make([]*model.SigningKey, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i] = signingKeyFromDB(dbKey)
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) DeleteExpiredSigningKeys(ctx context.Context) error {
+	return s.queries.DeleteExpiredSigningKeys(ctx)
+}
+
+func discrepancyFromDB(dbDiscrepancy *OrderDiscrepancy, orderNumber string) *model.OrderDiscrepancy {
+	return &model.OrderDiscrepancy{
+		ID:              dbDiscrepancy.ID.Bytes,
+		OrderID:         dbDiscrepancy.OrderID.Bytes,
+		OrderNumber:     orderNumber,
+		CreatedAt:       dbDiscrepancy.CreatedAt.Time,
+		UpdatedAt:       dbDiscrepancy.UpdatedAt.Time,
+		Status:          model.OrderStatus(dbDiscrepancy.Status),
+		Accrual:         dbDiscrepancy.Accrual,
+		ExpectedStatus:  model.AccrualOrderStatus(dbDiscrepancy.ExpectedStatus),
+		ExpectedAccrual: dbDiscrepancy.ExpectedAccrual,
+		AdjustedAt:      dbDiscrepancy.AdjustedAt.Time,
+		Adjustment:      dbDiscrepancy.Adjustment.Int32,
+		Reason:          dbDiscrepancy.Reason.String,
+	}
+}
+
+func sessionFromDB(dbSession *Session) *model.Session {
+	return &model.Session{
+		ID:        dbSession.ID.Bytes,
+		FamilyID:  dbSession.FamilyID.Bytes,
+		UserID:    dbSession.UserID.Bytes,
+		TokenHash: dbSession.TokenHash,
+		UserAgent: dbSession.UserAgent,
+		StartedAt: dbSession.StartedAt.Time,
+		CreatedAt: dbSession.CreatedAt.Time,
+		ExpiresAt: dbSession.ExpiresAt.Time,
+		RotatedAt: dbSession.RotatedAt.Time,
+		RevokedAt: dbSession.RevokedAt.Time,
+	}
+}
+
+func signingKeyFromDB(dbKey *SigningKey) *model.SigningKey {
+	return &model.SigningKey{
+		ID:         dbKey.ID.Bytes,
+		Algorithm:  dbKey.Algorithm,
+		PrivateKey: dbKey.PrivateKey,
+		CreatedAt:  dbKey.CreatedAt.Time,
+		ActiveAt:   dbKey.ActiveAt.Time,
+		RetireAt:   dbKey.RetireAt.Time,
+		ExpiresAt:  dbKey.ExpiresAt.Time,
+	}
+}
+
+func userFromDB(dbUser *User) *model.User {
+	return &model.User{
+		ID:        dbUser.ID.Bytes,
+		Login:     dbUser.Login,
+		Password:  dbUser.Password,
+		CreatedAt: dbUser.CreatedAt.Time,
+		Balance:   dbUser.Balance.Int32,
+		LoginKey:  dbUser.LoginKey.String,
+	}
+}
+
+// balanceError reports violation of nonnegative balance as application error.
+func balanceError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "users_balance_nonnegative" {
+		return application.ErrNotEnoughBonuses
+	}
+
+	return err
+}
+
+func orderFromDB(dbOrder *Order) *model.Order {
+	return &model.Order{
+		ID:            dbOrder.ID.Bytes,
+		UserID:        dbOrder.UserID.Bytes,
+		CreatedAt:     dbOrder.CreatedAt.Time,
+		Number:        dbOrder.Num,
+		Accrual:       dbOrder.Accrual.Int32,
+		Status:        model.OrderStatus(dbOrder.Status),
+		Attempts:      dbOrder.Attempts,
+		NextAttemptAt: dbOrder.NextAttemptAt.Time,
+		LastError:     dbOrder.LastError.String,
+		// checks of dead lettered orders are stopped until an administrator retries them
+		DeadLettered: !dbOrder.NextAttemptAt.Valid &&
+			(dbOrder.Status == OrderStatusNEW || dbOrder.Status == OrderStatusPROCESSING),
+
+		AccrualProvider: dbOrder.AccrualProvider.String,
+	}
+}
+
+// inTx runs fn with queries bound to a transaction, which is committed if fn succeeds.
+func (s *Storage) inTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
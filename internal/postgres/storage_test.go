@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application"
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/application/service"
+	"github.com/dtroode/gophermart/internal/application/service/mocks"
+	"github.com/dtroode/gophermart/internal/application/storage"
+	"github.com/dtroode/gophermart/internal/workerpool"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage connects to the database in TEST_DATABASE_URI, which is migrated
+// and emptied for every test. Tests are skipped if it is not set.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	s, err := NewStorage(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	_, err = s.db.Exec(context.Background(), `TRUNCATE users, orders, withdrawals, order_dead_letters,
+		order_events, order_discrepancies, sessions, signing_keys CASCADE`)
+	require.NoError(t, err)
+
+	return s
+}
+
+func saveTestUser(t *testing.T, s *Storage, login string) *model.User {
+	t.Helper()
+
+	user, err := s.SaveUser(context.Background(), &model.User{Login: login, Password: "password", LoginKey: login})
+	require.NoError(t, err)
+
+	return user
+}
+
+func saveTestOrder(t *testing.T, s *Storage, userID uuid.UUID, number string) *model.Order {
+	t.Helper()
+
+	order, err := s.SaveOrder(context.Background(), model.NewOrder(userID, number))
+	require.NoError(t, err)
+
+	return order
+}
+
+func TestStorage_RetryOrderDeadLetter(t *testing.T) {
+	orderNumber := "12345678903"
+
+	tests := map[string]func(ctx context.Context, s *Storage) error{
+		"retry order": func(ctx context.Context, s *Storage) error {
+			_, err := s.RetryOrderDeadLetter(ctx, orderNumber)
+			return err
+		},
+		"retry all orders": func(ctx context.Context, s *Storage) error {
+			_, err := s.RetryAllOrderDeadLetters(ctx)
+			return err
+		},
+	}
+
+	for tn, retry := range tests {
+		t.Run(tn, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(t)
+			user := saveTestUser(t, s, "user")
+			saveTestOrder(t, s, user.ID, orderNumber)
+
+			accrual := mocks.NewAccrualAdapter(t)
+			accrual.On("GetOrder", mock.Anything, "", orderNumber).Times(3).Return(nil, application.ErrAccrualUnavailable)
+
+			pool := workerpool.NewPool(1, 0)
+			pool.Start()
+			t.Cleanup(func() { _ = pool.Shutdown(context.Background()) })
+
+			svc := service.NewService(s, nil, nil, accrual, pool,
+				service.WithMaxOrderAttempts(2),
+				service.WithOrderRetryInterval(time.Nanosecond),
+			)
+
+			// the order runs out of attempts
+			_, err := svc.PollOrders(ctx)
+			require.NoError(t, err)
+			_, err = svc.PollOrders(ctx)
+			require.Error(t, err)
+
+			deadLetters, err := s.ListOrderDeadLetters(ctx)
+			require.NoError(t, err)
+			require.Len(t, deadLetters, 1)
+
+			require.NoError(t, retry(ctx, s))
+
+			order, err := s.GetOrderByNumber(ctx, orderNumber)
+			require.NoError(t, err)
+			assert.Zero(t, order.Attempts)
+			assert.Empty(t, order.LastError)
+
+			// the retried order survives a transient failure
+			claimed, err := svc.PollOrders(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, claimed)
+
+			deadLetters, err = s.ListOrderDeadLetters(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, deadLetters)
+		})
+	}
+}
+
+func TestStorage_DeadLetterOrder(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	user := saveTestUser(t, s, "user")
+
+	pending := saveTestOrder(t, s, user.ID, "12345678903")
+	processed := saveTestOrder(t, s, user.ID, "2377225624")
+	_, err := s.SetOrderStatusAndAccrual(ctx, &storage.SetOrderStatusAndAccrual{
+		ID:      processed.ID,
+		Status:  model.OrderStatusProcessed,
+		Accrual: 500,
+	})
+	require.NoError(t, err)
+
+	_, err = s.DeadLetterOrder(ctx, &storage.DeadLetterOrder{ID: pending.ID, Error: "accrual error"})
+	require.NoError(t, err)
+	_, err = s.DeadLetterOrder(ctx, &storage.DeadLetterOrder{ID: processed.ID, Error: "accrual error"})
+	assert.ErrorIs(t, err, application.ErrNotFound)
+
+	deadLetters, err := s.ListOrderDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, pending.ID, deadLetters[0].OrderID)
+
+	order, err := s.GetOrderByNumber(ctx, processed.Number)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusProcessed, order.Status)
+}
+
+func TestStorage_ClaimOrdersForReconciliation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	user := saveTestUser(t, s, "user")
+	order := saveTestOrder(t, s, user.ID, "12345678903")
+	_, err := s.SetOrderStatusAndAccrual(ctx, &storage.SetOrderStatusAndAccrual{
+		ID:      order.ID,
+		Status:  model.OrderStatusProcessed,
+		Accrual: 500,
+	})
+	require.NoError(t, err)
+
+	accrual := mocks.NewAccrualAdapter(t)
+	accrual.On("GetOrder", mock.Anything, "", order.Number).Once().Return(nil, application.ErrAccrualUnavailable)
+	accrual.On("GetOrder", mock.Anything, "", order.Number).Once().
+		Return(&model.AccrualOrder{Number: order.Number, Status: model.AccrualOrderStatusProcessed, Accrual: 5}, nil)
+
+	pool := workerpool.NewPool(1, 0)
+	pool.Start()
+	t.Cleanup(func() { _ = pool.Shutdown(context.Background()) })
+
+	lease := 100 * time.Millisecond
+	svc := service.NewService(s, nil, nil, accrual, pool, service.WithOrderLease(lease))
+
+	// the lookup fails and the order stays leased
+	report, err := svc.ReconcileOrders(ctx)
+	require.Error(t, err)
+	assert.Zero(t, report.Checked)
+
+	report, err = svc.ReconcileOrders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.Checked)
+
+	// the order is claimed again after the lease
+	time.Sleep(lease)
+	report, err = svc.ReconcileOrders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Checked)
+
+	// the compared order is not reconciled again for the period
+	report, err = svc.ReconcileOrders(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.Checked)
+}
+
+func TestStorage_ClaimDueOrders(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	backlogUser := saveTestUser(t, s, "backlog")
+	otherUser := saveTestUser(t, s, "other")
+
+	batchSize := int32(2)
+	// the backlog fills far more than a batch, and all of it is more overdue than the order of the other user
+	now := time.Now()
+	for i := range 5 * int(batchSize) {
+		order := model.NewOrder(backlogUser.ID, fmt.Sprintf("backlog-%d", i))
+		order.NextAttemptAt = now.Add(-time.Hour + time.Duration(i)*time.Second)
+		_, err := s.SaveOrder(ctx, order)
+		require.NoError(t, err)
+	}
+	other := model.NewOrder(otherUser.ID, "other")
+	other.NextAttemptAt = now.Add(-time.Minute)
+	_, err := s.SaveOrder(ctx, other)
+	require.NoError(t, err)
+
+	orders, err := s.ClaimDueOrders(ctx, &storage.ClaimDueOrders{
+		BatchSize:  batchSize,
+		LeaseUntil: now.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	numbers := make([]string, 0, len(orders))
+	for _, o := range orders {
+		numbers = append(numbers, o.Number)
+	}
+	assert.ElementsMatch(t, []string{"backlog-0", "other"}, numbers)
+}
+
+func TestStorage_PostponeOrderCheck(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	user := saveTestUser(t, s, "user")
+	order := saveTestOrder(t, s, user.ID, "12345678903")
+
+	_, err := s.RecordOrderAttempt(ctx, &storage.RecordOrderAttempt{
+		ID:            order.ID,
+		Attempts:      1,
+		NextAttemptAt: time.Now(),
+		LastError:     "accrual error",
+	})
+	require.NoError(t, err)
+
+	// the push moves the check and keeps the failed attempt
+	nextAttemptAt := time.Now().Add(time.Hour)
+	postponed, err := s.PostponeOrderCheck(ctx, &storage.PostponeOrderCheck{ID: order.ID, NextAttemptAt: nextAttemptAt})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), postponed.Attempts)
+	assert.Equal(t, "accrual error", postponed.LastError)
+	assert.WithinDuration(t, nextAttemptAt, postponed.NextAttemptAt, time.Millisecond)
+
+	// pushes don't revive dead lettered orders
+	_, err = s.DeadLetterOrder(ctx, &storage.DeadLetterOrder{ID: order.ID, Error: "accrual error"})
+	require.NoError(t, err)
+	_, err = s.PostponeOrderCheck(ctx, &storage.PostponeOrderCheck{ID: order.ID, NextAttemptAt: nextAttemptAt})
+	assert.ErrorIs(t, err, application.ErrNotFound)
+
+	deadLettered, err := s.GetOrderByNumber(ctx, order.Number)
+	require.NoError(t, err)
+	assert.True(t, deadLettered.DeadLettered)
+}
+
+func TestStorage_RecordOrderAttempt(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	user := saveTestUser(t, s, "user")
+	order := saveTestOrder(t, s, user.ID, "12345678903")
+
+	_, err := s.RecordOrderAttempt(ctx, &storage.RecordOrderAttempt{
+		ID:            order.ID,
+		Attempts:      1,
+		NextAttemptAt: time.Now(),
+		LastError:     "failed to request order: dial tcp: connection refused",
+		PublicError:   "failed to check order in accrual system",
+	})
+	require.NoError(t, err)
+	_, err = s.RecordOrderAttempt(ctx, &storage.RecordOrderAttempt{ID: order.ID, NextAttemptAt: time.Now()})
+	require.NoError(t, err)
+
+	// every poll is seen in the timeline, successful ones too
+	events, err := s.GetOrderEvents(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, model.OrderEventUploaded, events[0].Type)
+	assert.Equal(t, model.OrderEventChecked, events[1].Type)
+	assert.Equal(t, "failed to check order in accrual system", events[1].PublicError)
+	assert.Equal(t, model.OrderEventChecked, events[2].Type)
+	assert.Empty(t, events[2].Error)
+}
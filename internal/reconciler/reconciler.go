@@ -0,0 +1,67 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/periodic"
+)
+
+type OrderReconciler interface {
+	ReconcileOrders(ctx context.Context) (*model.ReconciliationReport, error)
+}
+
+// Reconciler periodically asks the service to compare finalized orders with accrual system
+// and logs discrepancies found.
+type Reconciler struct {
+	service  OrderReconciler
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+func New(service OrderReconciler, interval time.Duration, l *logger.Logger) *Reconciler {
+	return &Reconciler{
+		service:  service,
+		interval: interval,
+		logger:   l,
+	}
+}
+
+// Run reconciles orders until ctx is done. While there are orders left
+// it reconciles again without waiting for the next tick.
+func (r *Reconciler) Run(ctx context.Context) {
+	periodic.New(r.interval, func(ctx context.Context) bool {
+		return r.reconcile(ctx) > 0
+	}).Run(ctx)
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) int {
+	report, err := r.service.ReconcileOrders(ctx)
+	if err != nil {
+		r.logger.Error("failed to reconcile orders", "error", err)
+	}
+	if report == nil {
+		return 0
+	}
+
+	for _, d := range report.Discrepancies {
+		r.logger.Warn("order doesn't match accrual system",
+			"order", d.OrderNumber,
+			"status", d.Status,
+			"accrual", d.Accrual,
+			"expected_status", d.ExpectedStatus,
+			"expected_accrual", d.ExpectedAccrual,
+			"adjusted", d.IsAdjusted(),
+		)
+	}
+	if report.Checked > 0 {
+		r.logger.Info("reconciled orders",
+			"checked", report.Checked,
+			"discrepancies", len(report.Discrepancies),
+		)
+	}
+
+	return report.Checked
+}
@@ -0,0 +1,54 @@
+package reconciler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/application/model"
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/reconciler"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderReconcilerFunc func(ctx context.Context) (*model.ReconciliationReport, error)
+
+func (f orderReconcilerFunc) ReconcileOrders(ctx context.Context) (*model.ReconciliationReport, error) {
+	return f(ctx)
+}
+
+func TestReconciler_Run(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	// the service stops the run once no orders are left, the timeout only keeps a broken run from hanging
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var calls int
+	checked := []int{100, 100, 3}
+	s := orderReconcilerFunc(func(ctx context.Context) (*model.ReconciliationReport, error) {
+		calls++
+		if calls == 2 {
+			// failed orders don't stop the run
+			return &model.ReconciliationReport{Checked: checked[calls-1]}, errors.New("accrual error")
+		}
+		if calls <= len(checked) {
+			return &model.ReconciliationReport{
+				Checked:       checked[calls-1],
+				Discrepancies: []*model.OrderDiscrepancy{{OrderNumber: "1234"}},
+			}, nil
+		}
+		cancel()
+		return &model.ReconciliationReport{}, nil
+	})
+
+	reconciler.New(s, time.Hour, dummyLogger).Run(ctx)
+
+	// batches are reconciled one after another until no orders are left
+	assert.Equal(t, len(checked)+1, calls)
+}
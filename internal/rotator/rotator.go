@@ -0,0 +1,40 @@
+package rotator
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/periodic"
+)
+
+type SigningKeyRotator interface {
+	RotateSigningKeys(ctx context.Context) error
+}
+
+// Rotator periodically asks the service to rotate keys access tokens are signed with
+// and to load keys rotated by other instances.
+type Rotator struct {
+	service  SigningKeyRotator
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+func New(service SigningKeyRotator, interval time.Duration, l *logger.Logger) *Rotator {
+	return &Rotator{
+		service:  service,
+		interval: interval,
+		logger:   l,
+	}
+}
+
+// Run rotates keys on every tick until ctx is done. Keys are expected
+// to be rotated once before Run, so that tokens can be signed right away.
+func (r *Rotator) Run(ctx context.Context) {
+	periodic.New(r.interval, func(ctx context.Context) bool {
+		if err := r.service.RotateSigningKeys(ctx); err != nil {
+			r.logger.Error("failed to rotate signing keys", "error", err)
+		}
+		return false
+	}, periodic.WithFirstRoundOnTick()).Run(ctx)
+}
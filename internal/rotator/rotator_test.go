@@ -0,0 +1,45 @@
+package rotator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/rotator"
+	"github.com/stretchr/testify/assert"
+)
+
+type signingKeyRotatorFunc func(ctx context.Context) error
+
+func (f signingKeyRotatorFunc) RotateSigningKeys(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestRotator_Run(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	// the service stops the run, the timeout only keeps a broken run from hanging
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var calls int
+	s := signingKeyRotatorFunc(func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			// failed rotation doesn't stop the run
+			return errors.New("storage error")
+		}
+		cancel()
+		return nil
+	})
+
+	rotator.New(s, time.Millisecond, dummyLogger).Run(ctx)
+
+	assert.Equal(t, 2, calls)
+}
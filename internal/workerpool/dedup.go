@@ -0,0 +1,72 @@
+package workerpool
+
+import "context"
+
+type idempotencyCtxKey struct{}
+
+// WithIdempotencyKey marks jobs submitted with the context as doing the same work.
+// While a job with the key is scheduled, queued or running, another submission
+// with the key joins it and gets its result instead of running once more.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyCtxKey{}, key)
+}
+
+// GetIdempotencyKeyFromContext returns idempotency key set by WithIdempotencyKey.
+func GetIdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyCtxKey{}).(string)
+	return key, ok
+}
+
+// flight is a job with idempotency key that is not finished yet.
+type flight struct {
+	waiters []func(*Result)
+}
+
+// join registers the job under its idempotency key. If a job with the key
+// is already pending, it reports true and the job gets result of that job instead.
+func (p *Pool) join(job *Job) bool {
+	if job.idempotencyKey == "" {
+		return false
+	}
+
+	p.flightsMu.Lock()
+	defer p.flightsMu.Unlock()
+
+	f, ok := p.flights[job.idempotencyKey]
+	if !ok {
+		p.flights[job.idempotencyKey] = &flight{}
+		return false
+	}
+
+	p.joined.Add(1)
+	if job.done != nil {
+		f.waiters = append(f.waiters, job.done)
+	}
+
+	return true
+}
+
+// finish reports result of the job to its submitter and to the ones that joined it.
+func (p *Pool) finish(job *Job, res *Result) {
+	if job.done != nil {
+		job.done(res)
+	}
+
+	if job.idempotencyKey == "" {
+		return
+	}
+
+	p.flightsMu.Lock()
+	f := p.flights[job.idempotencyKey]
+	delete(p.flights, job.idempotencyKey)
+	p.flightsMu.Unlock()
+
+	for _, done := range f.waiters {
+		done(res)
+	}
+}
+
+// reject reports err as result of the job that won't run.
+func (p *Pool) reject(job *Job, err error) {
+	p.finish(job, &Result{Err: err})
+}
@@ -0,0 +1,123 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Submitter runs jobs reporting results to callbacks, Pool implements it.
+type Submitter interface {
+	SubmitFunc(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (any, error), done func(*Result))
+	TrySubmitFunc(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (any, error), done func(*Result)) error
+}
+
+// Future is result of a job known once the job is done.
+type Future[T any] struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	value     T
+	err       error
+	callbacks []func(T, error)
+}
+
+// Submit queues fn, waiting for room in the queue if it is full, and returns future of its result.
+func Submit[T any](s Submitter, ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) *Future[T] {
+	ctx, f := newFuture[T](ctx)
+	s.SubmitFunc(ctx, timeout, untyped(fn), f.complete)
+
+	return f
+}
+
+// TrySubmit queues fn only if there is room in the queue right away and returns future of its result.
+func TrySubmit[T any](s Submitter, ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (*Future[T], error) {
+	ctx, f := newFuture[T](ctx)
+	if err := s.TrySubmitFunc(ctx, timeout, untyped(fn), f.complete); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func newFuture[T any](ctx context.Context) (context.Context, *Future[T]) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return ctx, &Future[T]{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+func untyped[T any](fn func(ctx context.Context) (T, error)) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	}
+}
+
+// Await waits for the job and returns its result, or ctx error if ctx is done first.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns channel closed when the job is done.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel cancels context of the job. The job that hasn't started yet
+// still runs, but with cancelled context. A future that joined a job
+// with the same idempotency key doesn't cancel that job.
+func (f *Future[T]) Cancel() {
+	f.cancel()
+}
+
+// OnComplete registers fn called with result of the job. If the job is already done,
+// fn is called right away, otherwise it is called by the goroutine finishing the job
+// and must not block.
+func (f *Future[T]) OnComplete(fn func(T, error)) {
+	f.mu.Lock()
+	select {
+	case <-f.done:
+		f.mu.Unlock()
+		fn(f.value, f.err)
+		return
+	default:
+	}
+	f.callbacks = append(f.callbacks, fn)
+	f.mu.Unlock()
+}
+
+func (f *Future[T]) complete(res *Result) {
+	var value T
+	err := res.Err
+	if err == nil && res.Value != nil {
+		v, ok := res.Value.(T)
+		if !ok {
+			// a job of another type was submitted with the same idempotency key
+			err = fmt.Errorf("unexpected result type %T", res.Value)
+		}
+		value = v
+	}
+
+	f.mu.Lock()
+	f.value, f.err = value, err
+	close(f.done)
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.mu.Unlock()
+
+	f.cancel()
+
+	for _, fn := range callbacks {
+		fn(value, err)
+	}
+}
@@ -0,0 +1,133 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/workerpool"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	Number string
+}
+
+func TestSubmit(t *testing.T) {
+	tests := map[string]struct {
+		fn            func(ctx context.Context) (*order, error)
+		expectedValue *order
+		expectedErr   error
+	}{
+		"value": {
+			fn: func(ctx context.Context) (*order, error) {
+				return &order{Number: "1234"}, nil
+			},
+			expectedValue: &order{Number: "1234"},
+		},
+		"error": {
+			fn: func(ctx context.Context) (*order, error) {
+				return nil, errors.New("accrual error")
+			},
+			expectedErr: errors.New("accrual error"),
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			pool := workerpool.NewPool(1, 1)
+			pool.Start()
+			defer pool.Shutdown(context.Background())
+
+			future := workerpool.Submit(pool, context.Background(), time.Minute, tt.fn)
+
+			completed := make(chan *order, 1)
+			future.OnComplete(func(o *order, err error) {
+				completed <- o
+			})
+
+			value, err := future.Await(context.Background())
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedValue, value)
+			assert.Equal(t, tt.expectedValue, <-completed)
+
+			// callbacks registered after completion are called right away
+			future.OnComplete(func(o *order, err error) {
+				assert.Equal(t, tt.expectedValue, o)
+			})
+		})
+	}
+}
+
+func TestFuture_Cancel(t *testing.T) {
+	pool := workerpool.NewPool(1, 1)
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+
+	future := workerpool.Submit(pool, context.Background(), time.Minute, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+
+	// awaiting with a short context doesn't cancel the job
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := future.Await(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	future.Cancel()
+
+	_, err = future.Await(context.Background())
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestTrySubmit(t *testing.T) {
+	pool := workerpool.NewPool(1, 1)
+
+	job := func(ctx context.Context) (string, error) {
+		return "done", nil
+	}
+
+	queued, err := workerpool.TrySubmit(pool, context.Background(), time.Minute, job)
+	assert.NoError(t, err)
+
+	_, err = workerpool.TrySubmit(pool, context.Background(), time.Minute, job)
+	assert.Equal(t, workerpool.ErrQueueFull, err)
+
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+
+	value, err := queued.Await(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+}
+
+func TestSubmit_JoinedOtherType(t *testing.T) {
+	pool := workerpool.NewPool(1, 1)
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+
+	ctx := workerpool.WithIdempotencyKey(context.Background(), "job")
+	release := make(chan struct{})
+
+	first := workerpool.Submit(pool, ctx, time.Minute, func(ctx context.Context) (string, error) {
+		<-release
+		return "done", nil
+	})
+	joined := workerpool.Submit(pool, ctx, time.Minute, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	close(release)
+
+	value, err := first.Await(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+
+	// the joined future gets an error instead of a value of a wrong type
+	_, err = joined.Await(context.Background())
+	assert.EqualError(t, err, "unexpected result type string")
+}
@@ -0,0 +1,214 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+type keyCtxKey struct{}
+
+// WithKey marks jobs submitted with the context as belonging to key, e.g. a user.
+// Jobs of different keys are run in turns, so a burst of one key doesn't delay the rest.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtxKey{}, key)
+}
+
+// GetKeyFromContext returns key set by WithKey.
+func GetKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyCtxKey{}).(string)
+	return key, ok
+}
+
+type keyQueue struct {
+	key     string
+	jobs    []*Job
+	running int
+}
+
+// queue holds jobs per key and hands them out round-robin across keys.
+// A key with limit jobs running is skipped until one of them is done.
+// A key with keyCapacity jobs queued gets no more, so one key can't fill the whole queue.
+type queue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+
+	capacity    int
+	keyCapacity int
+	limit       int
+	len         int
+	closed      bool
+
+	keys map[string]*keyQueue
+	// ring holds keys with queued jobs, next is the key to take a job from first.
+	ring []*keyQueue
+	next int
+}
+
+func newQueue(capacity, keyCapacity, limit int) *queue {
+	q := &queue{
+		capacity:    capacity,
+		keyCapacity: keyCapacity,
+		limit:       limit,
+		keys:        map[string]*keyQueue{},
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+
+	return q
+}
+
+// push adds the job, waiting for room in the queue and for its key if wait is set.
+func (q *queue) push(job *Job, wait bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed {
+		if q.len >= q.capacity {
+			if !wait {
+				return ErrQueueFull
+			}
+		} else if q.keyFull(job.key) {
+			if !wait {
+				return ErrKeyQueueFull
+			}
+		} else {
+			break
+		}
+		q.notFull.Wait()
+	}
+	if q.closed {
+		return ErrStopped
+	}
+
+	kq, ok := q.keys[job.key]
+	if !ok {
+		kq = &keyQueue{key: job.key}
+		q.keys[job.key] = kq
+	}
+	if len(kq.jobs) == 0 {
+		q.ring = append(q.ring, kq)
+	}
+	kq.jobs = append(kq.jobs, job)
+	q.len++
+
+	q.notEmpty.Signal()
+
+	return nil
+}
+
+func (q *queue) keyFull(key string) bool {
+	if q.keyCapacity <= 0 {
+		return false
+	}
+	kq, ok := q.keys[key]
+
+	return ok && len(kq.jobs) >= q.keyCapacity
+}
+
+// pop waits for a job the limit allows to run. It returns nil when the queue
+// is closed and drained, or when interrupt reports the caller should stop waiting.
+// interrupt is checked under the queue lock every time the caller wakes up.
+func (q *queue) pop(interrupt func() bool) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if interrupt() {
+			if q.len > 0 {
+				// pass the wake up on to another worker
+				q.notEmpty.Signal()
+			}
+			return nil
+		}
+		if job := q.take(); job != nil {
+			return job
+		}
+		if q.closed && q.len == 0 {
+			return nil
+		}
+		q.notEmpty.Wait()
+	}
+}
+
+// take removes the first job of the next key in turn that is under the limit.
+func (q *queue) take() *Job {
+	for i := range len(q.ring) {
+		pos := (q.next + i) % len(q.ring)
+		kq := q.ring[pos]
+		if q.limit > 0 && kq.running >= q.limit {
+			continue
+		}
+
+		job := kq.jobs[0]
+		kq.jobs[0] = nil
+		kq.jobs = kq.jobs[1:]
+		kq.running++
+		q.len--
+
+		if len(kq.jobs) == 0 {
+			q.ring = append(q.ring[:pos], q.ring[pos+1:]...)
+			q.next = pos
+		} else {
+			q.next = pos + 1
+		}
+		if len(q.ring) > 0 {
+			q.next %= len(q.ring)
+		} else {
+			q.next = 0
+		}
+
+		if q.keyCapacity > 0 {
+			// the room may be waited for only by pushes of this key
+			q.notFull.Broadcast()
+		} else {
+			q.notFull.Signal()
+		}
+
+		return job
+	}
+
+	return nil
+}
+
+// done releases the place of the finished job in the limit of its key.
+func (q *queue) done(job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kq := q.keys[job.key]
+	kq.running--
+	if kq.running == 0 && len(kq.jobs) == 0 {
+		delete(q.keys, job.key)
+	}
+
+	if q.limit > 0 {
+		// jobs of the key may be waiting for this one
+		q.notEmpty.Broadcast()
+	}
+}
+
+// close makes push fail and pop return nil once queued jobs are taken.
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+// wake makes waiting pop calls check their interrupt.
+func (q *queue) wake() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.notEmpty.Broadcast()
+}
+
+func (q *queue) size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.len
+}
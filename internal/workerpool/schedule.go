@@ -0,0 +1,122 @@
+package workerpool
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+type scheduledJob struct {
+	at  time.Time
+	job *Job
+}
+
+// schedule is a min-heap of jobs ordered by time they are due.
+type schedule []*scheduledJob
+
+func (s schedule) Len() int           { return len(s) }
+func (s schedule) Less(i, j int) bool { return s[i].at.Before(s[j].at) }
+func (s schedule) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s *schedule) Push(x any) {
+	*s = append(*s, x.(*scheduledJob))
+}
+
+func (s *schedule) Pop() any {
+	old := *s
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*s = old[:n-1]
+	return item
+}
+
+// SubmitAt queues the job when at comes. Until then the job occupies
+// neither a worker nor a place in the queue.
+func (p *Pool) SubmitAt(
+	ctx context.Context,
+	at time.Time,
+	timeout time.Duration,
+	fn func(ctx context.Context) (any, error),
+	expectResult bool,
+) chan *Result {
+	resCh, done := resultChan(expectResult)
+	job := newJob(ctx, timeout, fn, done)
+	if p.join(job) {
+		return resCh
+	}
+
+	p.schedMu.Lock()
+	if p.isStopped() {
+		p.schedMu.Unlock()
+		p.reject(job, ErrStopped)
+		return resCh
+	}
+	heap.Push(&p.scheduled, &scheduledJob{at: at, job: job})
+	p.schedMu.Unlock()
+
+	// wake up scheduler in case the job is due earlier than the ones it waits for
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+
+	return resCh
+}
+
+// scheduler moves due jobs to the queue until the pool is shut down.
+func (p *Pool) scheduler() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		due, next := p.popDue(time.Now())
+		for _, job := range due {
+			p.enqueue(job)
+		}
+
+		if next.IsZero() {
+			timer.Stop()
+		} else {
+			timer.Reset(time.Until(next))
+		}
+
+		select {
+		case <-timer.C:
+		case <-p.wake:
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// popDue removes jobs due by now from the schedule.
+// It returns them with time the next job is due, zero if the schedule is empty.
+func (p *Pool) popDue(now time.Time) ([]*Job, time.Time) {
+	p.schedMu.Lock()
+	defer p.schedMu.Unlock()
+
+	var due []*Job
+	for p.scheduled.Len() > 0 {
+		next := p.scheduled[0]
+		if next.at.After(now) {
+			return due, next.at
+		}
+		heap.Pop(&p.scheduled)
+		due = append(due, next.job)
+	}
+
+	return due, time.Time{}
+}
+
+// dropScheduled rejects jobs that didn't come due before shutdown.
+func (p *Pool) dropScheduled() {
+	p.schedMu.Lock()
+	scheduled := p.scheduled
+	p.scheduled = nil
+	p.schedMu.Unlock()
+
+	for _, s := range scheduled {
+		p.reject(s.job, ErrStopped)
+	}
+}
@@ -2,85 +2,458 @@ package workerpool
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
 )
 
+// ErrStopped is returned for jobs submitted after the pool was shut down.
+var ErrStopped = errors.New("worker pool is stopped")
+
+// ErrQueueFull is returned by TrySubmit when there is no room for the job in the queue.
+var ErrQueueFull = errors.New("worker pool queue is full")
+
+// ErrKeyQueueFull is returned by TrySubmit when the key of the job has too many jobs queued, see WithKeyQueueLimit.
+// It is ErrQueueFull too.
+var ErrKeyQueueFull = fmt.Errorf("%w for the key", ErrQueueFull)
+
+// ErrInvalidSize is returned by Resize for size less than one.
+var ErrInvalidSize = errors.New("worker pool size must be positive")
+
+// PanicError is returned as result of a job that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panicked: %v", e.Value)
+}
+
 type Result struct {
 	Err   error
 	Value any
 }
 
 type Job struct {
-	ctx     context.Context
-	timeout time.Duration
-	fn      func(ctx context.Context) (any, error)
-	resCh   chan *Result
+	key            string
+	idempotencyKey string
+	ctx            context.Context
+	timeout        time.Duration
+	fn             func(ctx context.Context) (any, error)
+	done           func(*Result)
 }
 
 type Pool struct {
-	jobs  chan *Job
-	limit int
+	queue *queue
+
+	// limit is the wanted number of workers and size is the number of running ones.
+	// When limit goes down, surplus workers exit after their current job.
+	sizeMu  sync.Mutex
+	limit   int
+	size    int
+	started bool
+
+	// ctx is cancelled when shutdown deadline passes, running jobs see it as their context cancellation.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	stopped bool
+
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	rejected  atomic.Int64
+	joined    atomic.Int64
+	runTime   atomic.Int64
+
+	schedMu   sync.Mutex
+	scheduled schedule
+	wake      chan struct{}
+	quit      chan struct{}
+
+	flightsMu sync.Mutex
+	flights   map[string]*flight
+
+	keyLimit      int
+	keyQueueLimit int
+	logger        *logger.Logger
+}
+
+type Option func(*Pool)
+
+// WithKeyLimit limits the number of running jobs of one key, see WithKey.
+// Zero means no limit, keys still take turns.
+func WithKeyLimit(limit int) Option {
+	return func(p *Pool) {
+		p.keyLimit = limit
+	}
 }
 
-func NewPool(limit, queueSize int) *Pool {
+// WithKeyQueueLimit limits the number of queued jobs of one key, see WithKey.
+// Jobs of the key over the limit are rejected while other keys are still queued.
+// Zero means no limit besides the queue size.
+func WithKeyQueueLimit(limit int) Option {
+	return func(p *Pool) {
+		p.keyQueueLimit = limit
+	}
+}
+
+// WithLogger sets logger reporting panics of jobs.
+func WithLogger(l *logger.Logger) Option {
+	return func(p *Pool) {
+		p.logger = l
+	}
+}
+
+// Stats describes load of the pool.
+type Stats struct {
+	Workers   int
+	Active    int64
+	Queued    int
+	QueueSize int
+	Scheduled int
+	Completed int64
+	Failed    int64
+	Rejected  int64
+	Joined    int64
+	// AvgRunTime is average run time of finished jobs.
+	AvgRunTime time.Duration
+}
+
+func NewPool(limit, queueSize int, opts ...Option) *Pool {
 	if queueSize == 0 {
 		queueSize = limit * 5
 	}
 
-	return &Pool{
-		jobs:  make(chan *Job, queueSize),
-		limit: limit,
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		limit:   limit,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		flights: map[string]*flight{},
+		logger:  &logger.Logger{Logger: slog.Default()},
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
+
+	p.queue = newQueue(queueSize, p.keyQueueLimit, p.keyLimit)
+
+	return p
 }
 
 func (p *Pool) Start() {
-	for range p.limit {
+	p.sizeMu.Lock()
+	p.started = true
+	p.spawn(p.limit)
+	p.sizeMu.Unlock()
+
+	go p.scheduler()
+}
+
+// Resize changes the number of workers. New workers start right away,
+// surplus workers finish their current jobs first, so no job is dropped.
+func (p *Pool) Resize(size int) error {
+	if size < 1 {
+		return ErrInvalidSize
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return ErrStopped
+	}
+
+	p.sizeMu.Lock()
+	p.limit = size
+	shrink := p.started && p.size > size
+	if p.started && p.size < size {
+		p.spawn(size - p.size)
+	}
+	p.sizeMu.Unlock()
+
+	if shrink {
+		// wake up idle workers, so surplus ones notice they should exit
+		p.queue.wake()
+	}
+
+	return nil
+}
+
+// spawn starts n workers, sizeMu must be held.
+func (p *Pool) spawn(n int) {
+	p.wg.Add(n)
+	p.size += n
+	for range n {
 		go p.worker()
 	}
 }
 
-func (p *Pool) Stop() {
-	close(p.jobs)
+// retire reports whether the worker should exit because the pool was shrunk.
+func (p *Pool) retire() bool {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+
+	if p.size > p.limit {
+		p.size--
+		return true
+	}
+
+	return false
 }
 
+// Shutdown stops accepting jobs and waits until queued and running jobs are done.
+// Scheduled jobs that are not due yet get ErrStopped.
+// When ctx is done first, contexts of the remaining jobs are cancelled so they can
+// save their progress, and Shutdown waits for them to return.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.quit)
+		p.queue.close()
+	}
+	p.mu.Unlock()
+
+	p.dropScheduled()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Submit queues the job, waiting for room in the queue if it is full.
 func (p *Pool) Submit(
 	ctx context.Context,
 	timeout time.Duration,
 	fn func(ctx context.Context) (any, error),
 	expectResult bool,
 ) chan *Result {
-	var resCh chan *Result
-	if expectResult {
-		resCh = make(chan *Result, 1)
+	resCh, done := resultChan(expectResult)
+	p.SubmitFunc(ctx, timeout, fn, done)
+
+	return resCh
+}
+
+// SubmitFunc queues the job like Submit and passes its result to done, if it's set.
+// done is called by the goroutine finishing the job and must not block.
+func (p *Pool) SubmitFunc(
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(ctx context.Context) (any, error),
+	done func(*Result),
+) {
+	job := newJob(ctx, timeout, fn, done)
+	if p.join(job) {
+		return
 	}
 
-	job := &Job{
-		ctx:     ctx,
-		timeout: timeout,
-		fn:      fn,
-		resCh:   resCh,
+	p.enqueue(job)
+}
+
+func (p *Pool) enqueue(job *Job) {
+	if err := p.queue.push(job, true); err != nil {
+		p.reject(job, err)
 	}
+}
 
-	p.jobs <- job
-	return resCh
+func (p *Pool) isStopped() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.stopped
 }
 
-func (p *Pool) worker() {
-	for job := range p.jobs {
-		func() {
-			ctx, cancel := context.WithTimeout(job.ctx, job.timeout)
-			defer cancel()
+// TrySubmit queues the job only if there is room in the queue right away,
+// otherwise it returns ErrQueueFull, or ErrStopped after shutdown.
+func (p *Pool) TrySubmit(
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(ctx context.Context) (any, error),
+	expectResult bool,
+) (chan *Result, error) {
+	resCh, done := resultChan(expectResult)
+	if err := p.TrySubmitFunc(ctx, timeout, fn, done); err != nil {
+		return nil, err
+	}
+
+	return resCh, nil
+}
 
-			res, err := job.fn(ctx)
+// TrySubmitFunc queues the job like TrySubmit and passes its result to done, see SubmitFunc.
+// If the job is not queued, done gets the returned error.
+func (p *Pool) TrySubmitFunc(
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(ctx context.Context) (any, error),
+	done func(*Result),
+) error {
+	job := newJob(ctx, timeout, fn, done)
+	if p.join(job) {
+		return nil
+	}
 
-			if job.resCh != nil {
-				r := &Result{
-					Value: res,
-					Err:   err,
-				}
+	if err := p.queue.push(job, false); err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			p.rejected.Add(1)
+		}
+		// the ones that joined the job get the error too
+		p.reject(job, err)
+		return err
+	}
+
+	return nil
+}
+
+// Stats returns current load of the pool.
+func (p *Pool) Stats() Stats {
+	p.schedMu.Lock()
+	scheduled := p.scheduled.Len()
+	p.schedMu.Unlock()
+
+	p.sizeMu.Lock()
+	workers := p.size
+	p.sizeMu.Unlock()
+
+	stats := Stats{
+		Workers:   workers,
+		Active:    p.active.Load(),
+		Queued:    p.queue.size(),
+		QueueSize: p.queue.capacity,
+		Scheduled: scheduled,
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Rejected:  p.rejected.Load(),
+		Joined:    p.joined.Load(),
+	}
+	if finished := stats.Completed + stats.Failed; finished > 0 {
+		stats.AvgRunTime = time.Duration(p.runTime.Load() / finished)
+	}
 
-				job.resCh <- r
+	return stats
+}
+
+// resultChan returns channel receiving result of a job and function sending it there,
+// both are nil if result is not expected.
+func resultChan(expectResult bool) (chan *Result, func(*Result)) {
+	if !expectResult {
+		return nil, nil
+	}
+
+	resCh := make(chan *Result, 1)
+	return resCh, func(r *Result) {
+		resCh <- r
+	}
+}
+
+func newJob(
+	ctx context.Context,
+	timeout time.Duration,
+	fn func(ctx context.Context) (any, error),
+	done func(*Result),
+) *Job {
+	key, _ := GetKeyFromContext(ctx)
+	idempotencyKey, _ := GetIdempotencyKeyFromContext(ctx)
+
+	return &Job{
+		key:            key,
+		idempotencyKey: idempotencyKey,
+		ctx:            ctx,
+		timeout:        timeout,
+		fn:             fn,
+		done:           done,
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+
+	for {
+		retired := false
+		job := p.queue.pop(func() bool {
+			retired = p.retire()
+			return retired
+		})
+		if job == nil {
+			if !retired {
+				// the pool is shut down and the queue is drained
+				p.sizeMu.Lock()
+				p.size--
+				p.sizeMu.Unlock()
 			}
-		}()
+			return
+		}
+
+		p.run(job)
+		p.queue.done(job)
 	}
 }
+
+func (p *Pool) run(job *Job) {
+	ctx, cancel := context.WithTimeout(job.ctx, job.timeout)
+	defer cancel()
+
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	p.active.Add(1)
+	started := time.Now()
+
+	res, err := p.call(ctx, job.fn)
+
+	p.runTime.Add(int64(time.Since(started)))
+	p.active.Add(-1)
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.completed.Add(1)
+	}
+
+	p.finish(job, &Result{
+		Value: res,
+		Err:   err,
+	})
+}
+
+// call runs job function. A panic is recovered and returned as PanicError,
+// so the worker goes on with the next job and the pool doesn't lose capacity.
+func (p *Pool) call(ctx context.Context, fn func(ctx context.Context) (any, error)) (res any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
+			p.logger.Error("worker pool job panicked", "panic", panicErr.Value, "stack", string(panicErr.Stack))
+
+			res, err = nil, panicErr
+		}
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,336 @@
+package workerpool_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dtroode/gophermart/internal/logger"
+	"github.com/dtroode/gophermart/internal/workerpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_Shutdown(t *testing.T) {
+	tests := map[string]struct {
+		job         func(ctx context.Context) (any, error)
+		deadline    time.Duration
+		expectedRes *workerpool.Result
+		expectedErr error
+	}{
+		"running job finishes": {
+			job: func(ctx context.Context) (any, error) {
+				time.Sleep(10 * time.Millisecond)
+				return "done", nil
+			},
+			deadline:    time.Second,
+			expectedRes: &workerpool.Result{Value: "done"},
+		},
+		"running job is cancelled after deadline": {
+			job: func(ctx context.Context) (any, error) {
+				<-ctx.Done()
+				return "released", ctx.Err()
+			},
+			deadline:    10 * time.Millisecond,
+			expectedRes: &workerpool.Result{Value: "released", Err: context.Canceled},
+			expectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for tn, tt := range tests {
+		t.Run(tn, func(t *testing.T) {
+			t.Parallel()
+
+			pool := workerpool.NewPool(1, 1)
+			pool.Start()
+
+			resCh := pool.Submit(context.Background(), time.Minute, tt.job, true)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+
+			err := pool.Shutdown(ctx)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedRes, <-resCh)
+		})
+	}
+}
+
+func TestPool_SubmitAfterShutdown(t *testing.T) {
+	pool := workerpool.NewPool(1, 1)
+	pool.Start()
+
+	err := pool.Shutdown(context.Background())
+	assert.NoError(t, err)
+
+	res := <-pool.Submit(context.Background(), time.Minute, func(ctx context.Context) (any, error) {
+		return nil, nil
+	}, true)
+
+	assert.Equal(t, &workerpool.Result{Err: workerpool.ErrStopped}, res)
+	assert.Nil(t, pool.Submit(context.Background(), time.Minute, func(ctx context.Context) (any, error) {
+		return nil, nil
+	}, false))
+}
+
+func TestPool_TrySubmit(t *testing.T) {
+	pool := workerpool.NewPool(1, 1)
+
+	job := func(ctx context.Context) (any, error) {
+		return nil, nil
+	}
+
+	// workers are not started, so the first job occupies the whole queue
+	_, err := pool.TrySubmit(context.Background(), time.Minute, job, false)
+	assert.NoError(t, err)
+
+	_, err = pool.TrySubmit(context.Background(), time.Minute, job, false)
+	assert.Equal(t, workerpool.ErrQueueFull, err)
+
+	assert.Equal(t, workerpool.Stats{Queued: 1, QueueSize: 1, Rejected: 1}, pool.Stats())
+
+	pool.Start()
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	_, err = pool.TrySubmit(context.Background(), time.Minute, job, false)
+	assert.Equal(t, workerpool.ErrStopped, err)
+}
+
+func TestPool_Panic(t *testing.T) {
+	dummyLogger := &logger.Logger{
+		Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	pool := workerpool.NewPool(1, 2, workerpool.WithLogger(dummyLogger))
+	pool.Start()
+
+	panicked := <-pool.Submit(context.Background(), time.Minute, func(ctx context.Context) (any, error) {
+		panic("bad payload")
+	}, true)
+
+	var panicErr *workerpool.PanicError
+	if assert.ErrorAs(t, panicked.Err, &panicErr) {
+		assert.Equal(t, "bad payload", panicErr.Value)
+		assert.NotEmpty(t, panicErr.Stack)
+	}
+	assert.Nil(t, panicked.Value)
+
+	// the only worker survives the panic and runs the next job
+	res := <-pool.Submit(context.Background(), time.Minute, func(ctx context.Context) (any, error) {
+		return "done", nil
+	}, true)
+	assert.Equal(t, &workerpool.Result{Value: "done"}, res)
+
+	assert.NoError(t, pool.Shutdown(context.Background()))
+}
+
+func TestPool_SubmitAt(t *testing.T) {
+	pool := workerpool.NewPool(1, 5)
+	pool.Start()
+
+	var order []string
+	job := func(name string) func(ctx context.Context) (any, error) {
+		return func(ctx context.Context) (any, error) {
+			order = append(order, name)
+			return name, nil
+		}
+	}
+
+	now := time.Now()
+	late := pool.SubmitAt(context.Background(), now.Add(40*time.Millisecond), time.Minute, job("late"), true)
+	early := pool.SubmitAt(context.Background(), now.Add(20*time.Millisecond), time.Minute, job("early"), true)
+	overdue := pool.SubmitAt(context.Background(), now.Add(-time.Second), time.Minute, job("overdue"), true)
+
+	assert.Equal(t, &workerpool.Result{Value: "overdue"}, <-overdue)
+	assert.Equal(t, &workerpool.Result{Value: "early"}, <-early)
+	assert.Equal(t, &workerpool.Result{Value: "late"}, <-late)
+	assert.Equal(t, []string{"overdue", "early", "late"}, order)
+	assert.GreaterOrEqual(t, time.Since(now), 40*time.Millisecond)
+
+	// jobs that are not due by shutdown don't run
+	pending := pool.SubmitAt(context.Background(), time.Now().Add(time.Hour), time.Minute, job("pending"), true)
+	assert.Equal(t, 1, pool.Stats().Scheduled)
+
+	assert.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, &workerpool.Result{Err: workerpool.ErrStopped}, <-pending)
+	assert.Equal(t, 0, pool.Stats().Scheduled)
+
+	stopped := pool.SubmitAt(context.Background(), time.Now(), time.Minute, job("stopped"), true)
+	assert.Equal(t, &workerpool.Result{Err: workerpool.ErrStopped}, <-stopped)
+	assert.Equal(t, []string{"overdue", "early", "late"}, order)
+}
+
+func TestPool_Resize(t *testing.T) {
+	pool := workerpool.NewPool(1, 10)
+	pool.Start()
+
+	release := make(chan struct{})
+	blocked := func(ctx context.Context) (any, error) {
+		<-release
+		return nil, nil
+	}
+
+	assert.Equal(t, workerpool.ErrInvalidSize, pool.Resize(0))
+	assert.NoError(t, pool.Resize(3))
+
+	results := make([]chan *workerpool.Result, 3)
+	for i := range results {
+		results[i] = pool.Submit(context.Background(), time.Minute, blocked, true)
+	}
+
+	// all three jobs run at once on the grown pool
+	assert.Eventually(t, func() bool {
+		return pool.Stats().Active == 3
+	}, time.Second, time.Millisecond)
+
+	// shrinking doesn't interrupt running jobs
+	assert.NoError(t, pool.Resize(1))
+	assert.Equal(t, int64(3), pool.Stats().Active)
+
+	close(release)
+	for _, resCh := range results {
+		assert.Equal(t, &workerpool.Result{}, <-resCh)
+	}
+
+	assert.Eventually(t, func() bool {
+		return pool.Stats().Workers == 1
+	}, time.Second, time.Millisecond)
+
+	stats := pool.Stats()
+	assert.Equal(t, int64(3), stats.Completed)
+	assert.Equal(t, int64(0), stats.Failed)
+	assert.Positive(t, stats.AvgRunTime)
+
+	assert.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, workerpool.ErrStopped, pool.Resize(2))
+	assert.Equal(t, 0, pool.Stats().Workers)
+}
+
+func TestPool_Fairness(t *testing.T) {
+	pool := workerpool.NewPool(1, 10)
+
+	var order []string
+	job := func(name string) func(ctx context.Context) (any, error) {
+		return func(ctx context.Context) (any, error) {
+			order = append(order, name)
+			return nil, nil
+		}
+	}
+
+	// a burst of one key is queued before the others
+	var results []chan *workerpool.Result
+	for _, name := range []string{"a1", "a2", "a3", "a4"} {
+		results = append(results, pool.Submit(workerpool.WithKey(context.Background(), "a"), time.Minute, job(name), true))
+	}
+	results = append(results, pool.Submit(workerpool.WithKey(context.Background(), "b"), time.Minute, job("b1"), true))
+	results = append(results, pool.Submit(workerpool.WithKey(context.Background(), "c"), time.Minute, job("c1"), true))
+
+	pool.Start()
+	for _, resCh := range results {
+		<-resCh
+	}
+
+	assert.Equal(t, []string{"a1", "b1", "c1", "a2", "a3", "a4"}, order)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+}
+
+func TestPool_KeyQueueLimit(t *testing.T) {
+	pool := workerpool.NewPool(1, 10, workerpool.WithKeyQueueLimit(2))
+
+	job := func(ctx context.Context) (any, error) {
+		return nil, nil
+	}
+	a := workerpool.WithKey(context.Background(), "a")
+
+	a1, err := pool.TrySubmit(a, time.Minute, job, true)
+	require.NoError(t, err)
+	a2, err := pool.TrySubmit(a, time.Minute, job, true)
+	require.NoError(t, err)
+
+	// only the key over the limit is rejected
+	_, err = pool.TrySubmit(a, time.Minute, job, true)
+	assert.ErrorIs(t, err, workerpool.ErrKeyQueueFull)
+	assert.ErrorIs(t, err, workerpool.ErrQueueFull)
+	b1, err := pool.TrySubmit(workerpool.WithKey(context.Background(), "b"), time.Minute, job, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pool.Stats().Rejected)
+
+	pool.Start()
+	for _, resCh := range []chan *workerpool.Result{a1, a2, b1} {
+		assert.Equal(t, &workerpool.Result{}, <-resCh)
+	}
+
+	assert.NoError(t, pool.Shutdown(context.Background()))
+}
+
+func TestPool_KeyLimit(t *testing.T) {
+	pool := workerpool.NewPool(3, 10, workerpool.WithKeyLimit(1))
+	pool.Start()
+
+	release := make(chan struct{})
+	var started atomic.Int32
+	blocked := func(ctx context.Context) (any, error) {
+		started.Add(1)
+		<-release
+		return nil, nil
+	}
+
+	a1 := pool.Submit(workerpool.WithKey(context.Background(), "a"), time.Minute, blocked, true)
+	a2 := pool.Submit(workerpool.WithKey(context.Background(), "a"), time.Minute, blocked, true)
+	b1 := pool.Submit(workerpool.WithKey(context.Background(), "b"), time.Minute, blocked, true)
+
+	// the second job of the key waits even though a worker is free
+	assert.Eventually(t, func() bool {
+		return started.Load() == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 1, pool.Stats().Queued)
+
+	close(release)
+	for _, resCh := range []chan *workerpool.Result{a1, a2, b1} {
+		assert.Equal(t, &workerpool.Result{}, <-resCh)
+	}
+	assert.Equal(t, int32(3), started.Load())
+
+	assert.NoError(t, pool.Shutdown(context.Background()))
+}
+
+func TestPool_IdempotencyKey(t *testing.T) {
+	pool := workerpool.NewPool(2, 10)
+	pool.Start()
+
+	release := make(chan struct{})
+	var runs atomic.Int32
+	job := func(ctx context.Context) (any, error) {
+		runs.Add(1)
+		<-release
+		return "checked", nil
+	}
+
+	ctx := workerpool.WithIdempotencyKey(context.Background(), "check/1234")
+
+	first := pool.Submit(ctx, time.Minute, job, true)
+	joined, err := pool.TrySubmit(ctx, time.Minute, job, true)
+	assert.NoError(t, err)
+	scheduled := pool.SubmitAt(ctx, time.Now().Add(time.Hour), time.Minute, job, true)
+	other := pool.Submit(workerpool.WithIdempotencyKey(context.Background(), "check/5678"), time.Minute, job, true)
+
+	close(release)
+
+	for _, resCh := range []chan *workerpool.Result{first, joined, scheduled, other} {
+		assert.Equal(t, &workerpool.Result{Value: "checked"}, <-resCh)
+	}
+	assert.Equal(t, int32(2), runs.Load())
+	assert.Equal(t, int64(2), pool.Stats().Joined)
+	assert.Equal(t, 0, pool.Stats().Scheduled)
+
+	// the key is free once the job is done
+	again := pool.Submit(ctx, time.Minute, job, true)
+	assert.Equal(t, &workerpool.Result{Value: "checked"}, <-again)
+	assert.Equal(t, int32(3), runs.Load())
+
+	assert.NoError(t, pool.Shutdown(context.Background()))
+}